@@ -12,6 +12,10 @@ type ThirdPartyAuthService[TContext any] interface {
 
 	// 获取支持的第三方认证方法
 	GetAuthMethods() (*AuthMethodsResponse, error)
+
+	// 绑定关系管理：查看和解除用户已关联的第三方账号
+	ListBindings(ctx context.Context, ctxData TContext, userID uint) ([]*ThirdPartyBinding, error)
+	UnbindProvider(ctx context.Context, ctxData TContext, userID uint, provider string) error
 }
 
 // 🚀 第三方认证请求（泛型context）
@@ -30,6 +34,10 @@ type AuthOptions[TContext any] struct {
 	UserCreationHook   func(ctx TContext, externalInfo *ExternalUserInfo) (interface{}, error) `json:"-"` // 用户创建钩子
 	AccountLinkingHook func(ctx TContext, externalInfo *ExternalUserInfo) (interface{}, error) `json:"-"` // 账号关联钩子
 	PostAuthHook       func(ctx TContext, user interface{}, authInfo *AuthInfo) error          `json:"-"` // 认证后钩子
+
+	// 认证失败钩子（可选）：AuthenticateUser 任一步骤失败时调用，用于审计或风控，不影响返回的原始错误
+	// 凭证验证失败时 authInfo.ExternalUID 为空
+	PostAuthFailureHook func(ctx TContext, authInfo *AuthInfo, err error) `json:"-"`
 }
 
 // 🚀 第三方认证结果（不包含token）
@@ -55,6 +63,17 @@ type AuthInfo struct {
 type ThirdPartyAuthConfig struct {
 	// 第三方凭证提供商
 	CredentialProviders []CredentialProvider
+
+	// 认证限流器（可选），按 "provider:客户端IP" 限制调用第三方验证的频率，为空时不限流
+	// 客户端IP默认取连接的对端地址；部署在反向代理后时需要调用 helper.SetTrustedProxies，
+	// 否则所有请求都按代理地址计数
+	RateLimiter AuthRateLimiter
+}
+
+// AuthRateLimiter 认证限流接口，可基于内存或Redis等实现
+// Allow 返回是否放行本次认证；拒绝时返回建议的重试等待时间
+type AuthRateLimiter interface {
+	Allow(key string) (bool, time.Duration)
 }
 
 // 🚀 第三方凭证提供商接口
@@ -76,6 +95,13 @@ type ThirdPartyAuthRepository[TContext any] interface {
 	GetUserByID(ctx TContext, userID uint) (interface{}, error) // 返回具体的User类型
 }
 
+// StringIDThirdPartyAuthRepository 支持字符串用户ID（如UUID）的仓库接口（可选）
+// 用户对象的ID为字符串时，绑定的创建和已绑定用户的读取使用这些方法，ThirdPartyBinding.UserStringID 保存用户ID
+type StringIDThirdPartyAuthRepository[TContext any] interface {
+	CreateBindingForStringID(ctx TContext, userID string, provider, externalUID string) error
+	GetUserByStringID(ctx TContext, userID string) (interface{}, error)
+}
+
 // 强类型定义
 type ProviderType string
 
@@ -90,3 +116,14 @@ const (
 type UserWithID interface {
 	GetID() uint
 }
+
+// UserWithStringID 字符串用户ID接口 - 用户以UUID等字符串为主键时实现此接口
+type UserWithStringID interface {
+	GetStringID() string
+}
+
+// UserWithPassword 用户密码接口 - 用于判断解绑后用户是否仍可登录
+// 未实现此接口的用户对象视为没有设置密码
+type UserWithPassword interface {
+	HasPassword() bool
+}
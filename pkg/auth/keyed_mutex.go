@@ -0,0 +1,45 @@
+package auth
+
+import "sync"
+
+// keyedMutex 按键加锁的互斥锁，不同键之间互不阻塞
+// 不再使用的键会被清理，避免锁表无限增长
+// 锁只在当前进程内有效：多实例部署时仍需依赖仓库的唯一约束，CreateBinding 冲突后会重新读取绑定
+type keyedMutex struct {
+	mu    sync.Mutex
+	locks map[string]*keyedLock
+}
+
+type keyedLock struct {
+	mu   sync.Mutex
+	refs int
+}
+
+func newKeyedMutex() *keyedMutex {
+	return &keyedMutex{locks: make(map[string]*keyedLock)}
+}
+
+// lock 获取指定键的锁，返回解锁函数
+func (k *keyedMutex) lock(key string) func() {
+	k.mu.Lock()
+	l, ok := k.locks[key]
+	if !ok {
+		l = &keyedLock{}
+		k.locks[key] = l
+	}
+	l.refs++
+	k.mu.Unlock()
+
+	l.mu.Lock()
+
+	return func() {
+		l.mu.Unlock()
+
+		k.mu.Lock()
+		l.refs--
+		if l.refs == 0 {
+			delete(k.locks, key)
+		}
+		k.mu.Unlock()
+	}
+}
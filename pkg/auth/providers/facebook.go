@@ -5,13 +5,43 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"net/url"
+	"time"
 
 	"github.com/flaboy/aira-web/pkg/auth"
 )
 
 // FacebookProvider Facebook OAuth提供商
 type FacebookProvider struct {
-	appID string
+	appID     string
+	cacheTTL  time.Duration
+	cacheSize int
+	cache     *tokenCache
+	client    *http.Client
+}
+
+// Facebook token验证结果缓存的默认配置
+const (
+	defaultFacebookCacheTTL  = 60 * time.Second
+	defaultFacebookCacheSize = 1000
+	defaultFacebookTimeout   = 10 * time.Second
+)
+
+// FacebookOption Facebook提供商配置选项
+type FacebookOption func(*FacebookProvider)
+
+// WithFacebookCacheTTL 设置token验证结果的缓存时间，传入0关闭缓存
+func WithFacebookCacheTTL(ttl time.Duration) FacebookOption {
+	return func(p *FacebookProvider) {
+		p.cacheTTL = ttl
+	}
+}
+
+// WithFacebookCacheSize 设置token验证结果的最大缓存条目数，超出时淘汰最久未使用的条目
+func WithFacebookCacheSize(size int) FacebookOption {
+	return func(p *FacebookProvider) {
+		p.cacheSize = size
+	}
 }
 
 // FacebookUserDetails Facebook用户详情
@@ -26,9 +56,30 @@ type FacebookUserDetails struct {
 	} `json:"picture"`
 }
 
+// WithFacebookHTTPClient 设置调用Graph API使用的HTTP客户端，可用于配置代理或在测试中注入 httptest 客户端
+func WithFacebookHTTPClient(client *http.Client) FacebookOption {
+	return func(p *FacebookProvider) {
+		p.client = client
+	}
+}
+
 // NewFacebookProvider 创建Facebook提供商
-func NewFacebookProvider(appID string) auth.CredentialProvider {
-	return &FacebookProvider{appID: appID}
+// 同一token在缓存时间内重复验证时直接返回缓存结果，不再调用Graph API
+func NewFacebookProvider(appID string, opts ...FacebookOption) auth.CredentialProvider {
+	p := &FacebookProvider{
+		appID:     appID,
+		cacheTTL:  defaultFacebookCacheTTL,
+		cacheSize: defaultFacebookCacheSize,
+		client:    &http.Client{Timeout: defaultFacebookTimeout},
+	}
+	for _, opt := range opts {
+		opt(p)
+	}
+	if p.client == nil {
+		p.client = &http.Client{Timeout: defaultFacebookTimeout}
+	}
+	p.cache = newTokenCache(p.cacheTTL, p.cacheSize)
+	return p
 }
 
 func (p *FacebookProvider) Name() auth.ProviderType {
@@ -42,6 +93,11 @@ func (p *FacebookProvider) ValidateCredential(ctx context.Context, credential ma
 		return nil, fmt.Errorf("missing accessToken field")
 	}
 
+	// 命中缓存时直接返回
+	if userInfo, ok := p.cache.get(accessToken); ok {
+		return userInfo, nil
+	}
+
 	// 调用Facebook Graph API验证Token并获取用户信息
 	userDetails, err := p.fetchUserDetails(ctx, accessToken)
 	if err != nil {
@@ -59,19 +115,20 @@ func (p *FacebookProvider) ValidateCredential(ctx context.Context, credential ma
 		},
 	}
 
+	p.cache.set(accessToken, userInfo)
 	return userInfo, nil
 }
 
 // fetchUserDetails 从Facebook Graph API获取用户详情
 func (p *FacebookProvider) fetchUserDetails(ctx context.Context, accessToken string) (*FacebookUserDetails, error) {
-	url := fmt.Sprintf("https://graph.facebook.com/me?fields=id,name,email,picture&access_token=%s", accessToken)
+	endpoint := "https://graph.facebook.com/me?fields=id,name,email,picture&access_token=" + url.QueryEscape(accessToken)
 
-	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	req, err := http.NewRequestWithContext(ctx, "GET", endpoint, nil)
 	if err != nil {
 		return nil, err
 	}
 
-	resp, err := http.DefaultClient.Do(req)
+	resp, err := p.client.Do(req)
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,149 @@
+package providers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync/atomic"
+	"testing"
+)
+
+// rewriteTransport 将所有请求转发到测试服务器，用于替代 graph.facebook.com、api.weixin.qq.com 等第三方地址
+type rewriteTransport struct {
+	target *url.URL
+}
+
+func (t rewriteTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	req.URL.Scheme = t.target.Scheme
+	req.URL.Host = t.target.Host
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+// newAPIServer 创建模拟第三方API的测试服务器，返回调用计数
+func newAPIServer(t *testing.T, handler http.HandlerFunc) (*http.Client, *int32) {
+	t.Helper()
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		handler(w, r)
+	}))
+	t.Cleanup(server.Close)
+
+	target, _ := url.Parse(server.URL)
+	return &http.Client{Transport: rewriteTransport{target: target}}, &calls
+}
+
+func graphUser(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Write([]byte(`{"id":"fb-1","name":"Alice","email":"alice@example.com","picture":{"data":{"url":"https://example.com/a.png"}}}`))
+}
+
+func TestFacebookProviderCachesValidation(t *testing.T) {
+	client, calls := newAPIServer(t, graphUser)
+	p := NewFacebookProvider("app", WithFacebookHTTPClient(client))
+
+	for i := 0; i < 2; i++ {
+		info, err := p.ValidateCredential(context.Background(), map[string]string{"accessToken": "token-a"})
+		if err != nil {
+			t.Fatalf("validation %d: %v", i, err)
+		}
+		if info.UID != "fb-1" {
+			t.Fatalf("validation %d: UID = %q, want fb-1", i, info.UID)
+		}
+	}
+	if got := atomic.LoadInt32(calls); got != 1 {
+		t.Fatalf("Graph API called %d times, want 1 within the TTL", got)
+	}
+
+	// 不同的token不会命中缓存
+	if _, err := p.ValidateCredential(context.Background(), map[string]string{"accessToken": "token-b"}); err != nil {
+		t.Fatal(err)
+	}
+	if got := atomic.LoadInt32(calls); got != 2 {
+		t.Fatalf("Graph API called %d times, want 2 after a new token", got)
+	}
+}
+
+func TestFacebookProviderCacheDisabled(t *testing.T) {
+	client, calls := newAPIServer(t, graphUser)
+	p := NewFacebookProvider("app", WithFacebookHTTPClient(client), WithFacebookCacheTTL(0))
+
+	for i := 0; i < 2; i++ {
+		if _, err := p.ValidateCredential(context.Background(), map[string]string{"accessToken": "token-a"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got := atomic.LoadInt32(calls); got != 2 {
+		t.Fatalf("Graph API called %d times, want 2 with caching disabled", got)
+	}
+}
+
+func TestFacebookProviderDoesNotCacheFailures(t *testing.T) {
+	client, calls := newAPIServer(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+	})
+	p := NewFacebookProvider("app", WithFacebookHTTPClient(client))
+
+	for i := 0; i < 2; i++ {
+		if _, err := p.ValidateCredential(context.Background(), map[string]string{"accessToken": "expired"}); err == nil {
+			t.Fatal("rejected token should fail validation")
+		}
+	}
+	if got := atomic.LoadInt32(calls); got != 2 {
+		t.Fatalf("Graph API called %d times, failures must not be cached", got)
+	}
+}
+
+func TestFacebookProviderUsesInjectedClient(t *testing.T) {
+	var query url.Values
+	client, calls := newAPIServer(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/me" {
+			t.Errorf("unexpected path %s", r.URL.Path)
+		}
+		query = r.URL.Query()
+		graphUser(w, r)
+	})
+	p := NewFacebookProvider("app", WithFacebookHTTPClient(client))
+
+	info, err := p.ValidateCredential(context.Background(), map[string]string{"accessToken": "a&b=c"})
+	if err != nil {
+		t.Fatalf("ValidateCredential: %v", err)
+	}
+	if atomic.LoadInt32(calls) != 1 {
+		t.Fatal("request should go through the injected client")
+	}
+	if got := query.Get("access_token"); got != "a&b=c" {
+		t.Fatalf("access_token = %q, token must be query-escaped", got)
+	}
+	if info.Email != "alice@example.com" || info.Name != "Alice" || info.Avatar != "https://example.com/a.png" {
+		t.Fatalf("unexpected user info %+v", info)
+	}
+}
+
+func TestFacebookProviderReportsGraphErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		handler http.HandlerFunc
+	}{
+		{"non-200 status", func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusBadRequest) }},
+		{"malformed body", func(w http.ResponseWriter, r *http.Request) { w.Write([]byte("not json")) }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, _ := newAPIServer(t, tt.handler)
+			p := NewFacebookProvider("app", WithFacebookHTTPClient(client))
+			if _, err := p.ValidateCredential(context.Background(), map[string]string{"accessToken": "token"}); err == nil {
+				t.Fatal("Graph API error should fail validation")
+			}
+		})
+	}
+}
+
+func TestFacebookProviderDefaultClientHasTimeout(t *testing.T) {
+	p := NewFacebookProvider("app", WithFacebookHTTPClient(nil)).(*FacebookProvider)
+	if p.client == nil || p.client.Timeout != defaultFacebookTimeout {
+		t.Fatalf("default client = %+v, want timeout %s", p.client, defaultFacebookTimeout)
+	}
+}
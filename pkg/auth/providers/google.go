@@ -3,6 +3,8 @@ package providers
 import (
 	"context"
 	"fmt"
+	"slices"
+	"strings"
 
 	"github.com/flaboy/aira-web/pkg/auth"
 	"google.golang.org/api/idtoken"
@@ -10,12 +12,35 @@ import (
 
 // GoogleProvider Google OAuth提供商
 type GoogleProvider struct {
-	clientID string
+	clientIDs    []string // 允许的audience，第一个用于前端配置
+	hostedDomain string   // 要求的 Google Workspace 域名（hd claim），为空时不校验
+
+	validateToken func(ctx context.Context, idToken, audience string) (*idtoken.Payload, error) // 为空时使用 idtoken.Validate
+}
+
+// GoogleOption Google提供商配置选项
+type GoogleOption func(*GoogleProvider)
+
+// WithHostedDomain 要求 token 的 hd claim 与指定的 Google Workspace 域名一致
+func WithHostedDomain(domain string) GoogleOption {
+	return func(p *GoogleProvider) {
+		p.hostedDomain = domain
+	}
 }
 
 // NewGoogleProvider 创建Google提供商
-func NewGoogleProvider(clientID string) auth.CredentialProvider {
-	return &GoogleProvider{clientID: clientID}
+// 可传入多个客户端ID（如Web和移动端），token 的 aud 匹配其中任意一个即可
+func NewGoogleProvider(clientIDs ...string) auth.CredentialProvider {
+	return &GoogleProvider{clientIDs: clientIDs}
+}
+
+// NewGoogleProviderWithOptions 创建带配置选项的Google提供商
+func NewGoogleProviderWithOptions(clientIDs []string, opts ...GoogleOption) auth.CredentialProvider {
+	p := &GoogleProvider{clientIDs: clientIDs}
+	for _, opt := range opts {
+		opt(p)
+	}
+	return p
 }
 
 func (p *GoogleProvider) Name() auth.ProviderType {
@@ -29,8 +54,12 @@ func (p *GoogleProvider) ValidateCredential(ctx context.Context, credential map[
 		return nil, fmt.Errorf("missing credential field")
 	}
 
-	// 验证Token
-	payload, err := idtoken.Validate(ctx, idToken, p.clientID)
+	// 验证Token（audience 在下方按多个客户端ID校验）
+	validate := p.validateToken
+	if validate == nil {
+		validate = idtoken.Validate
+	}
+	payload, err := validate(ctx, idToken, "")
 	if err != nil {
 		return nil, fmt.Errorf("invalid Google token: %w", err)
 	}
@@ -41,10 +70,16 @@ func (p *GoogleProvider) ValidateCredential(ctx context.Context, credential map[
 	}
 
 	// 验证audience
-	if payload.Audience != p.clientID {
+	if !slices.Contains(p.clientIDs, payload.Audience) {
 		return nil, fmt.Errorf("invalid audience: %v", payload.Audience)
 	}
 
+	// 验证托管域名
+	hostedDomain := getString(payload.Claims, "hd")
+	if p.hostedDomain != "" && !strings.EqualFold(hostedDomain, p.hostedDomain) {
+		return nil, fmt.Errorf("invalid hosted domain: %q, expected %q", hostedDomain, p.hostedDomain)
+	}
+
 	// 提取用户信息
 	userInfo := &auth.ExternalUserInfo{
 		UID:    getString(payload.Claims, "email"),
@@ -54,6 +89,7 @@ func (p *GoogleProvider) ValidateCredential(ctx context.Context, credential map[
 		Metadata: map[string]interface{}{
 			"given_name":  getString(payload.Claims, "given_name"),
 			"family_name": getString(payload.Claims, "family_name"),
+			"hd":          hostedDomain,
 		},
 	}
 
@@ -69,8 +105,16 @@ func (p *GoogleProvider) GetFrontendConfig() *auth.ProviderFrontendConfig {
 	return &auth.ProviderFrontendConfig{
 		Name:        "Google",
 		Description: "Google one tap signin",
-		ConfigJSON:  fmt.Sprintf(`{"client_id":"%s"}`, p.clientID),
+		ConfigJSON:  fmt.Sprintf(`{"client_id":"%s"}`, p.primaryClientID()),
+	}
+}
+
+// primaryClientID 返回用于前端配置的客户端ID
+func (p *GoogleProvider) primaryClientID() string {
+	if len(p.clientIDs) == 0 {
+		return ""
 	}
+	return p.clientIDs[0]
 }
 
 // getString 安全地从claims中获取字符串值
@@ -0,0 +1,81 @@
+package providers
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"google.golang.org/api/idtoken"
+)
+
+// fakeGoogleValidator 返回固定 payload 的 token 校验函数
+func fakeGoogleValidator(payload *idtoken.Payload) func(ctx context.Context, idToken, audience string) (*idtoken.Payload, error) {
+	return func(ctx context.Context, idToken, audience string) (*idtoken.Payload, error) {
+		return payload, nil
+	}
+}
+
+func googlePayload(audience string, emailVerified bool) *idtoken.Payload {
+	return &idtoken.Payload{
+		Issuer:   "https://accounts.google.com",
+		Audience: audience,
+		Claims: map[string]interface{}{
+			"email":          "alice@example.com",
+			"email_verified": emailVerified,
+			"name":           "Alice",
+		},
+	}
+}
+
+func TestGoogleProviderAcceptsAnyClientID(t *testing.T) {
+	p := NewGoogleProvider("web-client", "ios-client").(*GoogleProvider)
+	p.validateToken = fakeGoogleValidator(googlePayload("ios-client", true))
+
+	info, err := p.ValidateCredential(context.Background(), map[string]string{"credential": "token"})
+	if err != nil {
+		t.Fatalf("token for the second client ID should be accepted: %v", err)
+	}
+	if info.Email != "alice@example.com" || info.UID != "alice@example.com" {
+		t.Fatalf("unexpected user info %+v", info)
+	}
+	// 前端配置使用第一个客户端ID
+	if config := p.GetFrontendConfig().ConfigJSON; !strings.Contains(config, "web-client") {
+		t.Fatalf("frontend config %s should use the primary client ID", config)
+	}
+}
+
+func TestGoogleProviderRejectsInvalidTokens(t *testing.T) {
+	tests := []struct {
+		name    string
+		payload *idtoken.Payload
+	}{
+		{"unknown audience", googlePayload("other-client", true)},
+		{"wrong issuer", func() *idtoken.Payload {
+			payload := googlePayload("web-client", true)
+			payload.Issuer = "https://evil.example.com"
+			return payload
+		}()},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := NewGoogleProvider("web-client", "ios-client").(*GoogleProvider)
+			p.validateToken = fakeGoogleValidator(tt.payload)
+			if _, err := p.ValidateCredential(context.Background(), map[string]string{"credential": "token"}); err == nil {
+				t.Fatal("token should be rejected")
+			}
+		})
+	}
+}
+
+func TestGoogleProviderSingleClientIDAndUnverifiedEmail(t *testing.T) {
+	p := NewGoogleProvider("web-client").(*GoogleProvider)
+	p.validateToken = fakeGoogleValidator(googlePayload("web-client", false))
+
+	info, err := p.ValidateCredential(context.Background(), map[string]string{"credential": "token"})
+	if err != nil {
+		t.Fatalf("single client ID provider should accept its own audience: %v", err)
+	}
+	if info.Email != "" {
+		t.Fatalf("unverified email should not be set, got %q", info.Email)
+	}
+}
@@ -0,0 +1,119 @@
+package providers
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"encoding/hex"
+	"sync"
+	"time"
+
+	"github.com/flaboy/aira-web/pkg/auth"
+)
+
+// tokenCache 并发安全、带过期时间和容量上限（LRU淘汰）的令牌验证结果缓存
+// 缓存键为令牌的 SHA-256 哈希，不在内存中保存原始令牌
+type tokenCache struct {
+	mu      sync.Mutex
+	ttl     time.Duration
+	maxSize int
+	entries map[string]*list.Element
+	order   *list.List // 最近使用的在前
+}
+
+type tokenCacheEntry struct {
+	key       string
+	userInfo  *auth.ExternalUserInfo
+	expiresAt time.Time
+}
+
+func newTokenCache(ttl time.Duration, maxSize int) *tokenCache {
+	return &tokenCache{
+		ttl:     ttl,
+		maxSize: maxSize,
+		entries: make(map[string]*list.Element),
+		order:   list.New(),
+	}
+}
+
+// enabled 判断缓存是否启用
+func (c *tokenCache) enabled() bool {
+	return c != nil && c.ttl > 0 && c.maxSize > 0
+}
+
+// get 获取未过期的缓存结果，返回副本
+func (c *tokenCache) get(token string) (*auth.ExternalUserInfo, bool) {
+	if !c.enabled() {
+		return nil, false
+	}
+
+	key := hashToken(token)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	elem, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+
+	entry := elem.Value.(*tokenCacheEntry)
+	if time.Now().After(entry.expiresAt) {
+		c.order.Remove(elem)
+		delete(c.entries, key)
+		return nil, false
+	}
+
+	c.order.MoveToFront(elem)
+	return copyUserInfo(entry.userInfo), true
+}
+
+// set 保存验证结果，超出容量时淘汰最久未使用的条目
+func (c *tokenCache) set(token string, userInfo *auth.ExternalUserInfo) {
+	if !c.enabled() {
+		return
+	}
+
+	key := hashToken(token)
+	entry := &tokenCacheEntry{
+		key:       key,
+		userInfo:  copyUserInfo(userInfo),
+		expiresAt: time.Now().Add(c.ttl),
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if elem, ok := c.entries[key]; ok {
+		elem.Value = entry
+		c.order.MoveToFront(elem)
+		return
+	}
+
+	c.entries[key] = c.order.PushFront(entry)
+	for c.order.Len() > c.maxSize {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*tokenCacheEntry).key)
+	}
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// copyUserInfo 复制用户信息，避免调用方修改缓存中的数据
+func copyUserInfo(userInfo *auth.ExternalUserInfo) *auth.ExternalUserInfo {
+	if userInfo == nil {
+		return nil
+	}
+
+	copied := *userInfo
+	if userInfo.Metadata != nil {
+		copied.Metadata = make(map[string]interface{}, len(userInfo.Metadata))
+		for k, v := range userInfo.Metadata {
+			copied.Metadata[k] = v
+		}
+	}
+	return &copied
+}
@@ -0,0 +1,196 @@
+package providers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/flaboy/aira-web/pkg/auth"
+)
+
+// WeChatProvider 微信OAuth提供商
+type WeChatProvider struct {
+	appID     string
+	appSecret string
+	client    *http.Client
+}
+
+// defaultWeChatTimeout 调用微信API的默认超时
+const defaultWeChatTimeout = 10 * time.Second
+
+// WeChatOption 微信提供商配置选项
+type WeChatOption func(*WeChatProvider)
+
+// WithWeChatHTTPClient 设置调用微信API使用的HTTP客户端，可用于配置代理或在测试中注入 httptest 客户端
+func WithWeChatHTTPClient(client *http.Client) WeChatOption {
+	return func(p *WeChatProvider) {
+		p.client = client
+	}
+}
+
+// WeChatAccessToken 微信授权码换取的访问令牌
+type WeChatAccessToken struct {
+	AccessToken  string `json:"access_token"`
+	ExpiresIn    int    `json:"expires_in"`
+	RefreshToken string `json:"refresh_token"`
+	OpenID       string `json:"openid"`
+	Scope        string `json:"scope"`
+	UnionID      string `json:"unionid"`
+	ErrCode      int    `json:"errcode"`
+	ErrMsg       string `json:"errmsg"`
+}
+
+// WeChatUserDetails 微信用户详情
+type WeChatUserDetails struct {
+	OpenID     string `json:"openid"`
+	UnionID    string `json:"unionid"`
+	Nickname   string `json:"nickname"`
+	HeadImgURL string `json:"headimgurl"`
+	Sex        int    `json:"sex"`
+	Province   string `json:"province"`
+	City       string `json:"city"`
+	Country    string `json:"country"`
+	ErrCode    int    `json:"errcode"`
+	ErrMsg     string `json:"errmsg"`
+}
+
+// NewWeChatProvider 创建微信提供商
+func NewWeChatProvider(appID, appSecret string, opts ...WeChatOption) auth.CredentialProvider {
+	p := &WeChatProvider{appID: appID, appSecret: appSecret}
+	for _, opt := range opts {
+		opt(p)
+	}
+	if p.client == nil {
+		p.client = &http.Client{Timeout: defaultWeChatTimeout}
+	}
+	return p
+}
+
+func (p *WeChatProvider) Name() auth.ProviderType {
+	return auth.ProviderWeChat
+}
+
+func (p *WeChatProvider) ValidateCredential(ctx context.Context, credential map[string]string) (*auth.ExternalUserInfo, error) {
+	// 获取微信授权码
+	code, ok := credential["code"]
+	if !ok {
+		return nil, fmt.Errorf("missing code field")
+	}
+
+	// 使用授权码换取access_token和openid
+	token, err := p.fetchAccessToken(ctx, code)
+	if err != nil {
+		return nil, fmt.Errorf("failed to exchange WeChat code: %w", err)
+	}
+
+	// 获取用户信息
+	userDetails, err := p.fetchUserDetails(ctx, token.AccessToken, token.OpenID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch WeChat user details: %w", err)
+	}
+
+	// 优先使用unionid，同一开放平台下的多个应用共享
+	uid := userDetails.UnionID
+	if uid == "" {
+		uid = token.UnionID
+	}
+	if uid == "" {
+		uid = userDetails.OpenID
+	}
+
+	// 转换为标准用户信息格式
+	userInfo := &auth.ExternalUserInfo{
+		UID:    uid,
+		Name:   userDetails.Nickname,
+		Avatar: userDetails.HeadImgURL,
+		Metadata: map[string]interface{}{
+			"provider": "wechat",
+			"openid":   userDetails.OpenID,
+			"unionid":  userDetails.UnionID,
+			"country":  userDetails.Country,
+			"province": userDetails.Province,
+			"city":     userDetails.City,
+		},
+	}
+
+	return userInfo, nil
+}
+
+// fetchAccessToken 通过授权码获取access_token
+func (p *WeChatProvider) fetchAccessToken(ctx context.Context, code string) (*WeChatAccessToken, error) {
+	query := url.Values{}
+	query.Set("appid", p.appID)
+	query.Set("secret", p.appSecret)
+	query.Set("code", code)
+	query.Set("grant_type", "authorization_code")
+
+	var token WeChatAccessToken
+	if err := p.getJSON(ctx, "https://api.weixin.qq.com/sns/oauth2/access_token?"+query.Encode(), &token); err != nil {
+		return nil, err
+	}
+
+	if token.ErrCode != 0 {
+		return nil, fmt.Errorf("WeChat API error %d: %s", token.ErrCode, token.ErrMsg)
+	}
+	if token.AccessToken == "" || token.OpenID == "" {
+		return nil, fmt.Errorf("WeChat API returned empty access token or openid")
+	}
+
+	return &token, nil
+}
+
+// fetchUserDetails 从微信API获取用户详情
+func (p *WeChatProvider) fetchUserDetails(ctx context.Context, accessToken, openID string) (*WeChatUserDetails, error) {
+	query := url.Values{}
+	query.Set("access_token", accessToken)
+	query.Set("openid", openID)
+
+	var userDetails WeChatUserDetails
+	if err := p.getJSON(ctx, "https://api.weixin.qq.com/sns/userinfo?"+query.Encode(), &userDetails); err != nil {
+		return nil, err
+	}
+
+	if userDetails.ErrCode != 0 {
+		return nil, fmt.Errorf("WeChat API error %d: %s", userDetails.ErrCode, userDetails.ErrMsg)
+	}
+
+	return &userDetails, nil
+}
+
+// getJSON 发送GET请求并解析JSON响应
+func (p *WeChatProvider) getJSON(ctx context.Context, url string, target interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("WeChat API returned status %d", resp.StatusCode)
+	}
+
+	return json.NewDecoder(resp.Body).Decode(target)
+}
+
+func (p *WeChatProvider) GetFrontendConfig() *auth.ProviderFrontendConfig {
+	return &auth.ProviderFrontendConfig{
+		Name:        "WeChat",
+		Description: "WeChat signin",
+		ConfigJSON:  fmt.Sprintf(`{"appid":"%s"}`, p.appID),
+		// 微信JS-SDK不在浏览器中保留登录会话，无需调用SDK登出
+		LogoutScript: `
+			return new Promise(
+				function(resolve, reject){
+					resolve();
+				}
+			)`,
+	}
+}
@@ -0,0 +1,142 @@
+package providers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+// wechatAPI 模拟微信授权码换取令牌和获取用户信息的接口
+type wechatAPI struct {
+	t        *testing.T
+	token    WeChatAccessToken
+	user     WeChatUserDetails
+	tokenReq map[string]string
+	userReq  map[string]string
+}
+
+func (a *wechatAPI) handle(w http.ResponseWriter, r *http.Request) {
+	query := map[string]string{}
+	for key := range r.URL.Query() {
+		query[key] = r.URL.Query().Get(key)
+	}
+
+	var body interface{}
+	switch r.URL.Path {
+	case "/sns/oauth2/access_token":
+		a.tokenReq = query
+		body = a.token
+	case "/sns/userinfo":
+		a.userReq = query
+		body = a.user
+	default:
+		a.t.Errorf("unexpected path %s", r.URL.Path)
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(body)
+}
+
+func newTestWeChat(t *testing.T, api *wechatAPI) (*WeChatProvider, *int32) {
+	api.t = t
+	client, calls := newAPIServer(t, api.handle)
+	return NewWeChatProvider("wx-app", "wx-secret", WithWeChatHTTPClient(client)).(*WeChatProvider), calls
+}
+
+func TestWeChatProviderExchangesCodeForUserInfo(t *testing.T) {
+	api := &wechatAPI{
+		token: WeChatAccessToken{AccessToken: "at-1", OpenID: "open-1", UnionID: "union-token"},
+		user:  WeChatUserDetails{OpenID: "open-1", UnionID: "union-1", Nickname: "小明", HeadImgURL: "https://example.com/a.png", City: "Shenzhen"},
+	}
+	p, calls := newTestWeChat(t, api)
+
+	info, err := p.ValidateCredential(context.Background(), map[string]string{"code": "code-1"})
+	if err != nil {
+		t.Fatalf("ValidateCredential: %v", err)
+	}
+	if atomic.LoadInt32(calls) != 2 {
+		t.Fatalf("WeChat API called %d times, want token and userinfo requests", atomic.LoadInt32(calls))
+	}
+
+	wantToken := map[string]string{"appid": "wx-app", "secret": "wx-secret", "code": "code-1", "grant_type": "authorization_code"}
+	for key, want := range wantToken {
+		if api.tokenReq[key] != want {
+			t.Fatalf("token request %s = %q, want %q", key, api.tokenReq[key], want)
+		}
+	}
+	if api.userReq["access_token"] != "at-1" || api.userReq["openid"] != "open-1" {
+		t.Fatalf("userinfo request = %v, want the exchanged token and openid", api.userReq)
+	}
+
+	if info.UID != "union-1" || info.Name != "小明" || info.Avatar != "https://example.com/a.png" {
+		t.Fatalf("unexpected user info %+v", info)
+	}
+	if info.Metadata["openid"] != "open-1" || info.Metadata["city"] != "Shenzhen" {
+		t.Fatalf("unexpected metadata %v", info.Metadata)
+	}
+}
+
+func TestWeChatProviderUIDFallback(t *testing.T) {
+	tests := []struct {
+		name         string
+		tokenUnionID string
+		userUnionID  string
+		want         string
+	}{
+		{"userinfo unionid", "union-token", "union-user", "union-user"},
+		{"token unionid", "union-token", "", "union-token"},
+		{"openid", "", "", "open-1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			api := &wechatAPI{
+				token: WeChatAccessToken{AccessToken: "at-1", OpenID: "open-1", UnionID: tt.tokenUnionID},
+				user:  WeChatUserDetails{OpenID: "open-1", UnionID: tt.userUnionID},
+			}
+			p, _ := newTestWeChat(t, api)
+
+			info, err := p.ValidateCredential(context.Background(), map[string]string{"code": "code-1"})
+			if err != nil {
+				t.Fatalf("ValidateCredential: %v", err)
+			}
+			if info.UID != tt.want {
+				t.Fatalf("UID = %q, want %q", info.UID, tt.want)
+			}
+		})
+	}
+}
+
+func TestWeChatProviderReportsAPIErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		api  *wechatAPI
+		want string
+	}{
+		{"invalid code", &wechatAPI{token: WeChatAccessToken{ErrCode: 40029, ErrMsg: "invalid code"}}, "40029"},
+		{"empty openid", &wechatAPI{token: WeChatAccessToken{AccessToken: "at-1"}}, "empty access token or openid"},
+		{"userinfo error", &wechatAPI{
+			token: WeChatAccessToken{AccessToken: "at-1", OpenID: "open-1"},
+			user:  WeChatUserDetails{ErrCode: 40003, ErrMsg: "invalid openid"},
+		}, "40003"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, _ := newTestWeChat(t, tt.api)
+			_, err := p.ValidateCredential(context.Background(), map[string]string{"code": "code-1"})
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("error = %v, want it to contain %s", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestWeChatProviderDefaultClientHasTimeout(t *testing.T) {
+	p := NewWeChatProvider("wx-app", "wx-secret").(*WeChatProvider)
+	if p.client == nil || p.client.Timeout != defaultWeChatTimeout {
+		t.Fatalf("default client = %+v, want timeout %s", p.client, defaultWeChatTimeout)
+	}
+}
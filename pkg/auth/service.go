@@ -5,7 +5,10 @@ import (
 	"fmt"
 	"net/http"
 	"reflect"
+	"strings"
 	"time"
+
+	"github.com/flaboy/aira-web/pkg/helper"
 )
 
 // thirdPartyAuthService 第三方认证服务实现（工具化）
@@ -14,6 +17,7 @@ type thirdPartyAuthService[TContext any] struct {
 	credentialProviders map[ProviderType]CredentialProvider
 	providerOrder       []ProviderType // 保持注册顺序
 	repository          ThirdPartyAuthRepository[TContext]
+	bindingLocks        *keyedMutex // 按第三方账号串行化绑定创建
 }
 
 // NewThirdPartyAuthService 创建第三方认证服务（泛型版本）
@@ -32,48 +36,52 @@ func NewThirdPartyAuthService[TContext any](config *ThirdPartyAuthConfig, reposi
 		credentialProviders: providers,
 		providerOrder:       order,
 		repository:          repository,
+		bindingLocks:        newKeyedMutex(),
 	}
 }
 
 // AuthenticateUser 🚀 核心方法：第三方用户认证（工具化，不包含token生成）
 func (s *thirdPartyAuthService[TContext]) AuthenticateUser(ctx context.Context, request *ThirdPartyAuthRequest[TContext]) (*ThirdPartyAuthResult, error) {
+	authInfo := &AuthInfo{
+		Provider:  request.Provider,
+		IP:        getClientIP(ctx),
+		UserAgent: getUserAgent(ctx),
+		Timestamp: time.Now(),
+	}
+
+	result, err := s.authenticate(ctx, request, authInfo)
+	if err != nil && request.Options != nil && request.Options.PostAuthFailureHook != nil {
+		request.Options.PostAuthFailureHook(request.Context, authInfo, err)
+	}
+	return result, err
+}
+
+// authenticate 执行认证流程，并将认证过程中获得的信息写入 authInfo
+func (s *thirdPartyAuthService[TContext]) authenticate(ctx context.Context, request *ThirdPartyAuthRequest[TContext], authInfo *AuthInfo) (*ThirdPartyAuthResult, error) {
+	// 限流检查在调用第三方验证之前执行，避免无效凭证反复触发外部请求
+	if limiter := s.config.RateLimiter; limiter != nil {
+		if allowed, retryAfter := limiter.Allow(request.Provider + ":" + getRateLimitIP(ctx)); !allowed {
+			return nil, fmt.Errorf("%w (retry after %s)", ErrRateLimited, retryAfter)
+		}
+	}
+
 	// 🔧 步骤1：验证第三方凭证
 	externalInfo, err := s.validateCredential(ctx, request.Provider, request.Credential)
 	if err != nil {
 		return nil, fmt.Errorf("credential validation failed: %w", err)
 	}
+	authInfo.ExternalUID = externalInfo.UID
+	normalizeExternalEmail(externalInfo)
+
+	// 同一第三方账号的查找与绑定串行执行，避免并发首次登录重复创建绑定
+	unlock := s.bindingLocks.lock(request.Provider + ":" + externalInfo.UID)
+	defer unlock()
 
 	// 🔧 步骤2：查找现有绑定关系
 	existingBinding, err := s.repository.FindBinding(request.Context, request.Provider, externalInfo.UID)
 	if err == nil {
 		// 已绑定，直接返回用户
-		user, err := s.repository.GetUserByID(request.Context, existingBinding.UserID)
-		if err != nil {
-			return nil, fmt.Errorf("failed to get bound user: %w", err)
-		}
-
-		// 执行认证后钩子
-		if request.Options != nil && request.Options.PostAuthHook != nil {
-			authInfo := &AuthInfo{
-				Provider:    request.Provider,
-				ExternalUID: externalInfo.UID,
-				IP:          getClientIP(ctx),
-				UserAgent:   getUserAgent(ctx),
-				Timestamp:   time.Now(),
-				IsNewUser:   false,
-			}
-
-			if err := request.Options.PostAuthHook(request.Context, user, authInfo); err != nil {
-				return nil, fmt.Errorf("post auth hook failed: %w", err)
-			}
-		}
-
-		return &ThirdPartyAuthResult{
-			Success:      true,
-			User:         user,
-			IsNewUser:    false,
-			ExternalInfo: externalInfo,
-		}, nil
+		return s.authenticateBound(request, authInfo, existingBinding, externalInfo)
 	}
 
 	// 🔧 步骤3：处理新用户或关联现有用户
@@ -108,28 +116,47 @@ func (s *thirdPartyAuthService[TContext]) AuthenticateUser(ctx context.Context,
 	}
 
 	// 获取用户ID（通过类型断言）
-	userID, err := s.extractUserID(user)
+	ref, err := s.extractUserID(user)
 	if err != nil {
 		return nil, fmt.Errorf("failed to extract user ID: %w", err)
 	}
 
 	// 🔗 步骤4：创建绑定关系
-	err = s.repository.CreateBinding(request.Context, userID, request.Provider, externalInfo.UID)
+	err = s.createBinding(request.Context, ref, request.Provider, externalInfo.UID)
 	if err != nil {
+		// 其他实例可能已并发创建了绑定（如唯一约束冲突），重新读取绑定并返回已绑定的用户
+		if existingBinding, findErr := s.repository.FindBinding(request.Context, request.Provider, externalInfo.UID); findErr == nil {
+			return s.authenticateBound(request, authInfo, existingBinding, externalInfo)
+		}
 		return nil, fmt.Errorf("failed to create binding: %w", err)
 	}
 
 	// 📝 步骤5：执行后置钩子
+	authInfo.IsNewUser = isNewUser
 	if request.Options != nil && request.Options.PostAuthHook != nil {
-		authInfo := &AuthInfo{
-			Provider:    request.Provider,
-			ExternalUID: externalInfo.UID,
-			IP:          getClientIP(ctx),
-			UserAgent:   getUserAgent(ctx),
-			Timestamp:   time.Now(),
-			IsNewUser:   isNewUser,
+		if err := request.Options.PostAuthHook(request.Context, user, authInfo); err != nil {
+			return nil, fmt.Errorf("post auth hook failed: %w", err)
 		}
+	}
+
+	return &ThirdPartyAuthResult{
+		Success:      true,
+		User:         user,
+		IsNewUser:    isNewUser,
+		ExternalInfo: externalInfo,
+	}, nil
+}
 
+// authenticateBound 返回已绑定的用户并执行认证后钩子
+func (s *thirdPartyAuthService[TContext]) authenticateBound(request *ThirdPartyAuthRequest[TContext], authInfo *AuthInfo, binding *ThirdPartyBinding, externalInfo *ExternalUserInfo) (*ThirdPartyAuthResult, error) {
+	user, err := s.getBoundUser(request.Context, binding)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get bound user: %w", err)
+	}
+
+	// 执行认证后钩子
+	authInfo.IsNewUser = false
+	if request.Options != nil && request.Options.PostAuthHook != nil {
 		if err := request.Options.PostAuthHook(request.Context, user, authInfo); err != nil {
 			return nil, fmt.Errorf("post auth hook failed: %w", err)
 		}
@@ -138,7 +165,7 @@ func (s *thirdPartyAuthService[TContext]) AuthenticateUser(ctx context.Context,
 	return &ThirdPartyAuthResult{
 		Success:      true,
 		User:         user,
-		IsNewUser:    isNewUser,
+		IsNewUser:    false,
 		ExternalInfo: externalInfo,
 	}, nil
 }
@@ -171,6 +198,50 @@ func (s *thirdPartyAuthService[TContext]) GetAuthMethods() (*AuthMethodsResponse
 	}, nil
 }
 
+// ListBindings 获取用户已绑定的第三方账号
+func (s *thirdPartyAuthService[TContext]) ListBindings(ctx context.Context, ctxData TContext, userID uint) ([]*ThirdPartyBinding, error) {
+	bindings, err := s.repository.ListUserBindings(ctxData, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list bindings: %w", err)
+	}
+	return bindings, nil
+}
+
+// UnbindProvider 解除用户与第三方账号的绑定
+// 如果用户没有设置密码，且这是最后一个绑定，则拒绝解绑，避免用户无法再登录
+func (s *thirdPartyAuthService[TContext]) UnbindProvider(ctx context.Context, ctxData TContext, userID uint, provider string) error {
+	bindings, err := s.repository.ListUserBindings(ctxData, userID)
+	if err != nil {
+		return fmt.Errorf("failed to list bindings: %w", err)
+	}
+
+	found := false
+	for _, binding := range bindings {
+		if binding.Provider == provider {
+			found = true
+			break
+		}
+	}
+	if !found {
+		return ErrBindingNotFound
+	}
+
+	if len(bindings) <= 1 {
+		user, err := s.repository.GetUserByID(ctxData, userID)
+		if err != nil {
+			return fmt.Errorf("failed to get user: %w", err)
+		}
+		if userWithPassword, ok := user.(UserWithPassword); !ok || !userWithPassword.HasPassword() {
+			return ErrLastAuthMethod
+		}
+	}
+
+	if err := s.repository.DeleteBinding(ctxData, userID, provider); err != nil {
+		return fmt.Errorf("failed to delete binding: %w", err)
+	}
+	return nil
+}
+
 // validateCredential 验证第三方凭证
 func (s *thirdPartyAuthService[TContext]) validateCredential(ctx context.Context, provider string, credential map[string]string) (*ExternalUserInfo, error) {
 	// 直接使用前端传入的provider名称，不做任何映射
@@ -183,11 +254,67 @@ func (s *thirdPartyAuthService[TContext]) validateCredential(ctx context.Context
 	return credProvider.ValidateCredential(ctx, credential)
 }
 
+// normalizeExternalEmail 将邮箱统一为去除首尾空白的小写形式，保证账号关联按邮箱匹配时不受大小写影响
+// 原始邮箱与规范化结果不同时保存在 Metadata["raw_email"] 中
+func normalizeExternalEmail(info *ExternalUserInfo) {
+	if info.Email == "" {
+		return
+	}
+	normalized := strings.ToLower(strings.TrimSpace(info.Email))
+	if normalized == info.Email {
+		return
+	}
+	if info.Metadata == nil {
+		info.Metadata = make(map[string]interface{})
+	}
+	info.Metadata["raw_email"] = info.Email
+	info.Email = normalized
+}
+
+// userRef 用户ID，数值ID与字符串ID二选一
+type userRef struct {
+	ID       uint
+	StringID string
+}
+
+// createBinding 按用户ID类型创建绑定，字符串ID要求仓库实现 StringIDThirdPartyAuthRepository
+func (s *thirdPartyAuthService[TContext]) createBinding(ctx TContext, ref userRef, provider, externalUID string) error {
+	if ref.StringID == "" {
+		return s.repository.CreateBinding(ctx, ref.ID, provider, externalUID)
+	}
+	repo, ok := s.repository.(StringIDThirdPartyAuthRepository[TContext])
+	if !ok {
+		return fmt.Errorf("user has string ID but repository does not implement StringIDThirdPartyAuthRepository")
+	}
+	return repo.CreateBindingForStringID(ctx, ref.StringID, provider, externalUID)
+}
+
+// getBoundUser 读取绑定关系对应的用户
+func (s *thirdPartyAuthService[TContext]) getBoundUser(ctx TContext, binding *ThirdPartyBinding) (interface{}, error) {
+	if binding.UserStringID == "" {
+		return s.repository.GetUserByID(ctx, binding.UserID)
+	}
+	repo, ok := s.repository.(StringIDThirdPartyAuthRepository[TContext])
+	if !ok {
+		return nil, fmt.Errorf("binding has string user ID but repository does not implement StringIDThirdPartyAuthRepository")
+	}
+	return repo.GetUserByStringID(ctx, binding.UserStringID)
+}
+
 // extractUserID 从用户对象中提取ID
-func (s *thirdPartyAuthService[TContext]) extractUserID(user interface{}) (uint, error) {
+// 依次尝试 UserWithID、UserWithStringID 接口，再通过反射读取 ID 字段：
+// 数值类型作为数值ID，string 或实现 fmt.Stringer 的类型（如 uuid.UUID）作为字符串ID
+func (s *thirdPartyAuthService[TContext]) extractUserID(user interface{}) (userRef, error) {
 	// 优先使用UserWithID接口
 	if userWithID, ok := user.(UserWithID); ok {
-		return userWithID.GetID(), nil
+		return userRef{ID: userWithID.GetID()}, nil
+	}
+	if userWithStringID, ok := user.(UserWithStringID); ok {
+		id := userWithStringID.GetStringID()
+		if id == "" {
+			return userRef{}, fmt.Errorf("user string ID is empty")
+		}
+		return userRef{StringID: id}, nil
 	}
 
 	// Fallback: 使用反射获取ID字段（向后兼容）
@@ -197,34 +324,53 @@ func (s *thirdPartyAuthService[TContext]) extractUserID(user interface{}) (uint,
 	}
 
 	if v.Kind() != reflect.Struct {
-		return 0, fmt.Errorf("user must be a struct or pointer to struct, got %T", user)
+		return userRef{}, fmt.Errorf("user must be a struct or pointer to struct, got %T", user)
 	}
 
 	// 尝试获取ID字段
 	idField := v.FieldByName("ID")
 	if !idField.IsValid() {
-		return 0, fmt.Errorf("user struct does not have ID field and does not implement UserWithID interface, type: %T", user)
+		return userRef{}, fmt.Errorf("user struct does not have ID field and does not implement UserWithID or UserWithStringID interface, type: %T", user)
 	}
 
-	// 检查ID字段类型并转换为uint
+	// 检查ID字段类型并转换
 	switch idField.Kind() {
 	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
-		return uint(idField.Uint()), nil
+		return userRef{ID: uint(idField.Uint())}, nil
 	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
 		intVal := idField.Int()
 		if intVal < 0 {
-			return 0, fmt.Errorf("ID field cannot be negative: %d", intVal)
+			return userRef{}, fmt.Errorf("ID field cannot be negative: %d", intVal)
+		}
+		return userRef{ID: uint(intVal)}, nil
+	case reflect.String:
+		if idField.String() == "" {
+			return userRef{}, fmt.Errorf("ID field is empty")
+		}
+		return userRef{StringID: idField.String()}, nil
+	}
+
+	if idField.CanInterface() {
+		if stringer, ok := idField.Interface().(fmt.Stringer); ok {
+			return userRef{StringID: stringer.String()}, nil
 		}
-		return uint(intVal), nil
-	default:
-		return 0, fmt.Errorf("ID field must be numeric type, got %s", idField.Kind())
 	}
+	return userRef{}, fmt.Errorf("ID field must be numeric, string or fmt.Stringer type, got %s", idField.Type())
 }
 
-// getClientIP 获取客户端IP
+// getClientIP 获取客户端IP，与 helper.RemoteIP 使用相同的转发头和受信任代理规则，不含端口
 func getClientIP(ctx context.Context) string {
 	if req, ok := ctx.Value("http_request").(*http.Request); ok {
-		return req.RemoteAddr
+		return helper.ClientIP(req)
+	}
+	return "unknown"
+}
+
+// getRateLimitIP 获取限流使用的客户端IP
+// 只在请求来自 helper.SetTrustedProxies 设置的受信任代理时读取转发头，避免伪造 X-Forwarded-For 绕过限流
+func getRateLimitIP(ctx context.Context) string {
+	if req, ok := ctx.Value("http_request").(*http.Request); ok {
+		return helper.TrustedClientIP(req)
 	}
 	return "unknown"
 }
@@ -0,0 +1,285 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/flaboy/aira-web/pkg/helper"
+)
+
+// fakeProvider 按凭证中的 uid/email 返回第三方用户信息
+type fakeProvider struct {
+	mutex sync.Mutex
+	calls int
+}
+
+func (p *fakeProvider) Name() ProviderType { return "fake" }
+
+func (p *fakeProvider) ValidateCredential(ctx context.Context, credential map[string]string) (*ExternalUserInfo, error) {
+	p.mutex.Lock()
+	p.calls++
+	p.mutex.Unlock()
+	return &ExternalUserInfo{UID: credential["uid"], Email: credential["email"]}, nil
+}
+
+func (p *fakeProvider) GetFrontendConfig() *ProviderFrontendConfig {
+	return &ProviderFrontendConfig{Name: "fake"}
+}
+
+type testUser struct {
+	ID    uint
+	Email string
+}
+
+// memoryRepository 内存中的绑定仓库，CreateBinding 对同一第三方账号只允许创建一次
+type memoryRepository struct {
+	mutex    sync.Mutex
+	bindings map[string]*ThirdPartyBinding
+	users    map[uint]*testUser
+	creates  int
+}
+
+func newMemoryRepository() *memoryRepository {
+	return &memoryRepository{bindings: make(map[string]*ThirdPartyBinding), users: make(map[uint]*testUser)}
+}
+
+func (r *memoryRepository) FindBinding(ctx struct{}, provider, externalUID string) (*ThirdPartyBinding, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if binding, ok := r.bindings[provider+":"+externalUID]; ok {
+		return binding, nil
+	}
+	return nil, ErrBindingNotFound
+}
+
+func (r *memoryRepository) CreateBinding(ctx struct{}, userID uint, provider, externalUID string) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.creates++
+	key := provider + ":" + externalUID
+	if _, ok := r.bindings[key]; ok {
+		return fmt.Errorf("duplicate binding %s", key)
+	}
+	r.bindings[key] = &ThirdPartyBinding{UserID: userID, Provider: provider, ExternalUID: externalUID}
+	return nil
+}
+
+func (r *memoryRepository) DeleteBinding(ctx struct{}, userID uint, provider string) error {
+	return nil
+}
+
+func (r *memoryRepository) ListUserBindings(ctx struct{}, userID uint) ([]*ThirdPartyBinding, error) {
+	return nil, nil
+}
+
+func (r *memoryRepository) GetUserByID(ctx struct{}, userID uint) (interface{}, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if user, ok := r.users[userID]; ok {
+		return user, nil
+	}
+	return nil, errors.New("user not found")
+}
+
+// fakeLimiter 放行前 allow 次请求并记录限流键
+type fakeLimiter struct {
+	mutex sync.Mutex
+	allow int
+	keys  []string
+}
+
+func (l *fakeLimiter) Allow(key string) (bool, time.Duration) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	l.keys = append(l.keys, key)
+	if len(l.keys) > l.allow {
+		return false, time.Minute
+	}
+	return true, 0
+}
+
+// requestContext 返回携带来自 remoteAddr、带 X-Forwarded-For 头的请求的 context
+func requestContext(remoteAddr, forwardedFor string) context.Context {
+	req := httptest.NewRequest(http.MethodPost, "/auth", nil)
+	req.RemoteAddr = remoteAddr
+	if forwardedFor != "" {
+		req.Header.Set("X-Forwarded-For", forwardedFor)
+	}
+	return context.WithValue(context.Background(), "http_request", req)
+}
+
+// newTestService 创建使用 fakeProvider 和 memoryRepository 的认证服务，已存在用户 1
+func newTestService(limiter AuthRateLimiter) (ThirdPartyAuthService[struct{}], *fakeProvider, *memoryRepository) {
+	provider := &fakeProvider{}
+	repo := newMemoryRepository()
+	repo.users[1] = &testUser{ID: 1, Email: "alice@example.com"}
+	config := &ThirdPartyAuthConfig{CredentialProviders: []CredentialProvider{provider}, RateLimiter: limiter}
+	return NewThirdPartyAuthService[struct{}](config, repo), provider, repo
+}
+
+// linkingOptions 按邮箱（区分大小写）关联到已存在的用户
+func linkingOptions(repo *memoryRepository) *AuthOptions[struct{}] {
+	return &AuthOptions[struct{}]{
+		AccountLinkingHook: func(ctx struct{}, info *ExternalUserInfo) (interface{}, error) {
+			repo.mutex.Lock()
+			defer repo.mutex.Unlock()
+			for _, user := range repo.users {
+				if user.Email == info.Email {
+					return user, nil
+				}
+			}
+			return nil, nil
+		},
+	}
+}
+
+func TestAuthenticateUserRateLimitExceeded(t *testing.T) {
+	limiter := &fakeLimiter{allow: 1}
+	service, provider, repo := newTestService(limiter)
+	request := &ThirdPartyAuthRequest[struct{}]{
+		Provider:   "fake",
+		Credential: map[string]string{"uid": "u1", "email": "alice@example.com"},
+		Options:    linkingOptions(repo),
+	}
+	ctx := requestContext("192.0.2.10:5000", "")
+
+	if _, err := service.AuthenticateUser(ctx, request); err != nil {
+		t.Fatalf("first attempt: %v", err)
+	}
+	_, err := service.AuthenticateUser(ctx, request)
+	if !errors.Is(err, ErrRateLimited) {
+		t.Fatalf("second attempt error = %v, want ErrRateLimited", err)
+	}
+	if provider.calls != 1 {
+		t.Fatalf("provider called %d times, want 1 (limited request must not reach the provider)", provider.calls)
+	}
+}
+
+func TestRateLimitKeyIgnoresForwardedHeaderWithoutTrustedProxies(t *testing.T) {
+	limiter := &fakeLimiter{allow: 10}
+	service, _, repo := newTestService(limiter)
+	request := &ThirdPartyAuthRequest[struct{}]{
+		Provider:   "fake",
+		Credential: map[string]string{"uid": "u1", "email": "alice@example.com"},
+		Options:    linkingOptions(repo),
+	}
+
+	service.AuthenticateUser(requestContext("192.0.2.10:5000", "203.0.113.1"), request)
+	if got := limiter.keys[0]; got != "fake:192.0.2.10" {
+		t.Fatalf("rate limit key = %s, want peer address", got)
+	}
+
+	if err := helper.SetTrustedProxies([]string{"192.0.2.0/24"}); err != nil {
+		t.Fatal(err)
+	}
+	defer helper.SetTrustedProxies(nil)
+
+	service.AuthenticateUser(requestContext("192.0.2.10:5000", "203.0.113.1"), request)
+	if got := limiter.keys[1]; got != "fake:203.0.113.1" {
+		t.Fatalf("rate limit key = %s, want forwarded client from trusted proxy", got)
+	}
+}
+
+func TestConcurrentAuthenticateCreatesOneBinding(t *testing.T) {
+	service, _, repo := newTestService(nil)
+	request := &ThirdPartyAuthRequest[struct{}]{
+		Provider:   "fake",
+		Credential: map[string]string{"uid": "same-uid", "email": "alice@example.com"},
+		Options:    linkingOptions(repo),
+	}
+
+	const n = 20
+	var wg sync.WaitGroup
+	errs := make(chan error, n)
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := service.AuthenticateUser(context.Background(), request); err != nil {
+				errs <- err
+			}
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		t.Errorf("authenticate: %v", err)
+	}
+	if repo.creates != 1 {
+		t.Fatalf("CreateBinding called %d times, want 1", repo.creates)
+	}
+}
+
+func TestMixedCaseEmailsLinkToSameUser(t *testing.T) {
+	service, _, repo := newTestService(nil)
+
+	for i, email := range []string{"Alice@Example.COM", "  alice@EXAMPLE.com ", "alice@example.com"} {
+		request := &ThirdPartyAuthRequest[struct{}]{
+			Provider:   "fake",
+			Credential: map[string]string{"uid": fmt.Sprintf("uid-%d", i), "email": email},
+			Options:    linkingOptions(repo),
+		}
+		result, err := service.AuthenticateUser(context.Background(), request)
+		if err != nil {
+			t.Fatalf("email %q: %v", email, err)
+		}
+		if user := result.User.(*testUser); user.ID != 1 {
+			t.Fatalf("email %q linked to user %d, want 1", email, user.ID)
+		}
+		if result.ExternalInfo.Email != "alice@example.com" {
+			t.Fatalf("email %q normalized to %q", email, result.ExternalInfo.Email)
+		}
+		raw, hasRaw := result.ExternalInfo.Metadata["raw_email"]
+		if email == "alice@example.com" && hasRaw {
+			t.Fatalf("already normalized email should not record raw_email, got %v", raw)
+		}
+		if email != "alice@example.com" && raw != email {
+			t.Fatalf("raw_email = %v, want original %q", raw, email)
+		}
+	}
+	if len(repo.bindings) != 3 {
+		t.Fatalf("got %d bindings, want 3", len(repo.bindings))
+	}
+}
+
+func TestAutoCreatedUserSeesNormalizedEmail(t *testing.T) {
+	service, _, repo := newTestService(nil)
+	options := linkingOptions(repo)
+	options.AutoCreateUser = true
+	options.UserCreationHook = func(ctx struct{}, info *ExternalUserInfo) (interface{}, error) {
+		repo.mutex.Lock()
+		defer repo.mutex.Unlock()
+		user := &testUser{ID: uint(len(repo.users) + 1), Email: info.Email}
+		repo.users[user.ID] = user
+		return user, nil
+	}
+
+	// 第一次登录创建用户，另一个第三方账号使用不同大小写的同一邮箱时关联到该用户
+	for i, email := range []string{"Bob@Example.com", "BOB@example.com"} {
+		request := &ThirdPartyAuthRequest[struct{}]{
+			Provider:   "fake",
+			Credential: map[string]string{"uid": fmt.Sprintf("bob-%d", i), "email": email},
+			Options:    options,
+		}
+		result, err := service.AuthenticateUser(context.Background(), request)
+		if err != nil {
+			t.Fatalf("email %q: %v", email, err)
+		}
+		if user := result.User.(*testUser); user.ID != 2 || user.Email != "bob@example.com" {
+			t.Fatalf("email %q resolved to %+v, want user 2 with normalized email", email, user)
+		}
+		if result.IsNewUser != (i == 0) {
+			t.Fatalf("email %q: IsNewUser = %v", email, result.IsNewUser)
+		}
+	}
+	if len(repo.users) != 2 {
+		t.Fatalf("got %d users, mixed-case emails must not create duplicates", len(repo.users))
+	}
+}
@@ -72,11 +72,12 @@ type AuthMethodInfo struct {
 
 // 🚀 新增：第三方绑定信息
 type ThirdPartyBinding struct {
-	Provider    string    `json:"provider"`
-	ExternalUID string    `json:"external_uid"`
-	UserID      uint      `json:"user_id"`
-	BoundAt     time.Time `json:"bound_at"`
-	IsActive    bool      `json:"is_active"`
+	Provider     string    `json:"provider"`
+	ExternalUID  string    `json:"external_uid"`
+	UserID       uint      `json:"user_id"`
+	UserStringID string    `json:"user_string_id,omitempty"` // 字符串用户ID（如UUID），为空时使用 UserID
+	BoundAt      time.Time `json:"bound_at"`
+	IsActive     bool      `json:"is_active"`
 }
 
 // 预定义错误
@@ -87,4 +88,7 @@ var (
 	ErrIdentifierNotSupported = &AuthError{Code: "identifier_not_supported", Message: "Identifier type not supported"}
 	ErrInvalidToken           = &AuthError{Code: "invalid_token", Message: "Invalid token"}
 	ErrAccountAlreadyLinked   = &AuthError{Code: "account_already_linked", Message: "Account already linked to another user"}
+	ErrBindingNotFound        = &AuthError{Code: "binding_not_found", Message: "Binding not found"}
+	ErrLastAuthMethod         = &AuthError{Code: "last_auth_method", Message: "Cannot unbind the last remaining authentication method"}
+	ErrRateLimited            = &AuthError{Code: "rate_limited", Message: "Too many authentication attempts, please try again later"}
 )
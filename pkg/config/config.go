@@ -1,8 +1,51 @@
 package config
 
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+)
+
 type FrameworkConfig struct {
-	AiraTablePreifix string `cfg:"AIRA_TABLE_PREFIX" default:"ar_"`
+	AiraTablePreifix string `cfg:"AIRA_TABLE_PREFIX" default:"ar_"` // 拼写错误为兼容保留，读取请使用 TablePrefix
 	FrontURL         string `cfg:"FRONT_URL" default:"http://localhost:3000/"`
+
+	// OpenAPI 文档信息，ApiServers 为逗号分隔的服务地址，可使用 "地址|描述" 的形式附带描述
+	ApiTitle       string `cfg:"API_TITLE" default:"API"`
+	ApiDescription string `cfg:"API_DESCRIPTION" default:""`
+	ApiVersion     string `cfg:"API_VERSION" default:"1.0.0"`
+	ApiServers     string `cfg:"API_SERVERS" default:""`
 }
 
 var Config *FrameworkConfig
+
+// DefaultTablePrefix 未设置配置时使用的表名前缀，与 AiraTablePreifix 的 default 标签一致
+const DefaultTablePrefix = "ar_"
+
+// TablePrefix 返回表名前缀，字段名 AiraTablePreifix 的拼写为兼容保留，新代码应使用此方法
+func (c *FrameworkConfig) TablePrefix() string {
+	if c == nil {
+		return DefaultTablePrefix
+	}
+	return c.AiraTablePreifix
+}
+
+// TablePrefix 返回当前框架配置的表名前缀
+func TablePrefix() string {
+	return Config.TablePrefix()
+}
+
+// tablePrefixPattern 表名前缀只允许字母、数字和下划线，且不能以数字开头
+var tablePrefixPattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// Validate 校验必填及格式受限的配置项
+func (c *FrameworkConfig) Validate() error {
+	u, err := url.Parse(c.FrontURL)
+	if c.FrontURL == "" || err != nil || !u.IsAbs() || u.Host == "" {
+		return fmt.Errorf("invalid FRONT_URL %q: must be an absolute URL such as https://example.com/", c.FrontURL)
+	}
+	if prefix := c.TablePrefix(); prefix != "" && !tablePrefixPattern.MatchString(prefix) {
+		return fmt.Errorf("invalid AIRA_TABLE_PREFIX %q: only letters, digits and underscores are allowed, and it must not start with a digit", prefix)
+	}
+	return nil
+}
@@ -0,0 +1,61 @@
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidateFrontURL(t *testing.T) {
+	tests := []struct {
+		frontURL string
+		valid    bool
+	}{
+		{"https://app.example.com/", true},
+		{"http://localhost:3000", true},
+		{"", false},
+		{"app.example.com", false},
+		{"/relative/path", false},
+		{"https://", false},
+		{"http://[::1", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.frontURL, func(t *testing.T) {
+			cfg := &FrameworkConfig{FrontURL: tt.frontURL, AiraTablePreifix: DefaultTablePrefix}
+			err := cfg.Validate()
+			if tt.valid && err != nil {
+				t.Fatalf("Validate: %v", err)
+			}
+			if !tt.valid && (err == nil || !strings.Contains(err.Error(), "FRONT_URL")) {
+				t.Fatalf("Validate = %v, want a FRONT_URL error", err)
+			}
+		})
+	}
+}
+
+func TestValidateTablePrefix(t *testing.T) {
+	tests := []struct {
+		prefix string
+		valid  bool
+	}{
+		{"ar_", true},
+		{"", true},
+		{"_Tenant1_", true},
+		{"1ar_", false},
+		{"ar-", false},
+		{"ar_; DROP TABLE users; --", false},
+		{"ar`", false},
+		{"ar.", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.prefix, func(t *testing.T) {
+			cfg := &FrameworkConfig{FrontURL: "https://app.example.com/", AiraTablePreifix: tt.prefix}
+			err := cfg.Validate()
+			if tt.valid && err != nil {
+				t.Fatalf("Validate: %v", err)
+			}
+			if !tt.valid && (err == nil || !strings.Contains(err.Error(), "AIRA_TABLE_PREFIX")) {
+				t.Fatalf("Validate = %v, want an AIRA_TABLE_PREFIX error", err)
+			}
+		})
+	}
+}
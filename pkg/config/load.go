@@ -0,0 +1,104 @@
+package config
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"reflect"
+	"strconv"
+)
+
+// Load 从环境变量加载框架配置
+// 每个字段读取 cfg 标签指定的环境变量，未设置时使用 default 标签的值
+func Load() (*FrameworkConfig, error) {
+	cfg := &FrameworkConfig{}
+	used, err := LoadInto(cfg)
+	if err != nil {
+		return nil, err
+	}
+	slog.Info("Framework config loaded from environment", "env", used)
+	return cfg, nil
+}
+
+// LoadInto 按 cfg/default 标签从环境变量填充结构体指针，返回实际读取到的环境变量名
+// 支持 string、整数、浮点数和 bool 字段，没有 cfg 标签的字段保持不变
+func LoadInto(target interface{}) ([]string, error) {
+	v := reflect.ValueOf(target)
+	if v.Kind() != reflect.Ptr || v.Elem().Kind() != reflect.Struct {
+		return nil, fmt.Errorf("config target must be a pointer to struct, got %T", target)
+	}
+	v = v.Elem()
+	t := v.Type()
+
+	var used []string
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name := field.Tag.Get("cfg")
+		if name == "" || !field.IsExported() {
+			continue
+		}
+
+		raw, ok := os.LookupEnv(name)
+		if ok {
+			used = append(used, name)
+		} else {
+			raw = field.Tag.Get("default")
+		}
+
+		if err := setField(v.Field(i), raw); err != nil {
+			return nil, fmt.Errorf("invalid value for %s: %v", name, err)
+		}
+	}
+	return used, nil
+}
+
+// setField 将字符串解析为字段类型后赋值，空字符串对数值字段视为零值
+func setField(field reflect.Value, raw string) error {
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(raw)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if raw == "" {
+			field.SetInt(0)
+			return nil
+		}
+		n, err := strconv.ParseInt(raw, 10, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if raw == "" {
+			field.SetUint(0)
+			return nil
+		}
+		n, err := strconv.ParseUint(raw, 10, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		if raw == "" {
+			field.SetFloat(0)
+			return nil
+		}
+		f, err := strconv.ParseFloat(raw, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetFloat(f)
+	case reflect.Bool:
+		if raw == "" {
+			field.SetBool(false)
+			return nil
+		}
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return err
+		}
+		field.SetBool(b)
+	default:
+		return fmt.Errorf("unsupported field type %s", field.Type())
+	}
+	return nil
+}
@@ -0,0 +1,111 @@
+package config
+
+import (
+	"os"
+	"slices"
+	"testing"
+)
+
+type testConfig struct {
+	Name     string  `cfg:"TEST_CFG_NAME" default:"app"`
+	Port     int     `cfg:"TEST_CFG_PORT" default:"8080"`
+	Workers  uint8   `cfg:"TEST_CFG_WORKERS" default:"4"`
+	Ratio    float64 `cfg:"TEST_CFG_RATIO" default:"0.5"`
+	Debug    bool    `cfg:"TEST_CFG_DEBUG" default:"false"`
+	Optional int     `cfg:"TEST_CFG_OPTIONAL"`
+	Untagged string
+}
+
+// unsetEnv 在测试期间移除环境变量，结束后恢复
+func unsetEnv(t *testing.T, names ...string) {
+	t.Helper()
+	for _, name := range names {
+		t.Setenv(name, "")
+		os.Unsetenv(name)
+	}
+}
+
+func TestLoadIntoParsesEnv(t *testing.T) {
+	unsetEnv(t, "TEST_CFG_DEBUG", "TEST_CFG_OPTIONAL")
+	t.Setenv("TEST_CFG_NAME", "billing")
+	t.Setenv("TEST_CFG_PORT", "9090")
+	t.Setenv("TEST_CFG_WORKERS", "16")
+	t.Setenv("TEST_CFG_RATIO", "0.25")
+
+	cfg := testConfig{Untagged: "keep"}
+	used, err := LoadInto(&cfg)
+	if err != nil {
+		t.Fatalf("LoadInto: %v", err)
+	}
+
+	want := testConfig{Name: "billing", Port: 9090, Workers: 16, Ratio: 0.25, Untagged: "keep"}
+	if cfg != want {
+		t.Fatalf("config = %+v, want %+v", cfg, want)
+	}
+	slices.Sort(used)
+	if !slices.Equal(used, []string{"TEST_CFG_NAME", "TEST_CFG_PORT", "TEST_CFG_RATIO", "TEST_CFG_WORKERS"}) {
+		t.Fatalf("used = %v, want only the variables that were set", used)
+	}
+}
+
+func TestLoadIntoFallsBackToDefaults(t *testing.T) {
+	unsetEnv(t, "TEST_CFG_NAME", "TEST_CFG_PORT", "TEST_CFG_WORKERS", "TEST_CFG_RATIO", "TEST_CFG_DEBUG", "TEST_CFG_OPTIONAL")
+	// 设置为空字符串也视为已设置，数值字段按零值处理
+	t.Setenv("TEST_CFG_PORT", "")
+
+	var cfg testConfig
+	used, err := LoadInto(&cfg)
+	if err != nil {
+		t.Fatalf("LoadInto: %v", err)
+	}
+
+	want := testConfig{Name: "app", Port: 0, Workers: 4, Ratio: 0.5}
+	if cfg != want {
+		t.Fatalf("config = %+v, want %+v", cfg, want)
+	}
+	if !slices.Equal(used, []string{"TEST_CFG_PORT"}) {
+		t.Fatalf("used = %v, want [TEST_CFG_PORT]", used)
+	}
+}
+
+func TestLoadIntoRejectsInvalidValues(t *testing.T) {
+	tests := []struct {
+		name, value string
+	}{
+		{"TEST_CFG_PORT", "eighty"},
+		{"TEST_CFG_WORKERS", "256"},
+		{"TEST_CFG_WORKERS", "-1"},
+		{"TEST_CFG_RATIO", "half"},
+		{"TEST_CFG_DEBUG", "maybe"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name+"="+tt.value, func(t *testing.T) {
+			t.Setenv(tt.name, tt.value)
+			if _, err := LoadInto(&testConfig{}); err == nil {
+				t.Fatalf("%s=%q should be rejected", tt.name, tt.value)
+			}
+		})
+	}
+}
+
+func TestLoadIntoRejectsNonStructPointer(t *testing.T) {
+	var cfg testConfig
+	for _, target := range []interface{}{cfg, new(int), nil} {
+		if _, err := LoadInto(target); err == nil {
+			t.Fatalf("LoadInto(%T) should fail", target)
+		}
+	}
+}
+
+func TestLoadFrameworkConfig(t *testing.T) {
+	unsetEnv(t, "AIRA_TABLE_PREFIX", "API_TITLE", "API_DESCRIPTION", "API_VERSION", "API_SERVERS")
+	t.Setenv("FRONT_URL", "https://app.example.com/")
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if cfg.FrontURL != "https://app.example.com/" || cfg.TablePrefix() != DefaultTablePrefix || cfg.ApiVersion != "1.0.0" {
+		t.Fatalf("unexpected config %+v", cfg)
+	}
+}
@@ -0,0 +1,291 @@
+package crud
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+
+	"github.com/flaboy/pin"
+	"github.com/flaboy/pin/usererrors"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+// Controller 基于 GORM 的通用 CRUD 控制器
+// T 为模型类型，List/Get/Create/Update/Delete 均为 pin 风格的处理函数
+type Controller[T any] struct {
+	DB *gorm.DB
+
+	// NewFilter 返回过滤器结构体指针，用于 List 绑定查询参数；为空时不过滤
+	NewFilter func() interface{}
+	// SortColumns 允许排序的列名白名单
+	SortColumns []string
+	// FilterColumns 允许过滤的列名白名单，为空时不限制列名
+	FilterColumns []string
+	// IDParam 路由中主键参数名，默认为 "id"
+	IDParam string
+
+	// Scopes 应用于所有查询的作用域函数，例如按当前用户限定数据范围
+	// 作用域只追加查询条件，不能阻止请求体写入租户列；需要限定写入的列请使用 ScopeValues
+	Scopes []func(c *pin.Context, db *gorm.DB) *gorm.DB
+	// ScopeValues 返回按列名限定的数据范围（如 {"tenant_id": 1}），作为等值条件应用于所有查询，
+	// 并在 Create/Update 时强制写入记录，请求体无法覆盖
+	ScopeValues func(c *pin.Context) map[string]interface{}
+	// BeforeCreate 创建前调用，返回错误时中止创建
+	BeforeCreate func(c *pin.Context, item *T) error
+	// BeforeUpdate 更新前调用，返回错误时中止更新
+	BeforeUpdate func(c *pin.Context, item *T) error
+	// AfterQuery List 和 Get 查询完成后调用，可用于补充关联数据或脱敏
+	AfterQuery func(c *pin.Context, items []T) error
+}
+
+// NewController 创建 CRUD 控制器
+func NewController[T any](db *gorm.DB, newFilter func() interface{}) *Controller[T] {
+	return &Controller[T]{
+		DB:        db,
+		NewFilter: newFilter,
+		IDParam:   "id",
+	}
+}
+
+// RegisterTo 将 CRUD 路由注册到 Routes：
+// GET path、GET path/:id、POST path、PUT path/:id、DELETE path/:id
+func (ctl *Controller[T]) RegisterTo(routes *Routes, path string) *Routes {
+	itemPath := path + "/:" + ctl.idParam()
+	return routes.
+		GET(path, pin.HandleFunc(ctl.List)).
+		GET(itemPath, pin.HandleFunc(ctl.Get)).
+		POST(path, pin.HandleFunc(ctl.Create)).
+		PUT(itemPath, pin.HandleFunc(ctl.Update)).
+		DELETE(itemPath, pin.HandleFunc(ctl.Delete))
+}
+
+// List 按查询参数过滤、排序并分页返回列表，Pagination.Total 为匹配的总数
+func (ctl *Controller[T]) List(c *pin.Context) error {
+	var filter interface{}
+	if ctl.NewFilter != nil {
+		filter = ctl.NewFilter()
+	}
+
+	q, err := BindQueryWithOptions(c, filter, QueryOptions{
+		AllowedSortColumns:   ctl.SortColumns,
+		AllowedFilterColumns: ctl.FilterColumns,
+	})
+	if err != nil {
+		return usererrors.New("invalid_query", err.Error())
+	}
+
+	db := q.ApplyToGorm(ctl.scoped(c).Model(new(T)))
+	if db.Error != nil {
+		return usererrors.New("invalid_query", db.Error.Error())
+	}
+
+	var total int64
+	if err := db.Session(&gorm.Session{}).Count(&total).Error; err != nil {
+		return err
+	}
+	q.SetTotal(total)
+
+	items := make([]T, 0)
+	db = q.ApplySort(db, ctl.SortColumns)
+	if err := q.ApplyPagination(db).Find(&items).Error; err != nil {
+		return err
+	}
+
+	if ctl.AfterQuery != nil {
+		if err := ctl.AfterQuery(c, items); err != nil {
+			return err
+		}
+	}
+
+	return c.Render(&QueryResult{
+		Items:      items,
+		Pagination: q.Pagination,
+	})
+}
+
+// Get 按主键返回单条记录
+func (ctl *Controller[T]) Get(c *pin.Context) error {
+	item, err := ctl.find(c)
+	if err != nil {
+		return err
+	}
+
+	if ctl.AfterQuery != nil {
+		items := []T{*item}
+		if err := ctl.AfterQuery(c, items); err != nil {
+			return err
+		}
+		item = &items[0]
+	}
+
+	return c.Render(item)
+}
+
+// Create 从 JSON 请求体创建记录，请求体中的主键被忽略，ScopeValues 中的列被强制写入
+func (ctl *Controller[T]) Create(c *pin.Context) error {
+	item := new(T)
+	if err := c.ShouldBindJSON(item); err != nil {
+		return usererrors.New("invalid_request", "Invalid request body")
+	}
+
+	sch, err := ctl.schema()
+	if err != nil {
+		return err
+	}
+	rv := reflect.ValueOf(item).Elem()
+	for _, field := range sch.PrimaryFields {
+		if err := field.Set(c.Request.Context(), rv, reflect.Zero(field.FieldType).Interface()); err != nil {
+			return err
+		}
+	}
+	if err := ctl.forceScopeValues(c, sch, rv); err != nil {
+		return err
+	}
+
+	if ctl.BeforeCreate != nil {
+		if err := ctl.BeforeCreate(c, item); err != nil {
+			return err
+		}
+	}
+
+	if err := ctl.scoped(c).Create(item).Error; err != nil {
+		return err
+	}
+	return c.Render(item)
+}
+
+// Update 按主键加载记录，用 JSON 请求体中的字段覆盖后保存
+// 主键和 ScopeValues 中的列在绑定后恢复为原值，请求体无法修改；作用域内没有更新到记录时返回 not_found
+func (ctl *Controller[T]) Update(c *pin.Context) error {
+	item, err := ctl.find(c)
+	if err != nil {
+		return err
+	}
+
+	sch, err := ctl.schema()
+	if err != nil {
+		return err
+	}
+	ctx := c.Request.Context()
+	rv := reflect.ValueOf(item).Elem()
+	primaryValues := make([]interface{}, len(sch.PrimaryFields))
+	for i, field := range sch.PrimaryFields {
+		primaryValues[i], _ = field.ValueOf(ctx, rv)
+	}
+
+	if err := c.ShouldBindJSON(item); err != nil {
+		return usererrors.New("invalid_request", "Invalid request body")
+	}
+
+	for i, field := range sch.PrimaryFields {
+		if err := field.Set(ctx, rv, primaryValues[i]); err != nil {
+			return err
+		}
+	}
+	if err := ctl.forceScopeValues(c, sch, rv); err != nil {
+		return err
+	}
+
+	if ctl.BeforeUpdate != nil {
+		if err := ctl.BeforeUpdate(c, item); err != nil {
+			return err
+		}
+	}
+
+	// 使用 Updates 而不是 Save：Save 在没有更新到记录时会回退为插入
+	result := ctl.scoped(c).Model(item).Select("*").Updates(item)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return usererrors.New("not_found", "Record not found")
+	}
+	return c.Render(item)
+}
+
+// Delete 按主键删除记录
+func (ctl *Controller[T]) Delete(c *pin.Context) error {
+	item, err := ctl.find(c)
+	if err != nil {
+		return err
+	}
+
+	if err := ctl.scoped(c).Delete(item).Error; err != nil {
+		return err
+	}
+	return c.Render(map[string]interface{}{"message": "Record deleted successfully"})
+}
+
+// find 在作用域内按主键查找记录
+func (ctl *Controller[T]) find(c *pin.Context) (*T, error) {
+	id := c.Param(ctl.idParam())
+	if id == "" {
+		return nil, usererrors.New("invalid_request", "Missing record id")
+	}
+
+	item := new(T)
+	err := ctl.scoped(c).Where(clause.Eq{Column: clause.PrimaryColumn, Value: id}).First(item).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, usererrors.New("not_found", "Record not found")
+	}
+	if err != nil {
+		return nil, err
+	}
+	return item, nil
+}
+
+// scoped 返回应用了 ScopeValues 和所有作用域函数的查询
+func (ctl *Controller[T]) scoped(c *pin.Context) *gorm.DB {
+	db := ctl.DB.WithContext(c.Request.Context())
+	if ctl.ScopeValues != nil {
+		values := ctl.ScopeValues(c)
+		columns := make([]string, 0, len(values))
+		for column := range values {
+			columns = append(columns, column)
+		}
+		sort.Strings(columns)
+		for _, column := range columns {
+			db = db.Where(clause.Eq{Column: clause.Column{Name: column}, Value: values[column]})
+		}
+	}
+	for _, scope := range ctl.Scopes {
+		db = scope(c, db)
+	}
+	return db
+}
+
+// schema 解析模型的 GORM schema
+func (ctl *Controller[T]) schema() (*schema.Schema, error) {
+	stmt := &gorm.Statement{DB: ctl.DB}
+	if err := stmt.Parse(new(T)); err != nil {
+		return nil, err
+	}
+	return stmt.Schema, nil
+}
+
+// forceScopeValues 将 ScopeValues 写入记录，覆盖请求体中的值
+func (ctl *Controller[T]) forceScopeValues(c *pin.Context, sch *schema.Schema, rv reflect.Value) error {
+	if ctl.ScopeValues == nil {
+		return nil
+	}
+	for column, value := range ctl.ScopeValues(c) {
+		field := sch.LookUpField(column)
+		if field == nil {
+			return fmt.Errorf("scope column %s not found in model %s", column, sch.Name)
+		}
+		if err := field.Set(c.Request.Context(), rv, value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (ctl *Controller[T]) idParam() string {
+	if ctl.IDParam == "" {
+		return "id"
+	}
+	return ctl.IDParam
+}
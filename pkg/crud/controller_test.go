@@ -0,0 +1,340 @@
+package crud
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/flaboy/pin"
+	"github.com/flaboy/pin/usererrors"
+	"github.com/gin-gonic/gin"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+type scopedRecord struct {
+	ID       uint   `json:"id"`
+	TenantID uint   `json:"tenant_id"`
+	Name     string `json:"name"`
+}
+
+// fakeResult 伪数据库对一条语句的响应
+type fakeResult struct {
+	columns  []string
+	rows     [][]driver.Value
+	insertID int64
+	affected int64
+}
+
+// fakeStatement 伪数据库收到的语句
+type fakeStatement struct {
+	sql  string
+	args []driver.Value
+}
+
+// fakeDB 记录收到的语句并由 respond 返回结果的 database/sql 驱动，用于在没有真实数据库时测试控制器
+type fakeDB struct {
+	mu         sync.Mutex
+	statements []fakeStatement
+	respond    func(sql string, args []driver.Value) fakeResult
+}
+
+func (f *fakeDB) Connect(context.Context) (driver.Conn, error) { return &fakeConn{db: f}, nil }
+func (f *fakeDB) Driver() driver.Driver                        { return fakeDriver{db: f} }
+
+func (f *fakeDB) run(query string, named []driver.NamedValue) fakeResult {
+	args := make([]driver.Value, len(named))
+	for i, arg := range named {
+		args[i] = arg.Value
+	}
+	f.mu.Lock()
+	f.statements = append(f.statements, fakeStatement{sql: query, args: args})
+	f.mu.Unlock()
+	if f.respond == nil {
+		return fakeResult{}
+	}
+	return f.respond(query, args)
+}
+
+// find 返回第一条以 prefix 开头的语句
+func (f *fakeDB) find(prefix string) (fakeStatement, bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, stmt := range f.statements {
+		if strings.HasPrefix(stmt.sql, prefix) {
+			return stmt, true
+		}
+	}
+	return fakeStatement{}, false
+}
+
+type fakeDriver struct{ db *fakeDB }
+
+func (d fakeDriver) Open(string) (driver.Conn, error) { return &fakeConn{db: d.db}, nil }
+
+type fakeConn struct{ db *fakeDB }
+
+func (c *fakeConn) Prepare(string) (driver.Stmt, error) {
+	return nil, errors.New("fake db: prepared statements are not supported")
+}
+func (c *fakeConn) Close() error              { return nil }
+func (c *fakeConn) Begin() (driver.Tx, error) { return fakeTx{}, nil }
+
+func (c *fakeConn) QueryContext(_ context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	result := c.db.run(query, args)
+	return &fakeRows{columns: result.columns, rows: result.rows}, nil
+}
+
+func (c *fakeConn) ExecContext(_ context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	result := c.db.run(query, args)
+	return fakeExecResult{insertID: result.insertID, affected: result.affected}, nil
+}
+
+type fakeTx struct{}
+
+func (fakeTx) Commit() error   { return nil }
+func (fakeTx) Rollback() error { return nil }
+
+type fakeExecResult struct{ insertID, affected int64 }
+
+func (r fakeExecResult) LastInsertId() (int64, error) { return r.insertID, nil }
+func (r fakeExecResult) RowsAffected() (int64, error) { return r.affected, nil }
+
+type fakeRows struct {
+	columns []string
+	rows    [][]driver.Value
+}
+
+func (r *fakeRows) Columns() []string { return r.columns }
+func (r *fakeRows) Close() error      { return nil }
+
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if len(r.rows) == 0 {
+		return io.EOF
+	}
+	copy(dest, r.rows[0])
+	r.rows = r.rows[1:]
+	return nil
+}
+
+var scopedRecordColumns = []string{"id", "tenant_id", "name"}
+
+// newFakeController 返回连接伪数据库、限定 tenant_id = 1 的控制器
+func newFakeController(t *testing.T, fake *fakeDB) *Controller[scopedRecord] {
+	t.Helper()
+	db, err := gorm.Open(mysql.New(mysql.Config{
+		Conn:                      sql.OpenDB(fake),
+		SkipInitializeWithVersion: true,
+	}), &gorm.Config{DisableAutomaticPing: true, Logger: logger.Discard})
+	if err != nil {
+		t.Fatalf("open fake db: %v", err)
+	}
+	ctl := NewController[scopedRecord](db, nil)
+	ctl.SortColumns = []string{"id", "name"}
+	ctl.ScopeValues = func(*pin.Context) map[string]interface{} {
+		return map[string]interface{}{"tenant_id": uint(1)}
+	}
+	return ctl
+}
+
+// newControllerContext 返回带路由主键参数和 JSON 请求体的上下文
+func newControllerContext(method, target, id, body string) (*pin.Context, *httptest.ResponseRecorder) {
+	gin.SetMode(gin.TestMode)
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(method, target, strings.NewReader(body))
+	c.Request.Header.Set("Content-Type", "application/json")
+	if id != "" {
+		c.Params = gin.Params{{Key: "id", Value: id}}
+	}
+	return &pin.Context{Context: c}, w
+}
+
+// decodeData 解析响应中的 data 字段
+func decodeData(t *testing.T, w *httptest.ResponseRecorder, v interface{}) {
+	t.Helper()
+	var rsp struct {
+		Data json.RawMessage `json:"data"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &rsp); err != nil {
+		t.Fatalf("decode response %s: %v", w.Body.String(), err)
+	}
+	if err := json.Unmarshal(rsp.Data, v); err != nil {
+		t.Fatalf("decode data %s: %v", rsp.Data, err)
+	}
+}
+
+// assertUserError 断言 err 为指定代码的用户错误
+func assertUserError(t *testing.T, err error, code string) {
+	t.Helper()
+	var userErr *usererrors.Error
+	if !errors.As(err, &userErr) || userErr.Code() != code {
+		t.Fatalf("error = %v, want user error %q", err, code)
+	}
+}
+
+// respondRecord 只在主键和 tenant_id 都匹配时返回 record，模拟按作用域查找
+func respondRecord(record scopedRecord) func(string, []driver.Value) fakeResult {
+	return func(query string, args []driver.Value) fakeResult {
+		if !strings.HasPrefix(query, "SELECT") {
+			return fakeResult{affected: 1}
+		}
+		if len(args) < 2 || args[0] != int64(record.TenantID) || args[1] != fmt.Sprint(record.ID) {
+			return fakeResult{columns: scopedRecordColumns}
+		}
+		return fakeResult{
+			columns: scopedRecordColumns,
+			rows:    [][]driver.Value{{int64(record.ID), int64(record.TenantID), record.Name}},
+		}
+	}
+}
+
+func TestControllerListReturnsTotalAndPage(t *testing.T) {
+	fake := &fakeDB{respond: func(query string, args []driver.Value) fakeResult {
+		if strings.HasPrefix(query, "SELECT count(*)") {
+			return fakeResult{columns: []string{"count(*)"}, rows: [][]driver.Value{{int64(5)}}}
+		}
+		return fakeResult{
+			columns: scopedRecordColumns,
+			rows:    [][]driver.Value{{int64(3), int64(1), "c"}, {int64(4), int64(1), "d"}},
+		}
+	}}
+	ctl := newFakeController(t, fake)
+
+	c, w := newControllerContext(http.MethodGet, "/items?pagination-page=2&pagination-size=2&sort.column=id", "", "")
+	if err := ctl.List(c); err != nil {
+		t.Fatalf("List: %v", err)
+	}
+
+	var result struct {
+		Items      []scopedRecord `json:"items"`
+		Pagination Pagination     `json:"pagination"`
+	}
+	decodeData(t, w, &result)
+	if want := (Pagination{Page: 2, Size: 2, Total: 5}); result.Pagination != want {
+		t.Errorf("pagination = %+v, want %+v", result.Pagination, want)
+	}
+	if len(result.Items) != 2 || result.Items[0].ID != 3 || result.Items[1].ID != 4 {
+		t.Errorf("items = %+v, want records 3 and 4", result.Items)
+	}
+
+	count, ok := fake.find("SELECT count(*)")
+	if !ok || !strings.Contains(count.sql, "WHERE `tenant_id` = ?") || strings.Contains(count.sql, "LIMIT") {
+		t.Errorf("count query = %q, want scoped and unpaginated", count.sql)
+	}
+	list, ok := fake.find("SELECT *")
+	if !ok {
+		t.Fatal("no list query executed")
+	}
+	if !strings.Contains(list.sql, "WHERE `tenant_id` = ?") || !strings.HasSuffix(list.sql, "ORDER BY `id` LIMIT ? OFFSET ?") {
+		t.Errorf("list query = %q, want scoped, sorted and paginated", list.sql)
+	}
+	if want := []driver.Value{int64(1), int64(2), int64(2)}; !reflect.DeepEqual(list.args, want) {
+		t.Errorf("list args = %v, want %v", list.args, want)
+	}
+}
+
+func TestControllerCreateIgnoresClientPrimaryKeyAndScope(t *testing.T) {
+	fake := &fakeDB{respond: func(string, []driver.Value) fakeResult {
+		return fakeResult{insertID: 42, affected: 1}
+	}}
+	ctl := newFakeController(t, fake)
+
+	c, w := newControllerContext(http.MethodPost, "/items", "", `{"id":99,"tenant_id":2,"name":"new"}`)
+	if err := ctl.Create(c); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+
+	insert, ok := fake.find("INSERT")
+	if !ok {
+		t.Fatal("no insert executed")
+	}
+	if want := []driver.Value{int64(1), "new"}; !reflect.DeepEqual(insert.args, want) {
+		t.Errorf("insert %q args = %v, want %v", insert.sql, insert.args, want)
+	}
+
+	var created scopedRecord
+	decodeData(t, w, &created)
+	if want := (scopedRecord{ID: 42, TenantID: 1, Name: "new"}); created != want {
+		t.Errorf("created = %+v, want %+v", created, want)
+	}
+}
+
+func TestControllerUpdateStaysInScope(t *testing.T) {
+	fake := &fakeDB{respond: respondRecord(scopedRecord{ID: 1, TenantID: 1, Name: "old"})}
+	ctl := newFakeController(t, fake)
+
+	c, w := newControllerContext(http.MethodPut, "/items/1", "1", `{"id":7,"tenant_id":2,"name":"renamed"}`)
+	if err := ctl.Update(c); err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+
+	update, ok := fake.find("UPDATE")
+	if !ok {
+		t.Fatal("no update executed")
+	}
+	if !strings.Contains(update.sql, "WHERE `tenant_id` = ? AND `id` = ?") {
+		t.Errorf("update query = %q, want scoped by tenant_id and id", update.sql)
+	}
+	if want := []driver.Value{int64(1), "renamed", int64(1), int64(1)}; !reflect.DeepEqual(update.args, want) {
+		t.Errorf("update args = %v, want %v", update.args, want)
+	}
+
+	var updated scopedRecord
+	decodeData(t, w, &updated)
+	if want := (scopedRecord{ID: 1, TenantID: 1, Name: "renamed"}); updated != want {
+		t.Errorf("updated = %+v, want %+v", updated, want)
+	}
+}
+
+func TestControllerUpdateNotFoundWhenNothingUpdated(t *testing.T) {
+	respond := respondRecord(scopedRecord{ID: 1, TenantID: 1, Name: "old"})
+	fake := &fakeDB{respond: func(query string, args []driver.Value) fakeResult {
+		if strings.HasPrefix(query, "UPDATE") {
+			return fakeResult{}
+		}
+		return respond(query, args)
+	}}
+	ctl := newFakeController(t, fake)
+
+	c, _ := newControllerContext(http.MethodPut, "/items/1", "1", `{"name":"renamed"}`)
+	assertUserError(t, ctl.Update(c), "not_found")
+}
+
+func TestControllerGetAndDeleteMissingOrOutOfScope(t *testing.T) {
+	records := map[string]scopedRecord{
+		"missing":      {ID: 2, TenantID: 1, Name: "another record"},
+		"out of scope": {ID: 1, TenantID: 2, Name: "other tenant"},
+	}
+	for name, record := range records {
+		t.Run(name, func(t *testing.T) {
+			fake := &fakeDB{respond: respondRecord(record)}
+			ctl := newFakeController(t, fake)
+
+			c, _ := newControllerContext(http.MethodGet, "/items/1", "1", "")
+			assertUserError(t, ctl.Get(c), "not_found")
+
+			c, _ = newControllerContext(http.MethodDelete, "/items/1", "1", "")
+			assertUserError(t, ctl.Delete(c), "not_found")
+
+			query, ok := fake.find("SELECT")
+			if !ok || !strings.Contains(query.sql, "WHERE `tenant_id` = ? AND `scoped_records`.`id` = ?") {
+				t.Errorf("lookup query = %q, want scoped by tenant_id and id", query.sql)
+			}
+			if stmt, ok := fake.find("DELETE"); ok {
+				t.Errorf("unexpected delete executed: %q", stmt.sql)
+			}
+		})
+	}
+}
@@ -2,8 +2,11 @@ package crud
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"reflect"
+	"slices"
 	"strconv"
 	"strings"
 	"time"
@@ -38,6 +41,7 @@ type QueryResult struct {
 	Items      interface{} `json:"items"`
 	Pagination *Pagination `json:"pagination"`
 	Addition   interface{} `json:"addition"`
+	NextCursor string      `json:"next_cursor,omitempty"` // 游标分页模式下的下一页游标，为空表示没有更多数据
 }
 
 func (f *QueryForm) BindJSON(c *pin.Context) error {
@@ -83,6 +87,12 @@ type QueryContext struct {
 	Filter     interface{}
 	Pagination *Pagination
 	Sort       *Sort
+	Sorts      []Sort // 多列排序，按优先级排列
+	Cursor     string // 游标分页模式下的游标（上一页最后一条记录排序键的 base64 编码），为空表示第一页
+
+	// AllowedFilterColumns 允许过滤的列名白名单，不为空时 ApplyToGorm 拒绝其他列名
+	// Filter 为 map（如通过 Parse 从请求体解析）时列名来自客户端，应当设置白名单
+	AllowedFilterColumns []string
 }
 
 func (q *QueryContext) Parse(f JsonRawMessage, c *pin.Context) error {
@@ -95,15 +105,36 @@ func (q *QueryContext) Parse(f JsonRawMessage, c *pin.Context) error {
 	return nil
 }
 
+// GetFilter 以 map 形式返回过滤条件
+// Filter 为结构体（或结构体指针，如 BindQuery 绑定的过滤器）时，返回所有非零值字段，
+// 键名与查询参数名一致，标记为 filter:"-" 的字段会被忽略
 func (q *QueryContext) GetFilter() (map[string]interface{}, error) {
 	filter := make(map[string]interface{})
 	val := reflect.ValueOf(q.Filter)
+	for val.Kind() == reflect.Ptr && !val.IsNil() {
+		val = val.Elem()
+	}
 	if val.Kind() == reflect.Map {
 		for _, key := range val.MapKeys() {
 			filter[key.String()] = val.MapIndex(key).Interface()
 		}
 		return filter, nil
 	}
+	if val.Kind() == reflect.Struct {
+		typ := val.Type()
+		for i := 0; i < typ.NumField(); i++ {
+			fieldType := typ.Field(i)
+			field := val.Field(i)
+			if !fieldType.IsExported() || fieldType.Tag.Get("filter") == "-" || field.IsZero() {
+				continue
+			}
+			for field.Kind() == reflect.Ptr {
+				field = field.Elem()
+			}
+			filter[getParamName(fieldType)] = field.Interface()
+		}
+		return filter, nil
+	}
 	return nil, fmt.Errorf("filter is not a valid map")
 }
 
@@ -164,11 +195,33 @@ func (q *QueryContext) SetTotal(total int64) {
 	q.Pagination.Total = total
 }
 
-func (q *QueryContext) ToQueryForm() *QueryForm {
-	return &QueryForm{
-		Filter:     json.RawMessage(q.Filter.([]byte)),
-		Pagination: *q.Pagination,
+// ToQueryForm 将 QueryContext 转换为 QueryForm
+// Filter 可以是 []byte、string、json.RawMessage，或任意可被 json.Marshal 编码的值（map、结构体等）
+func (q *QueryContext) ToQueryForm() (*QueryForm, error) {
+	form := &QueryForm{}
+	if q.Pagination != nil {
+		form.Pagination = *q.Pagination
 	}
+
+	switch filter := q.Filter.(type) {
+	case nil:
+	case json.RawMessage:
+		form.Filter = filter
+	case JsonRawMessage:
+		form.Filter = json.RawMessage(filter)
+	case []byte:
+		form.Filter = json.RawMessage(filter)
+	case string:
+		form.Filter = json.RawMessage(filter)
+	default:
+		data, err := json.Marshal(filter)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal filter: %v", err)
+		}
+		form.Filter = data
+	}
+
+	return form, nil
 }
 
 func (q *QueryContext) FromQueryForm(f *QueryForm) {
@@ -187,10 +240,23 @@ func (q *QueryContext) String() string {
 	return b.String()
 }
 
+// QueryOptions 查询绑定选项
+type QueryOptions struct {
+	// AllowedSortColumns 允许排序的列名白名单，为空时不限制列名
+	AllowedSortColumns []string
+	// AllowedFilterColumns 允许过滤的列名白名单，写入 QueryContext.AllowedFilterColumns
+	AllowedFilterColumns []string
+}
+
 // BindQuery 简化的查询绑定 API
 // 自动解析查询参数到过滤器结构体，并返回 QueryContext
 // filter 必须是指向结构体的指针
 func BindQuery(c *pin.Context, filter interface{}) (*QueryContext, error) {
+	return BindQueryWithOptions(c, filter, QueryOptions{})
+}
+
+// BindQueryWithOptions 与 BindQuery 相同，额外按选项校验排序列名并设置过滤列名白名单
+func BindQueryWithOptions(c *pin.Context, filter interface{}, opts QueryOptions) (*QueryContext, error) {
 	// 绑定过滤器
 	if err := bindFilterFromQuery(c, filter); err != nil {
 		return nil, err
@@ -208,20 +274,140 @@ func BindQuery(c *pin.Context, filter interface{}) (*QueryContext, error) {
 	// 创建排序信息
 	var sort *Sort
 	if column := c.Query("sort.column"); column != "" {
-		order := c.DefaultQuery("sort.order", "asc")
+		order, err := normalizeSortOrder(column, c.DefaultQuery("sort.order", "asc"))
+		if err != nil {
+			return nil, err
+		}
 		sort = &Sort{
 			Column: column,
 			Order:  order,
 		}
 	}
 
+	// 多列排序：sort=status:asc,created_at:desc
+	sorts, err := ParseSorts(c.Query("sort"))
+	if err != nil {
+		return nil, err
+	}
+	if len(sorts) == 0 && sort != nil {
+		sorts = []Sort{*sort}
+	}
+	if err := validateSortColumns(sorts, opts.AllowedSortColumns); err != nil {
+		return nil, err
+	}
+	// Sort 取自校验后的第一个排序列，未经白名单校验的 sort.column 不会暴露给调用方
+	sort = nil
+	if len(sorts) > 0 {
+		first := sorts[0]
+		sort = &first
+	}
+
 	return &QueryContext{
 		Filter:     filter,
 		Pagination: pagination,
 		Sort:       sort,
+		Sorts:      sorts,
+		Cursor:     c.Query("cursor"),
+
+		AllowedFilterColumns: opts.AllowedFilterColumns,
 	}, nil
 }
 
+// Bind 统一的查询绑定 API，同时支持查询参数和 JSON 请求体
+// 先按 BindQuery 解析查询参数，再在 Content-Type 为 application/json 时解析 QueryForm 格式的请求体
+// （{"filter": {...}, "pagination": {...}}）覆盖到同一个 QueryContext 上
+// 优先级：请求体中出现的过滤字段和非零分页参数覆盖查询参数中的同名值，其余字段保留查询参数的值
+func Bind(c *pin.Context, filter interface{}) (*QueryContext, error) {
+	q, err := BindQuery(c, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	if c.Request.Body == nil || c.ContentType() != "application/json" {
+		return q, nil
+	}
+
+	var form QueryForm
+	if err := json.NewDecoder(c.Request.Body).Decode(&form); err != nil {
+		if errors.Is(err, io.EOF) {
+			return q, nil
+		}
+		return nil, fmt.Errorf("invalid query body: %v", err)
+	}
+
+	if len(form.Filter) > 0 && string(form.Filter) != "null" && filter != nil {
+		if err := json.Unmarshal(form.Filter, filter); err != nil {
+			return nil, fmt.Errorf("invalid filter: %v", err)
+		}
+	}
+	if form.Pagination.Page > 0 {
+		q.Pagination.Page = form.Pagination.Page
+	}
+	if form.Pagination.Size > 0 {
+		q.Pagination.Size = form.Pagination.Size
+	}
+
+	return q, nil
+}
+
+// ParseSorts 解析多列排序字符串，例如 "status:asc,created_at:desc"
+// 未指定排序方向时默认为 asc，方向只允许 asc 或 desc
+func ParseSorts(sortStr string) ([]Sort, error) {
+	if sortStr == "" {
+		return nil, nil
+	}
+
+	var sorts []Sort
+	for _, item := range strings.Split(sortStr, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+
+		column, order, _ := strings.Cut(item, ":")
+		column = strings.TrimSpace(column)
+		if column == "" {
+			return nil, fmt.Errorf("invalid sort item %q: missing column", item)
+		}
+		order, err := normalizeSortOrder(column, order)
+		if err != nil {
+			return nil, err
+		}
+
+		sorts = append(sorts, Sort{
+			Column: column,
+			Order:  order,
+		})
+	}
+
+	return sorts, nil
+}
+
+// normalizeSortOrder 校验排序方向并统一为小写，空值默认为 asc，只允许 asc 或 desc（不区分大小写）
+func normalizeSortOrder(column, order string) (string, error) {
+	normalized := strings.ToLower(strings.TrimSpace(order))
+	if normalized == "" {
+		return "asc", nil
+	}
+	if normalized != "asc" && normalized != "desc" {
+		return "", fmt.Errorf("invalid sort order %q for column %s: must be asc or desc", order, column)
+	}
+	return normalized, nil
+}
+
+// validateSortColumns 校验排序列名是否在白名单中，白名单为空时不校验
+func validateSortColumns(sorts []Sort, allowed []string) error {
+	if len(allowed) == 0 {
+		return nil
+	}
+	for _, s := range sorts {
+		if !slices.Contains(allowed, s.Column) {
+			return fmt.Errorf("sort column %q is not allowed", s.Column)
+		}
+	}
+	return nil
+}
+
 // bindFilterFromQuery 从查询参数绑定到指定的 Filter 结构体
 // filter 必须是指向结构体的指针
 func bindFilterFromQuery(c *pin.Context, filter interface{}) error {
@@ -280,14 +466,42 @@ func getParamName(field reflect.StructField) string {
 
 // setFieldValue 设置字段值
 func setFieldValue(field reflect.Value, fieldType reflect.StructField, value string) error {
+	// time.Time 需要在按 Kind 分派之前单独处理
+	if field.Type() == reflect.TypeOf(time.Time{}) {
+		return setTimeValue(field, fieldType, value)
+	}
+
 	switch field.Kind() {
 	case reflect.String:
 		field.SetString(value)
 
-	case reflect.Int, reflect.Int64:
-		if intVal, err := strconv.ParseInt(value, 10, 64); err == nil {
-			field.SetInt(intVal)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		intVal, err := strconv.ParseInt(value, 10, field.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("invalid integer value for %s: %q", getParamName(fieldType), value)
 		}
+		field.SetInt(intVal)
+
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		uintVal, err := strconv.ParseUint(value, 10, field.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("invalid integer value for %s: %q", getParamName(fieldType), value)
+		}
+		field.SetUint(uintVal)
+
+	case reflect.Bool:
+		boolVal, err := parseBool(value)
+		if err != nil {
+			return fmt.Errorf("invalid bool value for %s: %q", getParamName(fieldType), value)
+		}
+		field.SetBool(boolVal)
+
+	case reflect.Float32, reflect.Float64:
+		floatVal, err := strconv.ParseFloat(value, field.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("invalid number value for %s: %q", getParamName(fieldType), value)
+		}
+		field.SetFloat(floatVal)
 
 	case reflect.Slice:
 		return setSliceValue(field, fieldType, value)
@@ -299,6 +513,33 @@ func setFieldValue(field reflect.Value, fieldType reflect.StructField, value str
 	return nil
 }
 
+// parseBool 解析布尔值，支持 true/false/1/0
+func parseBool(value string) (bool, error) {
+	switch strings.ToLower(value) {
+	case "true", "1":
+		return true, nil
+	case "false", "0":
+		return false, nil
+	}
+	return false, fmt.Errorf("invalid bool value: %q", value)
+}
+
+// setTimeValue 设置 time.Time 类型的值
+// 默认使用 RFC3339 格式，可通过 filter:"layout:2006-01-02" 指定其他格式
+func setTimeValue(field reflect.Value, fieldType reflect.StructField, value string) error {
+	layout := time.RFC3339
+	if tagLayout := getFilterTagOption(fieldType, "layout"); tagLayout != "" {
+		layout = tagLayout
+	}
+
+	t, err := time.Parse(layout, value)
+	if err != nil {
+		return fmt.Errorf("invalid time value for %s: %q does not match layout %q", getParamName(fieldType), value, layout)
+	}
+	field.Set(reflect.ValueOf(t))
+	return nil
+}
+
 // setSliceValue 设置切片类型的值
 func setSliceValue(field reflect.Value, fieldType reflect.StructField, value string) error {
 	elemType := field.Type().Elem()
@@ -321,16 +562,13 @@ func setSliceValue(field reflect.Value, fieldType reflect.StructField, value str
 		elemValue := reflect.New(elemType).Elem()
 
 		switch elemType.Kind() {
-		case reflect.Int:
-			if intVal, err := strconv.Atoi(part); err == nil {
-				elemValue.SetInt(int64(intVal))
-				slice = reflect.Append(slice, elemValue)
-			}
-		case reflect.Int64:
-			if int64Val, err := strconv.ParseInt(part, 10, 64); err == nil {
-				elemValue.SetInt(int64Val)
-				slice = reflect.Append(slice, elemValue)
+		case reflect.Int, reflect.Int64:
+			intVal, err := strconv.ParseInt(part, 10, elemType.Bits())
+			if err != nil {
+				return fmt.Errorf("invalid integer value for %s: %q", getParamName(fieldType), part)
 			}
+			elemValue.SetInt(intVal)
+			slice = reflect.Append(slice, elemValue)
 		case reflect.String:
 			elemValue.SetString(part)
 			slice = reflect.Append(slice, elemValue)
@@ -343,33 +581,77 @@ func setSliceValue(field reflect.Value, fieldType reflect.StructField, value str
 
 // getDelimiter 获取分隔符
 func getDelimiter(fieldType reflect.StructField) string {
+	if delimiter := getFilterTagOption(fieldType, "delimiter"); delimiter != "" {
+		return delimiter
+	}
+	return "," // 默认分隔符
+}
+
+// getFilterTagOption 解析 filter tag 中的选项值，例如 "delimiter:;" 或 "layout:2006-01-02"
+// 只按第一个冒号分割，选项值本身可以包含冒号
+func getFilterTagOption(fieldType reflect.StructField, key string) string {
 	if filterTag := fieldType.Tag.Get("filter"); filterTag != "" {
-		// 解析 filter tag，例如 "delimiter:;"
-		parts := strings.Split(filterTag, ":")
-		if len(parts) == 2 && parts[0] == "delimiter" {
+		parts := strings.SplitN(filterTag, ":", 2)
+		if len(parts) == 2 && parts[0] == key {
 			return parts[1]
 		}
 	}
-	return "," // 默认分隔符
+	return ""
+}
+
+// DateRangeOptions 日期范围的解析选项
+type DateRangeOptions struct {
+	// InclusiveEnd 为 true 时，日期格式的结束值扩展到当天最后一秒，例如 "2024-01-31" 解析为 2024-01-31 23:59:59
+	InclusiveEnd bool
+	// Location 解析不带时区的日期和时间时使用的时区，为nil时使用UTC；RFC3339 时间使用其自带的时区
+	Location *time.Location
+}
+
+// dateRangeLayouts 日期范围支持的时间格式，dateOnly 表示按天解析（InclusiveEnd 只作用于此类格式）
+var dateRangeLayouts = []struct {
+	layout   string
+	dateOnly bool
+}{
+	{time.RFC3339, false},
+	{"2006-01-02T15:04:05", false},
+	{"2006-01-02 15:04:05", false},
+	{"2006-01-02", true},
 }
 
 // ParseDateRange 解析日期范围字符串为时间戳数组
 // 支持格式：
 // 1. "timestamp1,timestamp2" - 直接的时间戳
-// 2. "2024-01-01,2024-01-31" - 日期字符串
+// 2. "2024-01-01,2024-01-31" - 日期字符串，按UTC解析
+// 3. "2024-01-01T08:00:00+08:00,2024-01-31T18:00:00Z" - RFC3339 时间
+// 输入为空时返回 (nil, nil)；非空但格式错误（不是两个值或无法解析）时返回错误
 func ParseDateRange(dateRangeStr string) ([]int64, error) {
-	if dateRangeStr == "" {
+	return ParseDateRangeWithOptions(dateRangeStr, DateRangeOptions{})
+}
+
+// ParseDateRangeInLocation 与 ParseDateRange 相同，但不带时区的日期和时间按 loc 解析
+// 例如按租户时区计算"今天"的起止时间
+func ParseDateRangeInLocation(dateRangeStr string, loc *time.Location) ([]int64, error) {
+	return ParseDateRangeWithOptions(dateRangeStr, DateRangeOptions{Location: loc})
+}
+
+// ParseDateRangeWithOptions 按选项解析日期范围字符串，格式与 ParseDateRange 相同
+func ParseDateRangeWithOptions(dateRangeStr string, opts DateRangeOptions) ([]int64, error) {
+	if strings.TrimSpace(dateRangeStr) == "" {
 		return nil, nil
 	}
 
 	parts := strings.Split(dateRangeStr, ",")
 	if len(parts) != 2 {
-		return nil, nil
+		return nil, fmt.Errorf("invalid date range %q: expected two values separated by a comma", dateRangeStr)
 	}
 
-	var result []int64
+	loc := opts.Location
+	if loc == nil {
+		loc = time.UTC
+	}
 
-	for _, part := range parts {
+	result := make([]int64, 0, 2)
+	for i, part := range parts {
 		part = strings.TrimSpace(part)
 
 		// 尝试解析为时间戳
@@ -378,15 +660,27 @@ func ParseDateRange(dateRangeStr string) ([]int64, error) {
 			continue
 		}
 
-		// 尝试解析为日期字符串
-		if t, err := time.Parse("2006-01-02", part); err == nil {
-			result = append(result, t.Unix())
-			continue
+		// 尝试按支持的时间格式解析
+		t, dateOnly, ok := parseDateRangeValue(part, loc)
+		if !ok {
+			return nil, fmt.Errorf("invalid date range value %q: expected a unix timestamp, a 2006-01-02 date or an RFC3339 time", part)
 		}
-
-		// 如果都解析失败，返回 nil
-		return nil, nil
+		if i == 1 && dateOnly && opts.InclusiveEnd {
+			// 按日历日计算，夏令时切换当天也能得到正确的当天最后一秒
+			t = t.AddDate(0, 0, 1).Add(-time.Second)
+		}
+		result = append(result, t.Unix())
 	}
 
 	return result, nil
 }
+
+// parseDateRangeValue 依次尝试 dateRangeLayouts 中的格式
+func parseDateRangeValue(value string, loc *time.Location) (time.Time, bool, bool) {
+	for _, l := range dateRangeLayouts {
+		if t, err := time.ParseInLocation(l.layout, value, loc); err == nil {
+			return t, l.dateOnly, true
+		}
+	}
+	return time.Time{}, false, false
+}
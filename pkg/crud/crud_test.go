@@ -0,0 +1,410 @@
+package crud
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/flaboy/pin"
+	"github.com/gin-gonic/gin"
+)
+
+// newTestContext 创建指向给定请求的 pin.Context
+func newTestContext(req *http.Request) *pin.Context {
+	gin.SetMode(gin.TestMode)
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = req
+	return &pin.Context{Context: c}
+}
+
+func TestBindQueryWithOptionsSortColumnMustBeAllowed(t *testing.T) {
+	opts := QueryOptions{AllowedSortColumns: []string{"name"}}
+
+	c := newTestContext(httptest.NewRequest(http.MethodGet, "/?sort.column=password", nil))
+	if _, err := BindQueryWithOptions(c, nil, opts); err == nil {
+		t.Fatal("expected sort.column outside allow-list to be rejected")
+	}
+}
+
+func TestBindQueryWithOptionsSortDerivedFromValidatedSorts(t *testing.T) {
+	opts := QueryOptions{AllowedSortColumns: []string{"name", "status"}}
+
+	// sort 参数优先，sort.column 中未校验的列名不能出现在 q.Sort 中
+	c := newTestContext(httptest.NewRequest(http.MethodGet, "/?sort=status:desc,name&sort.column=password", nil))
+	q, err := BindQueryWithOptions(c, nil, opts)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if q.Sort == nil || q.Sort.Column != "status" || q.Sort.Order != "desc" {
+		t.Fatalf("Sort = %+v, want status desc", q.Sort)
+	}
+	if len(q.Sorts) != 2 {
+		t.Fatalf("Sorts = %+v, want 2 entries", q.Sorts)
+	}
+}
+
+func TestBindQuerySortOrder(t *testing.T) {
+	tests := []struct {
+		name    string
+		query   url.Values
+		want    []Sort
+		wantErr bool
+	}{
+		{"asc", url.Values{"sort.column": {"name"}, "sort.order": {"asc"}}, []Sort{{"name", "asc"}}, false},
+		{"desc", url.Values{"sort.column": {"name"}, "sort.order": {"desc"}}, []Sort{{"name", "desc"}}, false},
+		{"default order", url.Values{"sort.column": {"name"}}, []Sort{{"name", "asc"}}, false},
+		{"upper case", url.Values{"sort.column": {"name"}, "sort.order": {"DESC"}}, []Sort{{"name", "desc"}}, false},
+		{"mixed case", url.Values{"sort.column": {"name"}, "sort.order": {"Asc"}}, []Sort{{"name", "asc"}}, false},
+		{"multi-column mixed case", url.Values{"sort": {"name:DeSc,status"}}, []Sort{{"name", "desc"}, {"status", "asc"}}, false},
+		{"injection", url.Values{"sort.column": {"name"}, "sort.order": {";DROP TABLE x"}}, nil, true},
+		{"injection after order", url.Values{"sort.column": {"name"}, "sort.order": {"desc; DROP TABLE x"}}, nil, true},
+		{"unknown token", url.Values{"sort.column": {"name"}, "sort.order": {"up"}}, nil, true},
+		{"multi-column injection", url.Values{"sort": {"name:desc--"}}, nil, true},
+		{"multi-column unknown token", url.Values{"sort": {"name:asc,status:up"}}, nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, bind := range []func(*pin.Context) (*QueryContext, error){
+				func(c *pin.Context) (*QueryContext, error) { return BindQuery(c, nil) },
+				func(c *pin.Context) (*QueryContext, error) {
+					return BindQueryWithOptions(c, nil, QueryOptions{AllowedSortColumns: []string{"name", "status"}})
+				},
+			} {
+				c := newTestContext(httptest.NewRequest(http.MethodGet, "/?"+tt.query.Encode(), nil))
+				q, err := bind(c)
+				if tt.wantErr {
+					if err == nil {
+						t.Fatalf("query %s: expected an error, got sorts %+v", tt.query.Encode(), q.Sorts)
+					}
+					continue
+				}
+				if err != nil {
+					t.Fatalf("query %s: %v", tt.query.Encode(), err)
+				}
+				if !reflect.DeepEqual(q.Sorts, tt.want) {
+					t.Fatalf("query %s: Sorts = %+v, want %+v", tt.query.Encode(), q.Sorts, tt.want)
+				}
+				if q.Sort == nil || *q.Sort != tt.want[0] {
+					t.Fatalf("query %s: Sort = %+v, want %+v", tt.query.Encode(), q.Sort, tt.want[0])
+				}
+			}
+		})
+	}
+}
+
+func TestParseSortsRejectsBadOrder(t *testing.T) {
+	for _, input := range []string{"name:up", "name:ascending", "name:asc desc", "name:1", ":asc"} {
+		if sorts, err := ParseSorts(input); err == nil {
+			t.Fatalf("ParseSorts(%q) = %+v, want error", input, sorts)
+		}
+	}
+}
+
+type typedFilter struct {
+	Name     string    `json:"name"`
+	Age      int       `json:"age"`
+	Count    int64     `json:"count"`
+	Small    int8      `json:"small"`
+	Limit    uint      `json:"limit"`
+	Active   bool      `json:"active"`
+	Score    float64   `json:"score"`
+	IDs      []int     `json:"ids"`
+	Tags     []string  `json:"tags" filter:"delimiter:|"`
+	Since    time.Time `json:"since"`
+	Day      time.Time `json:"day" filter:"layout:2006-01-02"`
+	Untagged string
+}
+
+// bindTyped 按查询字符串绑定 typedFilter
+func bindTyped(rawQuery string) (*typedFilter, error) {
+	filter := &typedFilter{}
+	c := newTestContext(httptest.NewRequest(http.MethodGet, "/?"+rawQuery, nil))
+	_, err := BindQuery(c, filter)
+	return filter, err
+}
+
+func TestBindQueryFieldTypes(t *testing.T) {
+	query := url.Values{
+		"name":     {"acme"},
+		"age":      {"30"},
+		"count":    {"9000000000"},
+		"small":    {"-8"},
+		"limit":    {"5"},
+		"active":   {"1"},
+		"score":    {"4.5"},
+		"ids":      {"1, 2,3"},
+		"tags":     {"a|b"},
+		"since":    {"2024-01-02T03:04:05+08:00"},
+		"day":      {"2024-02-29"},
+		"untagged": {"x"},
+	}
+	filter, err := bindTyped(query.Encode())
+	if err != nil {
+		t.Fatalf("BindQuery: %v", err)
+	}
+
+	want := &typedFilter{
+		Name:     "acme",
+		Age:      30,
+		Count:    9000000000,
+		Small:    -8,
+		Limit:    5,
+		Active:   true,
+		Score:    4.5,
+		IDs:      []int{1, 2, 3},
+		Tags:     []string{"a", "b"},
+		Since:    time.Date(2024, 1, 2, 3, 4, 5, 0, time.FixedZone("", 8*3600)),
+		Day:      time.Date(2024, 2, 29, 0, 0, 0, 0, time.UTC),
+		Untagged: "x",
+	}
+	if !filter.Since.Equal(want.Since) || !filter.Day.Equal(want.Day) {
+		t.Fatalf("times = %v, %v, want %v, %v", filter.Since, filter.Day, want.Since, want.Day)
+	}
+	filter.Since, filter.Day, want.Since, want.Day = time.Time{}, time.Time{}, time.Time{}, time.Time{}
+	if !reflect.DeepEqual(filter, want) {
+		t.Fatalf("filter = %+v, want %+v", filter, want)
+	}
+}
+
+func TestBindQueryMalformedValues(t *testing.T) {
+	cases := map[string]string{
+		"age=abc":          "age",
+		"age=1.5":          "age",
+		"small=300":        "small",
+		"limit=-1":         "limit",
+		"ids=1,x":          "ids",
+		"active=yes":       "active",
+		"score=high":       "score",
+		"since=2024-01-02": "since",
+		"day=02/29/2024":   "day",
+	}
+	for rawQuery, field := range cases {
+		_, err := bindTyped(rawQuery)
+		if err == nil || !strings.Contains(err.Error(), field) {
+			t.Errorf("%s: error = %v, want error naming %s", rawQuery, err, field)
+		}
+	}
+}
+
+type mergeFilter struct {
+	Name   string `json:"name"`
+	Status string `json:"status"`
+}
+
+func TestBindBodyOverridesQueryWhenBothPresent(t *testing.T) {
+	body := `{"filter": {"name": "from-body"}, "pagination": {"size": 50}}`
+	req := httptest.NewRequest(http.MethodPost, "/?name=from-query&status=active&pagination-page=3&pagination-size=10", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+
+	filter := &mergeFilter{}
+	q, err := Bind(newTestContext(req), filter)
+	if err != nil {
+		t.Fatalf("Bind: %v", err)
+	}
+
+	// 请求体中出现的字段和非零分页参数覆盖查询参数，其余保留查询参数的值
+	if filter.Name != "from-body" || filter.Status != "active" {
+		t.Fatalf("filter = %+v, want name from body and status from query", filter)
+	}
+	if q.Pagination.Page != 3 || q.Pagination.Size != 50 {
+		t.Fatalf("pagination = %+v, want page 3 from query and size 50 from body", q.Pagination)
+	}
+}
+
+func TestBindIgnoresBodyWithoutJSONContentType(t *testing.T) {
+	req := httptest.NewRequest(http.MethodPost, "/?name=from-query", strings.NewReader(`{"filter": {"name": "from-body"}}`))
+	req.Header.Set("Content-Type", "text/plain")
+
+	filter := &mergeFilter{}
+	if _, err := Bind(newTestContext(req), filter); err != nil {
+		t.Fatalf("Bind: %v", err)
+	}
+	if filter.Name != "from-query" {
+		t.Fatalf("name = %q, want from-query", filter.Name)
+	}
+}
+
+func TestBindEmptyJSONBodyKeepsQuery(t *testing.T) {
+	req := httptest.NewRequest(http.MethodPost, "/?name=from-query", strings.NewReader(""))
+	req.Header.Set("Content-Type", "application/json")
+
+	filter := &mergeFilter{}
+	if _, err := Bind(newTestContext(req), filter); err != nil {
+		t.Fatalf("Bind: %v", err)
+	}
+	if filter.Name != "from-query" {
+		t.Fatalf("name = %q, want from-query", filter.Name)
+	}
+}
+
+func TestToQueryFormFilterSources(t *testing.T) {
+	const want = `{"name":"acme"}`
+	cases := map[string]interface{}{
+		"nil":             nil,
+		"json.RawMessage": json.RawMessage(want),
+		"JsonRawMessage":  JsonRawMessage(want),
+		"[]byte":          []byte(want),
+		"string":          want,
+		"map":             map[string]interface{}{"name": "acme"},
+		"struct":          mergeFilter{Name: "acme"},
+		"struct pointer": &struct {
+			Name string `json:"name"`
+		}{Name: "acme"},
+	}
+	for name, filter := range cases {
+		q := &QueryContext{Filter: filter, Pagination: &Pagination{Page: 2, Size: 10}}
+		form, err := q.ToQueryForm()
+		if err != nil {
+			t.Errorf("%s: %v", name, err)
+			continue
+		}
+		if form.Pagination.Page != 2 || form.Pagination.Size != 10 {
+			t.Errorf("%s: pagination = %+v", name, form.Pagination)
+		}
+
+		expected := want
+		switch filter.(type) {
+		case nil:
+			expected = ""
+		case mergeFilter:
+			expected = `{"name":"acme","status":""}`
+		}
+		if string(form.Filter) != expected {
+			t.Errorf("%s: filter = %s, want %s", name, form.Filter, expected)
+		}
+	}
+}
+
+func TestToQueryFormUnmarshalableFilter(t *testing.T) {
+	q := &QueryContext{Filter: map[string]interface{}{"ch": make(chan int)}}
+	if _, err := q.ToQueryForm(); err == nil {
+		t.Fatal("expected marshal error for channel filter")
+	}
+}
+
+func TestParseDateRange(t *testing.T) {
+	jan1 := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC).Unix()
+	jan31 := time.Date(2024, 1, 31, 0, 0, 0, 0, time.UTC).Unix()
+
+	tests := []struct {
+		name    string
+		input   string
+		want    []int64
+		wantErr bool
+	}{
+		{"empty", "", nil, false},
+		{"blank", "   ", nil, false},
+		{"timestamps", "1700000000,1700086400", []int64{1700000000, 1700086400}, false},
+		{"dates in UTC", "2024-01-01,2024-01-31", []int64{jan1, jan31}, false},
+		{"spaces around values", " 2024-01-01 , 2024-01-31 ", []int64{jan1, jan31}, false},
+		{"mixed timestamp and date", "1700000000,2024-01-31", []int64{1700000000, jan31}, false},
+		{"rfc3339 with offset", "2024-01-01T08:00:00+08:00,2024-01-31T00:00:00Z", []int64{jan1, jan31}, false},
+		{"datetime without zone", "2024-01-01 00:00:00,2024-01-31T00:00:00", []int64{jan1, jan31}, false},
+		{"single value", "2024-01-01", nil, true},
+		{"trailing comma", "2024-01-01,", nil, true},
+		{"three values", "1,2,3", nil, true},
+		{"unparseable start", "yesterday,2024-01-31", nil, true},
+		{"unparseable end", "2024-01-01,2024-13-01", nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseDateRange(tt.input)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("ParseDateRange(%q) = %v, want error", tt.input, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseDateRange(%q): %v", tt.input, err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("ParseDateRange(%q) = %v, want %v", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseDateRangeInclusiveEnd(t *testing.T) {
+	opts := DateRangeOptions{InclusiveEnd: true}
+
+	got, err := ParseDateRangeWithOptions("2024-01-01,2024-01-31", opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []int64{
+		time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC).Unix(),
+		time.Date(2024, 1, 31, 23, 59, 59, 0, time.UTC).Unix(),
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+
+	// 只扩展日期格式的结束值，时间戳和带时间的值保持不变
+	got, err = ParseDateRangeWithOptions("1700000000,2024-01-31T12:00:00Z", opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want = []int64{1700000000, time.Date(2024, 1, 31, 12, 0, 0, 0, time.UTC).Unix()}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+}
+
+func TestParseDateRangeAcrossDST(t *testing.T) {
+	loc, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skipf("time zone data unavailable: %v", err)
+	}
+
+	// 2024-03-10 夏令时开始（当天23小时），2024-11-03 夏令时结束（当天25小时）
+	got, err := ParseDateRangeInLocation("2024-03-09,2024-03-11", loc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := time.Date(2024, 3, 9, 5, 0, 0, 0, time.UTC).Unix(); got[0] != want {
+		t.Fatalf("start = %d, want midnight EST %d", got[0], want)
+	}
+	if want := time.Date(2024, 3, 11, 4, 0, 0, 0, time.UTC).Unix(); got[1] != want {
+		t.Fatalf("end = %d, want midnight EDT %d", got[1], want)
+	}
+	if span := got[1] - got[0]; span != int64((47 * time.Hour).Seconds()) {
+		t.Fatalf("span = %ds, want 47h across spring forward", span)
+	}
+
+	tests := []struct {
+		day  string
+		span time.Duration
+	}{
+		{"2024-03-10", 23*time.Hour - time.Second},
+		{"2024-11-03", 25*time.Hour - time.Second},
+		{"2024-07-01", 24*time.Hour - time.Second},
+	}
+	for _, tt := range tests {
+		got, err := ParseDateRangeWithOptions(tt.day+","+tt.day, DateRangeOptions{Location: loc, InclusiveEnd: true})
+		if err != nil {
+			t.Fatal(err)
+		}
+		end := time.Unix(got[1], 0).In(loc)
+		if end.Format("2006-01-02 15:04:05") != tt.day+" 23:59:59" {
+			t.Fatalf("%s: end = %s, want the last second of the local day", tt.day, end)
+		}
+		if span := time.Duration(got[1]-got[0]) * time.Second; span != tt.span {
+			t.Fatalf("%s: span = %s, want %s", tt.day, span, tt.span)
+		}
+	}
+
+	// RFC3339 时间使用自带的时区，不受 loc 影响
+	got, err = ParseDateRangeInLocation("2024-03-10T12:00:00Z,2024-03-10T13:00:00Z", loc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := time.Date(2024, 3, 10, 12, 0, 0, 0, time.UTC).Unix(); got[0] != want {
+		t.Fatalf("rfc3339 start = %d, want %d", got[0], want)
+	}
+}
@@ -0,0 +1,202 @@
+package crud
+
+import (
+	"encoding/csv"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/flaboy/pin"
+)
+
+// CSVColumn 描述CSV导出中的一列
+// Field 为字段路径，使用点号访问嵌套字段（如 "User.Name"），可使用字段名或 json tag 名
+// 设置 Value 时优先使用 Value 提取列值，忽略 Field
+type CSVColumn struct {
+	Header     string
+	Field      string
+	Value      func(item interface{}) interface{}
+	TimeLayout string // time.Time 的输出格式，默认为 RFC3339
+}
+
+// ExportAllRequested 判断请求是否要求导出全部匹配数据（export=all），此时调用方应忽略分页
+func ExportAllRequested(c *pin.Context) bool {
+	return c.Query("export") == "all"
+}
+
+// RenderCSV 将查询结果的 Items 以CSV附件形式输出，文件名为 export.csv
+func RenderCSV(c *pin.Context, result QueryResult, columns []CSVColumn) error {
+	return RenderCSVFile(c, "export.csv", result, columns)
+}
+
+// RenderCSVFile 将查询结果的 Items 以CSV附件形式流式输出
+// Items 必须是切片或数组，元素可以是结构体、结构体指针或 map
+func RenderCSVFile(c *pin.Context, filename string, result QueryResult, columns []CSVColumn) error {
+	items := reflect.ValueOf(result.Items)
+	for items.Kind() == reflect.Ptr || items.Kind() == reflect.Interface {
+		items = items.Elem()
+	}
+	if result.Items != nil && items.Kind() != reflect.Slice && items.Kind() != reflect.Array {
+		return fmt.Errorf("csv export requires a slice of items, got %T", result.Items)
+	}
+
+	c.Header("Content-Type", "text/csv; charset=utf-8")
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	c.Status(http.StatusOK)
+
+	// 写入UTF-8 BOM，便于Excel正确识别中文
+	if _, err := c.Writer.Write([]byte("\xEF\xBB\xBF")); err != nil {
+		return err
+	}
+
+	w := csv.NewWriter(c.Writer)
+
+	headers := make([]string, len(columns))
+	for i, col := range columns {
+		headers[i] = col.Header
+	}
+	if err := w.Write(headers); err != nil {
+		return err
+	}
+
+	if result.Items != nil {
+		record := make([]string, len(columns))
+		for i := 0; i < items.Len(); i++ {
+			item := items.Index(i)
+			for j, col := range columns {
+				record[j] = csvColumnValue(item, col)
+			}
+			if err := w.Write(record); err != nil {
+				return err
+			}
+			// 定期刷新，避免大结果集全部缓存在内存中
+			if i%100 == 99 {
+				w.Flush()
+				if err := w.Error(); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	w.Flush()
+	return w.Error()
+}
+
+// csvColumnValue 提取单个单元格的值并格式化为字符串
+func csvColumnValue(item reflect.Value, col CSVColumn) string {
+	var value interface{}
+	if col.Value != nil {
+		value = col.Value(item.Interface())
+	} else {
+		v, ok := lookupFieldPath(item, col.Field)
+		if !ok {
+			return ""
+		}
+		value = v.Interface()
+	}
+	return formatCSVValue(value, col.TimeLayout)
+}
+
+// lookupFieldPath 按点号分隔的路径查找嵌套字段，路径中遇到空指针时返回 false
+func lookupFieldPath(v reflect.Value, path string) (reflect.Value, bool) {
+	for _, name := range strings.Split(path, ".") {
+		for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+			if v.IsNil() {
+				return reflect.Value{}, false
+			}
+			v = v.Elem()
+		}
+
+		switch v.Kind() {
+		case reflect.Struct:
+			field, ok := structFieldByName(v, name)
+			if !ok {
+				return reflect.Value{}, false
+			}
+			v = field
+		case reflect.Map:
+			if v.Type().Key().Kind() != reflect.String {
+				return reflect.Value{}, false
+			}
+			v = v.MapIndex(reflect.ValueOf(name).Convert(v.Type().Key()))
+			if !v.IsValid() {
+				return reflect.Value{}, false
+			}
+		default:
+			return reflect.Value{}, false
+		}
+	}
+	return v, true
+}
+
+// structFieldByName 按字段名或 json tag 名查找结构体字段，未导出的字段视为不存在
+func structFieldByName(v reflect.Value, name string) (reflect.Value, bool) {
+	if sf, ok := v.Type().FieldByName(name); ok && sf.IsExported() {
+		if field, err := v.FieldByIndexErr(sf.Index); err == nil && field.CanInterface() {
+			return field, true
+		}
+	}
+
+	typ := v.Type()
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		if tagName := strings.Split(field.Tag.Get("json"), ",")[0]; tagName == name && tagName != "-" {
+			return v.Field(i), true
+		}
+	}
+	return reflect.Value{}, false
+}
+
+// formatCSVValue 将单元格值格式化为字符串，time.Time 按指定格式输出
+// 非数值的单元格以 = + - @ 开头时添加 ' 前缀，防止在表格软件中被当作公式执行
+func formatCSVValue(value interface{}, timeLayout string) string {
+	if value == nil {
+		return ""
+	}
+
+	v := reflect.ValueOf(value)
+	for v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return ""
+		}
+		v = v.Elem()
+	}
+
+	if t, ok := v.Interface().(time.Time); ok {
+		if t.IsZero() {
+			return ""
+		}
+		if timeLayout == "" {
+			timeLayout = time.RFC3339
+		}
+		return t.Format(timeLayout)
+	}
+
+	if stringer, ok := v.Interface().(fmt.Stringer); ok {
+		return escapeCSVFormula(stringer.String())
+	}
+
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		// 负数按原样输出
+		return fmt.Sprint(v.Interface())
+	}
+	return escapeCSVFormula(fmt.Sprint(v.Interface()))
+}
+
+// escapeCSVFormula 为可能被解析为公式的单元格添加 ' 前缀
+// 以制表符或回车开头的值也会被部分表格软件去掉前导空白后按公式解析
+func escapeCSVFormula(s string) string {
+	if s != "" && strings.ContainsRune("=+-@\t\r", rune(s[0])) {
+		return "'" + s
+	}
+	return s
+}
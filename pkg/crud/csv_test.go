@@ -0,0 +1,75 @@
+package crud
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/flaboy/pin"
+	"github.com/gin-gonic/gin"
+)
+
+type csvUser struct {
+	Name     string `json:"name"`
+	password string
+	Secret   string `json:"-"`
+}
+
+func TestStructFieldByNameSkipsUnexportedFields(t *testing.T) {
+	v := reflect.ValueOf(csvUser{Name: "alice", password: "p"})
+
+	if _, ok := structFieldByName(v, "password"); ok {
+		t.Fatal("unexported field must not be found")
+	}
+	if field, ok := structFieldByName(v, "name"); !ok || field.String() != "alice" {
+		t.Fatalf("json tag lookup = %v, %v, want alice", field, ok)
+	}
+	if _, ok := structFieldByName(v, "-"); ok {
+		t.Fatal(`json:"-" must not match the "-" name`)
+	}
+}
+
+func TestFormatCSVValueEscapesFormulas(t *testing.T) {
+	cases := []struct {
+		value interface{}
+		want  string
+	}{
+		{"=SUM(A1:A2)", "'=SUM(A1:A2)"},
+		{"+1", "'+1"},
+		{"-cmd", "'-cmd"},
+		{"@evil", "'@evil"},
+		{"\t=1+1", "'\t=1+1"},
+		{"\r=1+1", "'\r=1+1"},
+		{"tab\tinside", "tab\tinside"},
+		{"plain", "plain"},
+		{"", ""},
+		{-5, "-5"},
+		{-1.5, "-1.5"},
+	}
+	for _, tc := range cases {
+		if got := formatCSVValue(tc.value, ""); got != tc.want {
+			t.Errorf("formatCSVValue(%v) = %q, want %q", tc.value, got, tc.want)
+		}
+	}
+}
+
+func TestRenderCSVEscapesFormulaCells(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodGet, "/export", nil)
+
+	result := QueryResult{Items: []csvUser{{Name: "=HYPERLINK(\"http://x\")", password: "p"}}}
+	columns := []CSVColumn{{Header: "Name", Field: "name"}, {Header: "Password", Field: "password"}}
+	if err := RenderCSV(&pin.Context{Context: c}, result, columns); err != nil {
+		t.Fatalf("RenderCSV: %v", err)
+	}
+
+	body := strings.TrimPrefix(w.Body.String(), "\xEF\xBB\xBF")
+	want := "Name,Password\n\"'=HYPERLINK(\"\"http://x\"\")\",\n"
+	if body != want {
+		t.Fatalf("body = %q, want %q", body, want)
+	}
+}
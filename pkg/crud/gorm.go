@@ -0,0 +1,273 @@
+package crud
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"slices"
+	"sort"
+	"strings"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// 支持的过滤操作符
+const (
+	OpEq      = "eq"
+	OpNe      = "ne"
+	OpGt      = "gt"
+	OpGte     = "gte"
+	OpLt      = "lt"
+	OpLte     = "lte"
+	OpLike    = "like"
+	OpIn      = "in"
+	OpBetween = "between"
+)
+
+// ApplyToGorm 将过滤条件转换为 GORM 的 Where 子句
+// 过滤值可以是普通值（等值匹配），也可以是操作符对象，例如：
+// {"created_at": {"gte": 1700000000, "lte": 1700600000}, "name": {"like": "acme"}}
+// 遇到无法识别的操作符或不在 AllowedFilterColumns 白名单中的列名时，
+// 错误会通过 db.AddError 记录到返回的 *gorm.DB 中
+// 注意：AllowedFilterColumns 为空时不校验列名，客户端传入的过滤键会直接作为列名使用（会加引号，
+// 但可以按表中任意列过滤，例如逐位猜测密码哈希），处理外部请求时应通过 QueryOptions 设置白名单
+func (q *QueryContext) ApplyToGorm(db *gorm.DB) *gorm.DB {
+	if q.Filter == nil {
+		return db
+	}
+
+	filter, err := q.GetFilter()
+	if err != nil {
+		db.AddError(err)
+		return db
+	}
+
+	// 按列名排序，保证生成的SQL稳定
+	columns := make([]string, 0, len(filter))
+	for column := range filter {
+		columns = append(columns, column)
+	}
+	sort.Strings(columns)
+
+	for _, column := range columns {
+		if len(q.AllowedFilterColumns) > 0 && !slices.Contains(q.AllowedFilterColumns, column) {
+			db.AddError(fmt.Errorf("filter column %q is not allowed", column))
+			return db
+		}
+		exprs, err := buildFilterExprs(column, filter[column])
+		if err != nil {
+			db.AddError(err)
+			return db
+		}
+		for _, expr := range exprs {
+			db = db.Where(expr)
+		}
+	}
+
+	return db
+}
+
+// ApplySort 将排序条件应用到 GORM 查询
+// 只有出现在 allowed 白名单中的列才会被应用，避免通过任意列名进行SQL注入
+func (q *QueryContext) ApplySort(db *gorm.DB, allowed []string) *gorm.DB {
+	allowedSet := make(map[string]bool, len(allowed))
+	for _, column := range allowed {
+		allowedSet[column] = true
+	}
+
+	sorts := q.Sorts
+	if len(sorts) == 0 && q.Sort != nil {
+		sorts = []Sort{*q.Sort}
+	}
+
+	for _, s := range sorts {
+		if !allowedSet[s.Column] {
+			continue
+		}
+		db = db.Order(clause.OrderByColumn{
+			Column: clause.Column{Name: s.Column},
+			Desc:   s.Order == "desc",
+		})
+	}
+
+	return db
+}
+
+// ApplyPagination 按页码和每页数量应用 Offset/Limit（默认的分页模式）
+// Pagination 为空或 Size 不大于0时不分页
+func (q *QueryContext) ApplyPagination(db *gorm.DB) *gorm.DB {
+	if q.Pagination == nil || q.Pagination.Size <= 0 {
+		return db
+	}
+
+	page := q.Pagination.Page
+	if page < 1 {
+		page = 1
+	}
+	return db.Offset((page - 1) * q.Pagination.Size).Limit(q.Pagination.Size)
+}
+
+// ApplyCursor 应用游标分页（可选模式）：按 keyColumn 升序排列，只返回排序键大于游标的记录
+// 每页数量取自 Pagination.Size；游标无法解析时错误会通过 db.AddError 记录到返回的 *gorm.DB 中
+// 查询完成后可使用 NextCursor 生成下一页游标
+func (q *QueryContext) ApplyCursor(db *gorm.DB, keyColumn string) *gorm.DB {
+	col := clause.Column{Name: keyColumn}
+
+	if q.Cursor != "" {
+		key, err := DecodeCursor(q.Cursor)
+		if err != nil {
+			db.AddError(err)
+			return db
+		}
+		db = db.Where(clause.Gt{Column: col, Value: key})
+	}
+
+	db = db.Order(clause.OrderByColumn{Column: col})
+	if q.Pagination != nil && q.Pagination.Size > 0 {
+		db = db.Limit(q.Pagination.Size)
+	}
+	return db
+}
+
+// NextCursor 根据本页结果生成下一页游标
+// keyField 为排序键对应的字段路径（字段名或 json tag 名）；本页数量不足一页时返回空字符串
+func (q *QueryContext) NextCursor(items interface{}, keyField string) (string, error) {
+	val := reflect.ValueOf(items)
+	for val.Kind() == reflect.Ptr {
+		val = val.Elem()
+	}
+	if val.Kind() != reflect.Slice && val.Kind() != reflect.Array {
+		return "", fmt.Errorf("next cursor requires a slice of items, got %T", items)
+	}
+
+	if val.Len() == 0 || (q.Pagination != nil && val.Len() < q.Pagination.Size) {
+		return "", nil
+	}
+
+	key, ok := lookupFieldPath(val.Index(val.Len()-1), keyField)
+	if !ok {
+		return "", fmt.Errorf("cursor key field %q not found", keyField)
+	}
+	return EncodeCursor(key.Interface())
+}
+
+// EncodeCursor 将排序键编码为不透明的游标字符串
+func EncodeCursor(key interface{}) (string, error) {
+	data, err := json.Marshal(key)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(data), nil
+}
+
+// DecodeCursor 将游标字符串解码为排序键，整数键会还原为 int64
+func DecodeCursor(cursor string) (interface{}, error) {
+	data, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, fmt.Errorf("invalid cursor: %v", err)
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var key interface{}
+	if err := decoder.Decode(&key); err != nil {
+		return nil, fmt.Errorf("invalid cursor: %v", err)
+	}
+
+	if num, ok := key.(json.Number); ok {
+		if i, err := num.Int64(); err == nil {
+			return i, nil
+		}
+		return num.Float64()
+	}
+	return key, nil
+}
+
+// buildFilterExprs 根据单个字段的过滤值生成条件表达式
+func buildFilterExprs(column string, value interface{}) ([]clause.Expression, error) {
+	col := clause.Column{Name: column}
+
+	ops, ok := value.(map[string]interface{})
+	if !ok {
+		return []clause.Expression{clause.Eq{Column: col, Value: value}}, nil
+	}
+
+	// 按操作符排序，保证生成的SQL稳定
+	names := make([]string, 0, len(ops))
+	for op := range ops {
+		names = append(names, op)
+	}
+	sort.Strings(names)
+
+	exprs := make([]clause.Expression, 0, len(ops))
+	for _, op := range names {
+		operand := ops[op]
+		switch op {
+		case OpEq:
+			exprs = append(exprs, clause.Eq{Column: col, Value: operand})
+		case OpNe:
+			exprs = append(exprs, clause.Neq{Column: col, Value: operand})
+		case OpGt:
+			exprs = append(exprs, clause.Gt{Column: col, Value: operand})
+		case OpGte:
+			exprs = append(exprs, clause.Gte{Column: col, Value: operand})
+		case OpLt:
+			exprs = append(exprs, clause.Lt{Column: col, Value: operand})
+		case OpLte:
+			exprs = append(exprs, clause.Lte{Column: col, Value: operand})
+		case OpLike:
+			str, ok := operand.(string)
+			if !ok {
+				return nil, fmt.Errorf("filter %s: operator %q requires a string value", column, op)
+			}
+			exprs = append(exprs, clause.Expr{
+				SQL:  "? LIKE ? ESCAPE '" + likeEscapeChar + "'",
+				Vars: []interface{}{col, "%" + escapeLike(str) + "%"},
+			})
+		case OpIn:
+			values, err := toValueList(operand)
+			if err != nil {
+				return nil, fmt.Errorf("filter %s: operator %q %v", column, op, err)
+			}
+			exprs = append(exprs, clause.IN{Column: col, Values: values})
+		case OpBetween:
+			values, err := toValueList(operand)
+			if err != nil || len(values) != 2 {
+				return nil, fmt.Errorf("filter %s: operator %q requires an array of two values", column, op)
+			}
+			exprs = append(exprs, clause.Expr{SQL: "? BETWEEN ? AND ?", Vars: []interface{}{col, values[0], values[1]}})
+		default:
+			return nil, fmt.Errorf("filter %s: unsupported operator %q", column, op)
+		}
+	}
+
+	return exprs, nil
+}
+
+// likeEscapeChar LIKE 模式的转义字符，不使用反斜杠以兼容不同数据库的字符串字面量规则
+const likeEscapeChar = "!"
+
+// likeEscaper 转义 LIKE 通配符，使 like 过滤值按字面匹配
+var likeEscaper = strings.NewReplacer(likeEscapeChar, likeEscapeChar+likeEscapeChar, "%", likeEscapeChar+"%", "_", likeEscapeChar+"_")
+
+// escapeLike 转义过滤值中的转义字符和通配符 % _
+func escapeLike(s string) string {
+	return likeEscaper.Replace(s)
+}
+
+// toValueList 将数组或切片类型的过滤值转换为 []interface{}
+func toValueList(value interface{}) ([]interface{}, error) {
+	val := reflect.ValueOf(value)
+	if val.Kind() != reflect.Slice && val.Kind() != reflect.Array {
+		return nil, fmt.Errorf("requires an array value, got %T", value)
+	}
+
+	values := make([]interface{}, val.Len())
+	for i := 0; i < val.Len(); i++ {
+		values[i] = val.Index(i).Interface()
+	}
+	return values, nil
+}
@@ -0,0 +1,297 @@
+package crud
+
+import (
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+)
+
+type testRecord struct {
+	ID     uint
+	Name   string
+	Status string
+}
+
+// dryRunDB 返回只生成SQL不执行的数据库连接
+func dryRunDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(mysql.New(mysql.Config{
+		DSN:                       "user:pass@tcp(127.0.0.1:3306)/test",
+		SkipInitializeWithVersion: true,
+	}), &gorm.Config{DryRun: true, DisableAutomaticPing: true})
+	if err != nil {
+		t.Fatalf("open dry run db: %v", err)
+	}
+	return db
+}
+
+func TestApplyToGormRejectsColumnOutsideAllowList(t *testing.T) {
+	q := &QueryContext{
+		Filter:               map[string]interface{}{"name": "acme", "password": "x"},
+		AllowedFilterColumns: []string{"name", "status"},
+	}
+
+	db := q.ApplyToGorm(dryRunDB(t).Model(&testRecord{}))
+	if db.Error == nil || !strings.Contains(db.Error.Error(), `"password"`) {
+		t.Fatalf("error = %v, want password column rejected", db.Error)
+	}
+}
+
+func TestApplyToGormAllowsListedColumns(t *testing.T) {
+	q := &QueryContext{
+		Filter:               map[string]interface{}{"name": "acme"},
+		AllowedFilterColumns: []string{"name"},
+	}
+
+	var items []testRecord
+	stmt := q.ApplyToGorm(dryRunDB(t).Model(&testRecord{})).Find(&items).Statement
+	if stmt.Error != nil {
+		t.Fatalf("unexpected error: %v", stmt.Error)
+	}
+	if sql := stmt.SQL.String(); !strings.Contains(sql, "`name` = ?") {
+		t.Fatalf("sql = %s, want name condition", sql)
+	}
+}
+
+func TestApplyToGormEscapesLikeWildcards(t *testing.T) {
+	q := &QueryContext{
+		Filter: map[string]interface{}{"name": map[string]interface{}{OpLike: "50%_off!"}},
+	}
+
+	var items []testRecord
+	stmt := q.ApplyToGorm(dryRunDB(t).Model(&testRecord{})).Find(&items).Statement
+	if stmt.Error != nil {
+		t.Fatalf("unexpected error: %v", stmt.Error)
+	}
+	if sql := stmt.SQL.String(); !strings.Contains(sql, "`name` LIKE ? ESCAPE '!'") {
+		t.Fatalf("sql = %s, want LIKE with ESCAPE clause", sql)
+	}
+	if len(stmt.Vars) != 1 || stmt.Vars[0] != "%50!%!_off!!%" {
+		t.Fatalf("vars = %v, want escaped pattern", stmt.Vars)
+	}
+}
+
+// filterSQL 对过滤条件生成 DryRun SQL，返回 WHERE 子句之后的部分及参数
+func filterSQL(t *testing.T, filter map[string]interface{}) (string, []interface{}, error) {
+	t.Helper()
+	q := &QueryContext{Filter: filter}
+
+	var items []testRecord
+	stmt := q.ApplyToGorm(dryRunDB(t).Model(&testRecord{})).Find(&items).Statement
+	if stmt.Error != nil {
+		return "", nil, stmt.Error
+	}
+	sql := stmt.SQL.String()
+	if i := strings.Index(sql, "WHERE "); i >= 0 {
+		sql = sql[i+len("WHERE "):]
+	}
+	return sql, stmt.Vars, nil
+}
+
+func TestApplyToGormOperators(t *testing.T) {
+	tests := []struct {
+		name   string
+		filter map[string]interface{}
+		sql    string
+		vars   []interface{}
+	}{
+		{"plain value", map[string]interface{}{"status": "active"}, "`status` = ?", []interface{}{"active"}},
+		{"eq", map[string]interface{}{"status": map[string]interface{}{OpEq: "active"}}, "`status` = ?", []interface{}{"active"}},
+		{"ne", map[string]interface{}{"status": map[string]interface{}{OpNe: "deleted"}}, "`status` <> ?", []interface{}{"deleted"}},
+		{"gt", map[string]interface{}{"id": map[string]interface{}{OpGt: 10}}, "`id` > ?", []interface{}{10}},
+		{"gte", map[string]interface{}{"id": map[string]interface{}{OpGte: 10}}, "`id` >= ?", []interface{}{10}},
+		{"lt", map[string]interface{}{"id": map[string]interface{}{OpLt: 10}}, "`id` < ?", []interface{}{10}},
+		{"lte", map[string]interface{}{"id": map[string]interface{}{OpLte: 10}}, "`id` <= ?", []interface{}{10}},
+		{"in", map[string]interface{}{"status": map[string]interface{}{OpIn: []interface{}{"a", "b"}}}, "`status` IN (?,?)", []interface{}{"a", "b"}},
+		{"between", map[string]interface{}{"id": map[string]interface{}{OpBetween: []interface{}{1, 5}}}, "`id` BETWEEN ? AND ?", []interface{}{1, 5}},
+		{"range on one column", map[string]interface{}{"id": map[string]interface{}{OpGte: 1, OpLte: 5}}, "`id` >= ? AND `id` <= ?", []interface{}{1, 5}},
+		{"several columns", map[string]interface{}{"status": "active", "name": map[string]interface{}{OpNe: "x"}}, "`name` <> ? AND `status` = ?", []interface{}{"x", "active"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sql, vars, err := filterSQL(t, tt.filter)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if sql != tt.sql {
+				t.Fatalf("where = %s, want %s", sql, tt.sql)
+			}
+			if !reflect.DeepEqual(vars, tt.vars) {
+				t.Fatalf("vars = %v, want %v", vars, tt.vars)
+			}
+		})
+	}
+}
+
+func TestApplyToGormOperatorErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		filter map[string]interface{}
+		want   string
+	}{
+		{"unknown operator", map[string]interface{}{"id": map[string]interface{}{"regexp": ".*"}}, `unsupported operator "regexp"`},
+		{"in with scalar", map[string]interface{}{"id": map[string]interface{}{OpIn: 5}}, `operator "in"`},
+		{"between with scalar", map[string]interface{}{"id": map[string]interface{}{OpBetween: 5}}, `operator "between" requires an array of two values`},
+		{"between with one value", map[string]interface{}{"id": map[string]interface{}{OpBetween: []interface{}{1}}}, `operator "between" requires an array of two values`},
+		{"between with three values", map[string]interface{}{"id": map[string]interface{}{OpBetween: []interface{}{1, 2, 3}}}, `operator "between" requires an array of two values`},
+		{"like with number", map[string]interface{}{"name": map[string]interface{}{OpLike: 5}}, `operator "like" requires a string value`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, err := filterSQL(t, tt.filter)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("error = %v, want it to contain %s", err, tt.want)
+			}
+		})
+	}
+}
+
+// querySQL 返回 db 查询 testRecord 时生成的SQL和参数
+func querySQL(db *gorm.DB) (string, []interface{}, error) {
+	var items []testRecord
+	stmt := db.Find(&items).Statement
+	return stmt.SQL.String(), stmt.Vars, stmt.Error
+}
+
+func TestApplySortDropsColumnsOutsideAllowList(t *testing.T) {
+	q := &QueryContext{Sorts: []Sort{
+		{Column: "name", Order: "asc"},
+		{Column: "password", Order: "desc"},
+		{Column: "status", Order: "desc"},
+	}}
+
+	sql, _, err := querySQL(q.ApplySort(dryRunDB(t).Model(&testRecord{}), []string{"name", "status"}))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := "SELECT * FROM `test_records` ORDER BY `name`,`status` DESC"; sql != want {
+		t.Fatalf("sql = %s, want %s", sql, want)
+	}
+}
+
+func TestApplySortWithoutAllowListAppliesNothing(t *testing.T) {
+	q := &QueryContext{Sort: &Sort{Column: "name", Order: "desc"}}
+
+	sql, _, err := querySQL(q.ApplySort(dryRunDB(t).Model(&testRecord{}), nil))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if strings.Contains(sql, "ORDER BY") {
+		t.Fatalf("sql = %s, want no ORDER BY", sql)
+	}
+}
+
+func TestApplySortKeepsQuerySortOrder(t *testing.T) {
+	c := newTestContext(httptest.NewRequest(http.MethodGet, "/?sort=status:asc,name:desc", nil))
+	q, err := BindQuery(c, nil)
+	if err != nil {
+		t.Fatalf("BindQuery: %v", err)
+	}
+
+	sql, _, err := querySQL(q.ApplySort(dryRunDB(t).Model(&testRecord{}), []string{"name", "status"}))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := "ORDER BY `status`,`name` DESC"; !strings.HasSuffix(sql, want) {
+		t.Fatalf("sql = %s, want it to end with %s", sql, want)
+	}
+}
+
+func TestCursorRoundTrip(t *testing.T) {
+	tests := []struct {
+		name string
+		key  interface{}
+		want interface{}
+	}{
+		{"integer", uint(42), int64(42)},
+		{"large integer", int64(1) << 60, int64(1) << 60},
+		{"float", 1.5, 1.5},
+		{"string", "2024-01-02T03:04:05Z", "2024-01-02T03:04:05Z"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cursor, err := EncodeCursor(tt.key)
+			if err != nil {
+				t.Fatalf("EncodeCursor: %v", err)
+			}
+			key, err := DecodeCursor(cursor)
+			if err != nil {
+				t.Fatalf("DecodeCursor(%q): %v", cursor, err)
+			}
+			if key != tt.want {
+				t.Fatalf("key = %#v, want %#v", key, tt.want)
+			}
+		})
+	}
+}
+
+func TestApplyCursor(t *testing.T) {
+	cursor, err := EncodeCursor(uint(42))
+	if err != nil {
+		t.Fatalf("EncodeCursor: %v", err)
+	}
+	q := &QueryContext{Cursor: cursor, Pagination: &Pagination{Size: 10}}
+
+	sql, vars, err := querySQL(q.ApplyCursor(dryRunDB(t).Model(&testRecord{}), "id"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := "SELECT * FROM `test_records` WHERE `id` > ? ORDER BY `id` LIMIT ?"; sql != want {
+		t.Fatalf("sql = %s, want %s", sql, want)
+	}
+	if want := []interface{}{int64(42), 10}; !reflect.DeepEqual(vars, want) {
+		t.Fatalf("vars = %v, want %v", vars, want)
+	}
+}
+
+func TestApplyCursorFirstPage(t *testing.T) {
+	q := &QueryContext{Pagination: &Pagination{Size: 10}}
+
+	sql, _, err := querySQL(q.ApplyCursor(dryRunDB(t).Model(&testRecord{}), "id"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := "SELECT * FROM `test_records` ORDER BY `id` LIMIT ?"; sql != want {
+		t.Fatalf("sql = %s, want %s", sql, want)
+	}
+}
+
+func TestApplyCursorInvalidCursor(t *testing.T) {
+	for _, cursor := range []string{"not base64!", base64.RawURLEncoding.EncodeToString([]byte("{broken"))} {
+		q := &QueryContext{Cursor: cursor}
+		db := q.ApplyCursor(dryRunDB(t).Model(&testRecord{}), "id")
+		if db.Error == nil || !strings.Contains(db.Error.Error(), "invalid cursor") {
+			t.Fatalf("cursor %q: error = %v, want invalid cursor", cursor, db.Error)
+		}
+	}
+}
+
+func TestNextCursor(t *testing.T) {
+	q := &QueryContext{Pagination: &Pagination{Size: 2}}
+
+	cursor, err := q.NextCursor([]testRecord{{ID: 1}, {ID: 2}}, "ID")
+	if err != nil {
+		t.Fatalf("NextCursor: %v", err)
+	}
+	key, err := DecodeCursor(cursor)
+	if err != nil || key != int64(2) {
+		t.Fatalf("full page cursor decodes to %#v, %v; want last key 2", key, err)
+	}
+
+	for name, items := range map[string][]testRecord{"short page": {{ID: 3}}, "empty page": {}} {
+		cursor, err := q.NextCursor(items, "ID")
+		if err != nil || cursor != "" {
+			t.Fatalf("%s: cursor = %q, %v; want empty", name, cursor, err)
+		}
+	}
+
+	if _, err := q.NextCursor([]testRecord{{ID: 1}, {ID: 2}}, "missing"); err == nil {
+		t.Fatal("expected error for unknown key field")
+	}
+}
@@ -2,6 +2,7 @@ package engine
 
 import (
 	"github.com/flaboy/pin"
+	"github.com/gin-gonic/gin"
 )
 
 type Context struct {
@@ -12,8 +13,62 @@ type Context struct {
 	RequestID string
 }
 
+// contextKey engine.Context 在 gin.Context 中的存储键
+const contextKey = "engine_context"
+
 func NewContext(c *pin.Context) *Context {
 	return &Context{
 		Context: c,
 	}
 }
+
+// FromContext 获取附加在请求上的 engine.Context，不存在时创建并附加
+// 同一请求内多次调用返回同一实例，因此中间件填充的字段在后续处理器中可见
+func FromContext(c *pin.Context) *Context {
+	if value, exists := c.Get(contextKey); exists {
+		if ec, ok := value.(*Context); ok {
+			ec.Context = c
+			return ec
+		}
+	}
+
+	ec := NewContext(c)
+	c.Set(contextKey, ec)
+	return ec
+}
+
+// WithUser 创建填充 UserID 的中间件，loader 通常从会话或令牌中解析当前用户
+// loader 返回错误时中断请求并渲染该错误
+//
+// 中间件顺序：应注册在认证中间件之后；WithTenant 的 loader 依赖 UserID 时，WithUser 必须在 WithTenant 之前注册
+func WithUser(loader func(*pin.Context) (uint, error)) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		pc := &pin.Context{Context: c}
+		userID, err := loader(pc)
+		if err != nil {
+			pc.RenderError(err)
+			c.Abort()
+			return
+		}
+
+		FromContext(pc).UserID = userID
+		c.Next()
+	}
+}
+
+// WithTenant 创建填充 TenantID 的中间件，loader 中可通过 FromContext(c).UserID 读取已加载的用户
+// loader 返回错误时中断请求并渲染该错误
+func WithTenant(loader func(*pin.Context) (uint, error)) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		pc := &pin.Context{Context: c}
+		tenantID, err := loader(pc)
+		if err != nil {
+			pc.RenderError(err)
+			c.Abort()
+			return
+		}
+
+		FromContext(pc).TenantID = tenantID
+		c.Next()
+	}
+}
@@ -0,0 +1,59 @@
+package engine
+
+import (
+	"github.com/flaboy/pin"
+	"github.com/gin-gonic/gin"
+	"github.com/twinj/uuid"
+)
+
+// DefaultRequestIDHeader 默认的请求ID头
+const DefaultRequestIDHeader = "X-Request-Id"
+
+// maxRequestIDLength 允许沿用的请求ID最大长度
+const maxRequestIDLength = 128
+
+// RequestIDMiddleware 创建请求ID中间件，header 为空时使用 DefaultRequestIDHeader
+// 请求头中带有合法的请求ID时沿用，否则生成UUID；请求ID会写入 engine.Context.RequestID、
+// 响应头，以及 pin 响应中的 trace_id，保证两者为同一个值
+//
+// 中间件顺序：应尽早注册（在 WithUser/WithTenant 之前），使后续中间件和日志都能读取到请求ID
+func RequestIDMiddleware(header string) gin.HandlerFunc {
+	if header == "" {
+		header = DefaultRequestIDHeader
+	}
+
+	return func(c *gin.Context) {
+		requestID := c.GetHeader(header)
+		if !validRequestID(requestID) {
+			requestID = uuid.NewV4().String()
+		}
+
+		pc := &pin.Context{Context: c}
+		FromContext(pc).RequestID = requestID
+		c.Set("trace_id", requestID)
+		c.Header(header, requestID)
+
+		c.Next()
+	}
+}
+
+// GetRequestID 获取当前请求的请求ID，未经过 RequestIDMiddleware 时返回空字符串
+func GetRequestID(c *pin.Context) string {
+	return FromContext(c).RequestID
+}
+
+// validRequestID 校验外部传入的请求ID，只允许有限长度的字母、数字及 - _ . : 字符
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for _, r := range id {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+		case r == '-', r == '_', r == '.', r == ':':
+		default:
+			return false
+		}
+	}
+	return true
+}
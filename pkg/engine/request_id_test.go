@@ -0,0 +1,77 @@
+package engine
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/flaboy/pin"
+	"github.com/gin-gonic/gin"
+)
+
+// serveRequestID 经过 RequestIDMiddleware 处理一个请求，返回响应及处理器读取到的请求ID
+func serveRequestID(header, incoming string) (*httptest.ResponseRecorder, string) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(RequestIDMiddleware(header))
+
+	var seen string
+	r.GET("/ping", func(c *gin.Context) {
+		pc := &pin.Context{Context: c}
+		seen = GetRequestID(pc)
+		pc.Render("pong")
+	})
+
+	name := header
+	if name == "" {
+		name = DefaultRequestIDHeader
+	}
+	req := httptest.NewRequest(http.MethodGet, "/ping", nil)
+	if incoming != "" {
+		req.Header.Set(name, incoming)
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w, seen
+}
+
+func traceID(t *testing.T, w *httptest.ResponseRecorder) string {
+	t.Helper()
+	var body struct {
+		TraceID string `json:"trace_id"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatalf("invalid response body %q: %v", w.Body.String(), err)
+	}
+	return body.TraceID
+}
+
+func TestRequestIDMiddlewareRoundTripsIncomingID(t *testing.T) {
+	w, seen := serveRequestID("", "req-123.abc:1")
+
+	if got := w.Header().Get(DefaultRequestIDHeader); got != "req-123.abc:1" {
+		t.Fatalf("response header = %q, want incoming request id", got)
+	}
+	if seen != "req-123.abc:1" {
+		t.Fatalf("GetRequestID = %q, want incoming request id", seen)
+	}
+	if got := traceID(t, w); got != "req-123.abc:1" {
+		t.Fatalf("trace_id = %q, want incoming request id", got)
+	}
+}
+
+func TestRequestIDMiddlewareGeneratesID(t *testing.T) {
+	for _, incoming := range []string{"", "bad id with spaces", "<script>", strings.Repeat("a", maxRequestIDLength+1)} {
+		w, seen := serveRequestID("X-Correlation-Id", incoming)
+
+		generated := w.Header().Get("X-Correlation-Id")
+		if generated == "" || generated == incoming || len(generated) != 36 {
+			t.Fatalf("incoming %q: response header = %q, want a generated UUID", incoming, generated)
+		}
+		if seen != generated || traceID(t, w) != generated {
+			t.Fatalf("incoming %q: request id %q and trace_id %q should equal header %q", incoming, seen, traceID(t, w), generated)
+		}
+	}
+}
@@ -1,10 +1,20 @@
 package framework
 
 import (
+	"errors"
+	"fmt"
+
 	"github.com/flaboy/aira-web/pkg/config"
 )
 
+// Start 校验并设置框架配置，配置无效时返回错误且不替换当前配置
 func Start(cfg *config.FrameworkConfig) error {
+	if cfg == nil {
+		return errors.New("framework config is nil")
+	}
+	if err := cfg.Validate(); err != nil {
+		return fmt.Errorf("invalid framework config: %w", err)
+	}
 	config.Config = cfg
 	return nil
 }
@@ -0,0 +1,41 @@
+package framework
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/flaboy/aira-web/pkg/config"
+)
+
+func TestStartRejectsInvalidConfig(t *testing.T) {
+	previous := config.Config
+	defer func() { config.Config = previous }()
+
+	current := &config.FrameworkConfig{FrontURL: "https://app.example.com/", AiraTablePreifix: "ar_"}
+	if err := Start(current); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+
+	tests := []struct {
+		name string
+		cfg  *config.FrameworkConfig
+		want string
+	}{
+		{"nil config", nil, "nil"},
+		{"missing FrontURL", &config.FrameworkConfig{AiraTablePreifix: "ar_"}, "FRONT_URL"},
+		{"relative FrontURL", &config.FrameworkConfig{FrontURL: "app.example.com", AiraTablePreifix: "ar_"}, "FRONT_URL"},
+		{"illegal prefix", &config.FrameworkConfig{FrontURL: "https://app.example.com/", AiraTablePreifix: "ar-1"}, "AIRA_TABLE_PREFIX"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Start(tt.cfg)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("Start = %v, want error mentioning %s", err, tt.want)
+			}
+			// 配置无效时保留之前的配置
+			if config.Config != current {
+				t.Fatal("invalid config must not replace the current config")
+			}
+		})
+	}
+}
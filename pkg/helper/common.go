@@ -1,23 +1,78 @@
 package helper
 
 import (
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/url"
 	"strings"
+	"sync/atomic"
 
 	"github.com/flaboy/aira-web/pkg/config"
 	"github.com/flaboy/pin"
 )
 
-func BuildUrl(path string) string {
-	if strings.HasPrefix(path, "/") {
-		path = path[1:]
+// BuildUrl 拼接前端地址和路径，可选地追加查询参数
+// 前端地址与路径之间的多余斜杠会被合并；查询参数使用 net/url 编码，路径中已有 ? 时以 & 追加
+func BuildUrl(path string, query ...map[string]string) string {
+	base := strings.TrimRight(config.Config.FrontURL, "/")
+	result := base + "/" + strings.TrimLeft(path, "/")
+
+	values := url.Values{}
+	for _, q := range query {
+		for key, value := range q {
+			values.Set(key, value)
+		}
+	}
+	if len(values) == 0 {
+		return result
+	}
+
+	switch {
+	case strings.HasSuffix(result, "?"), strings.HasSuffix(result, "&"):
+	case strings.Contains(result, "?"):
+		result += "&"
+	default:
+		result += "?"
+	}
+	return result + values.Encode()
+}
+
+// 受信任的代理网段，为空时保持原有行为，直接信任转发头
+// 使用原子指针保存，请求处理期间调用 SetTrustedProxies 也不会产生数据竞争
+var trustedProxies atomic.Pointer[[]*net.IPNet]
+
+// SetTrustedProxies 设置受信任的代理（CIDR或单个IP）
+// 设置后，只有来自受信任代理的请求才会读取转发头；列表中有无效项时返回错误且不修改当前配置
+func SetTrustedProxies(proxies []string) error {
+	nets, err := parseTrustedProxies(proxies)
+	if err != nil {
+		return err
 	}
-	if !strings.HasSuffix(config.Config.FrontURL, "/") {
-		config.Config.FrontURL += "/"
+	trustedProxies.Store(&nets)
+	return nil
+}
+
+// getTrustedProxies 返回当前受信任的代理网段
+func getTrustedProxies() []*net.IPNet {
+	if nets := trustedProxies.Load(); nets != nil {
+		return *nets
 	}
-	return config.Config.FrontURL + path
+	return nil
 }
 
 func RemoteIP(c *pin.Context) string {
+	return ClientIP(c.Request)
+}
+
+// ClientIP 从HTTP请求中获取客户端IP（不含端口），与 RemoteIP 使用相同的转发头和受信任代理规则
+// 供没有 pin.Context 的场景使用，例如 auth 包从 context 中取出的 *http.Request
+func ClientIP(req *http.Request) string {
+	if nets := getTrustedProxies(); len(nets) > 0 {
+		return remoteIPWithTrusted(req, nets)
+	}
+
 	// HTTP头一般格式如下:
 	// X-Forwarded-For: client1, proxy1, proxy2
 	// 一般取X-Forwarded-For中第一个非unknown的有效IP字符串
@@ -30,20 +85,115 @@ func RemoteIP(c *pin.Context) string {
 		"X-Real-IP",
 	}
 	for _, header := range ip_headers {
-		ip := c.Request.Header.Get(header)
+		ip := req.Header.Get(header)
 		// revel.AppLog.Infof("ApiController::RemoteIP: %s: %s", header, ip)
 		if ip != "" {
 			parts := strings.Split(ip, ",")
 			if len(parts) > 0 {
-				return parts[0]
+				return strings.TrimSpace(parts[0])
 			}
 		}
 	}
 
 	// revel.AppLog.Infof("ApiController::RemoteIP: c.Request.RemoteAddr: %s", c.Request.RemoteAddr)
-	parts := strings.Split(c.Request.RemoteAddr, ":")
-	if len(parts) > 0 {
-		return parts[0]
+	return hostFromAddr(req.RemoteAddr)
+}
+
+// TrustedClientIP 按 SetTrustedProxies 设置的受信任代理获取客户端IP（不含端口）
+// 与 ClientIP 不同，没有设置受信任代理时不读取任何转发头，直接返回 RemoteAddr，
+// 适用于限流等不能被客户端伪造转发头绕过的场景
+func TrustedClientIP(req *http.Request) string {
+	return remoteIPWithTrusted(req, getTrustedProxies())
+}
+
+// RemoteIPWithConfig 根据受信任代理列表获取客户端IP
+// 只有 RemoteAddr 属于受信任代理时才读取转发头，X-Forwarded-For 从右向左跳过受信任的代理
+// 代理列表无效时记录错误并不信任任何代理，直接返回 RemoteAddr
+func RemoteIPWithConfig(c *pin.Context, proxies []string) string {
+	nets, err := parseTrustedProxies(proxies)
+	if err != nil {
+		slog.Error("Invalid trusted proxies, ignoring forwarded headers", "proxies", proxies, "error", err)
+	}
+	return remoteIPWithTrusted(c.Request, nets)
+}
+
+func remoteIPWithTrusted(req *http.Request, nets []*net.IPNet) string {
+	peer := hostFromAddr(req.RemoteAddr)
+	if !isTrustedProxy(peer, nets) {
+		return peer
+	}
+
+	if ip := strings.TrimSpace(req.Header.Get("CF-Connecting-IP")); ip != "" {
+		return ip
+	}
+
+	if xff := req.Header.Get("X-Forwarded-For"); xff != "" {
+		hops := strings.Split(xff, ",")
+		for i := len(hops) - 1; i >= 0; i-- {
+			hop := strings.TrimSpace(hops[i])
+			if hop == "" || strings.EqualFold(hop, "unknown") {
+				continue
+			}
+			if i == 0 || !isTrustedProxy(hop, nets) {
+				return hop
+			}
+		}
+	}
+
+	if ip := strings.TrimSpace(req.Header.Get("X-Real-IP")); ip != "" {
+		return ip
+	}
+
+	return peer
+}
+
+// parseTrustedProxies 解析受信任代理列表，支持CIDR和单个IP
+func parseTrustedProxies(proxies []string) ([]*net.IPNet, error) {
+	var nets []*net.IPNet
+	for _, proxy := range proxies {
+		proxy = strings.TrimSpace(proxy)
+		if proxy == "" {
+			continue
+		}
+		if !strings.Contains(proxy, "/") {
+			ip := net.ParseIP(proxy)
+			if ip == nil {
+				return nil, fmt.Errorf("invalid trusted proxy: %s", proxy)
+			}
+			bits := 32
+			if ip.To4() == nil {
+				bits = 128
+			}
+			proxy = fmt.Sprintf("%s/%d", proxy, bits)
+		}
+		_, ipNet, err := net.ParseCIDR(proxy)
+		if err != nil {
+			return nil, fmt.Errorf("invalid trusted proxy: %s", proxy)
+		}
+		nets = append(nets, ipNet)
+	}
+	return nets, nil
+}
+
+// isTrustedProxy 判断IP是否属于受信任代理
+func isTrustedProxy(addr string, nets []*net.IPNet) bool {
+	ip := net.ParseIP(hostFromAddr(addr))
+	if ip == nil {
+		return false
+	}
+	for _, ipNet := range nets {
+		if ipNet.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// hostFromAddr 从 host:port 格式的地址中提取主机部分，兼容IPv6和不带端口的地址
+func hostFromAddr(addr string) string {
+	if host, _, err := net.SplitHostPort(addr); err == nil {
+		return host
 	}
-	return c.Request.RemoteAddr
+	// 没有端口时，去掉IPv6地址两侧的方括号
+	return strings.TrimSuffix(strings.TrimPrefix(addr, "["), "]")
 }
@@ -0,0 +1,142 @@
+package helper
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/flaboy/aira-web/pkg/config"
+	"github.com/flaboy/pin"
+	"github.com/gin-gonic/gin"
+)
+
+// newRequest 创建来自 remoteAddr 并带有 X-Forwarded-For 头的请求
+func newRequest(remoteAddr, forwardedFor string) *http.Request {
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.RemoteAddr = remoteAddr
+	if forwardedFor != "" {
+		req.Header.Set("X-Forwarded-For", forwardedFor)
+	}
+	return req
+}
+
+func newPinContext(req *http.Request) *pin.Context {
+	gin.SetMode(gin.TestMode)
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = req
+	return &pin.Context{Context: c}
+}
+
+func TestRemoteIPWithConfigInvalidProxiesIgnoresForwardedHeaders(t *testing.T) {
+	c := newPinContext(newRequest("10.0.0.1:1234", "203.0.113.9"))
+
+	if got := RemoteIPWithConfig(c, []string{"10.0.0.0/8", "not-an-ip"}); got != "10.0.0.1" {
+		t.Fatalf("RemoteIPWithConfig = %s, want peer address 10.0.0.1", got)
+	}
+	if got := RemoteIPWithConfig(c, []string{"10.0.0.0/8"}); got != "203.0.113.9" {
+		t.Fatalf("RemoteIPWithConfig = %s, want forwarded client 203.0.113.9", got)
+	}
+}
+
+func TestSetTrustedProxiesKeepsConfigOnError(t *testing.T) {
+	defer SetTrustedProxies(nil)
+
+	if err := SetTrustedProxies([]string{"10.0.0.0/8"}); err != nil {
+		t.Fatalf("SetTrustedProxies: %v", err)
+	}
+	if err := SetTrustedProxies([]string{"bogus"}); err == nil {
+		t.Fatal("expected invalid proxy to be rejected")
+	}
+	if got := ClientIP(newRequest("192.0.2.1:1234", "203.0.113.9")); got != "192.0.2.1" {
+		t.Fatalf("ClientIP = %s, want untrusted peer 192.0.2.1", got)
+	}
+}
+
+func TestSetTrustedProxiesConcurrentWithClientIP(t *testing.T) {
+	defer SetTrustedProxies(nil)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			SetTrustedProxies([]string{"10.0.0.0/8"})
+		}()
+		go func() {
+			defer wg.Done()
+			ClientIP(newRequest("10.0.0.1:1234", "203.0.113.9"))
+		}()
+	}
+	wg.Wait()
+}
+
+func TestHostFromAddr(t *testing.T) {
+	cases := map[string]string{
+		"192.0.2.1:8080":      "192.0.2.1",
+		"192.0.2.1":           "192.0.2.1",
+		"[2001:db8::1]:443":   "2001:db8::1",
+		"[2001:db8::1]":       "2001:db8::1",
+		"2001:db8::1":         "2001:db8::1",
+		"[fe80::1%eth0]:8080": "fe80::1%eth0",
+		"localhost:3000":      "localhost",
+		"":                    "",
+	}
+	for addr, want := range cases {
+		if got := hostFromAddr(addr); got != want {
+			t.Errorf("hostFromAddr(%q) = %q, want %q", addr, got, want)
+		}
+	}
+}
+
+func TestClientIPWithIPv6RemoteAddr(t *testing.T) {
+	if got := ClientIP(newRequest("[2001:db8::1]:443", "")); got != "2001:db8::1" {
+		t.Fatalf("ClientIP = %q, want 2001:db8::1", got)
+	}
+}
+
+// withFrontURL 在测试期间替换前端地址
+func withFrontURL(t *testing.T, frontURL string) {
+	t.Helper()
+	orig := config.Config
+	config.Config = &config.FrameworkConfig{FrontURL: frontURL}
+	t.Cleanup(func() { config.Config = orig })
+}
+
+func TestBuildUrl(t *testing.T) {
+	withFrontURL(t, "https://app.example.com/")
+
+	cases := []struct {
+		name  string
+		path  string
+		query map[string]string
+		want  string
+	}{
+		{"duplicate slashes", "//login", nil, "https://app.example.com/login"},
+		{"no query", "reset", nil, "https://app.example.com/reset"},
+		{"space and ampersand", "/search", map[string]string{"q": "a b&c"}, "https://app.example.com/search?q=a+b%26c"},
+		{"unicode", "/search", map[string]string{"q": "中文"}, "https://app.example.com/search?q=%E4%B8%AD%E6%96%87"},
+		{"existing query", "/verify?lang=en", map[string]string{"token": "t=1"}, "https://app.example.com/verify?lang=en&token=t%3D1"},
+		{"trailing question mark", "/verify?", map[string]string{"token": "abc"}, "https://app.example.com/verify?token=abc"},
+		{"multiple params sorted", "/x", map[string]string{"b": "2", "a": "1"}, "https://app.example.com/x?a=1&b=2"},
+	}
+	for _, tc := range cases {
+		var got string
+		if tc.query == nil {
+			got = BuildUrl(tc.path)
+		} else {
+			got = BuildUrl(tc.path, tc.query)
+		}
+		if got != tc.want {
+			t.Errorf("%s: BuildUrl(%q) = %q, want %q", tc.name, tc.path, got, tc.want)
+		}
+	}
+}
+
+func TestBuildUrlFrontURLWithoutTrailingSlash(t *testing.T) {
+	withFrontURL(t, "https://app.example.com")
+
+	if got := BuildUrl("login"); got != "https://app.example.com/login" {
+		t.Fatalf("BuildUrl = %q, want https://app.example.com/login", got)
+	}
+}
@@ -1,10 +1,14 @@
 package migration
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"log/slog"
 	"strings"
 	"time"
+
+	"gorm.io/gorm"
 )
 
 // MigrationStorage 定义迁移状态存储接口
@@ -15,16 +19,97 @@ type MigrationStorage interface {
 	MarkMigrationSkipped(namespace, name string) error
 }
 
+// RollbackStorage 支持记录迁移回滚的存储（可选）
+// MigrationStorage 的实现同时实现此接口时才能使用 Rollback
+type RollbackStorage interface {
+	// MarkMigrationRolledBack 将已应用的迁移标记为已回滚，之后 RunMigrations 会重新执行它
+	MarkMigrationRolledBack(namespace, name string) error
+}
+
+// MigrationRecord 迁移执行记录
+type MigrationRecord struct {
+	Namespace string
+	Name      string
+	State     MigrationState
+	Error     string // 失败时的错误信息
+	At        time.Time
+}
+
+// MigrationRecordStorage 支持查询迁移执行记录的存储（可选）
+// MigrationStorage 的实现同时实现此接口时，Status 可以区分失败和跳过的迁移
+type MigrationRecordStorage interface {
+	// GetMigrationRecords 按执行时间顺序返回所有迁移记录
+	GetMigrationRecords() ([]MigrationRecord, error)
+}
+
+// ChecksumStorage 支持记录迁移校验和的存储（可选）
+// MigrationStorage 的实现同时实现此接口时，RunMigrations 会检测已应用迁移的校验和是否变化
+type ChecksumStorage interface {
+	// GetMigrationChecksums 返回已应用迁移的校验和，键为 "namespace:name"
+	GetMigrationChecksums() (map[string]string, error)
+	// SetMigrationChecksum 记录已应用（或跳过）迁移的校验和
+	SetMigrationChecksum(namespace, name, checksum string) error
+}
+
+// FailureStorage 支持查询迁移失败次数的存储（可选）
+// MigrationStorage 的实现同时实现此接口时，RunMigrations 按 SetMaxAttempts 限制失败迁移的重试次数
+type FailureStorage interface {
+	// GetFailedAttempts 返回自最近一次成功（或回滚）以来连续失败的次数，键为 "namespace:name"
+	GetFailedAttempts() (map[string]int, error)
+}
+
+// ChecksumMode 已应用迁移的校验和不一致时的处理方式
+type ChecksumMode string
+
+const (
+	ChecksumModeWarn ChecksumMode = "warn" // 记录警告并继续执行（默认）
+	ChecksumModeFail ChecksumMode = "fail" // 中止本次执行
+)
+
 // LockProvider 定义分布式锁接口
 type LockProvider interface {
 	Lock(key string, seconds int) (bool, error)
 	Unlock(key string) error
 }
 
+// RenewableLockProvider 支持续期的分布式锁
+// 实现此接口的锁在迁移执行期间会被自动续期
+type RenewableLockProvider interface {
+	LockProvider
+	Renew(key string, seconds int) (bool, error)
+}
+
+// ErrMigrationLockLost 迁移执行期间锁续期失败
+var ErrMigrationLockLost = errors.New("migration lock lost")
+
+// ErrMigrationTimeout 迁移执行超过了 SetMigrationTimeout 设置的时间
+var ErrMigrationTimeout = errors.New("migration timed out")
+
+// ErrMigrationGaveUp 迁移失败次数已达上限，不再重试
+var ErrMigrationGaveUp = errors.New("giving up on failed migration")
+
 // Migration 迁移执行上下文
 type Migration struct {
 	logStrings []string
 	storage    MigrationStorage
+	db         *gorm.DB // 启用事务时为事务句柄，否则为普通数据库连接
+	ctx        context.Context
+}
+
+// DB 返回迁移使用的数据库句柄
+// 启用事务时返回当前迁移的事务，迁移函数应通过它执行数据库操作
+// 句柄已绑定 Context()，超时或锁丢失时正在执行的查询会被取消
+func (m *Migration) DB() *gorm.DB {
+	return m.db
+}
+
+// Context 返回迁移执行的 context，超时或锁丢失时被取消
+// 长时间运行的迁移（如分批回填数据）应定期检查 ctx.Err() 并尽快返回
+func (m *Migration) Context() context.Context {
+	if m.ctx == nil {
+		return context.Background()
+	}
+	return m.ctx
 }
 
 func (m *Migration) Log(format string, args ...interface{}) {
@@ -43,13 +128,23 @@ type MigrationItem struct {
 	Namespace string
 	Name      string
 	Func      MigrationFunc
+	Down      MigrationFunc // 回滚函数（可选）
+	Checksum  string        // 校验和或版本号（可选），修改已应用迁移的内容时应随之更新
+	DependsOn []string      // 依赖的迁移（可选），格式为 "namespace:name"，依赖会先于本迁移执行
 }
 
 // MigrationManager 迁移管理器
 type MigrationManager struct {
-	storage      MigrationStorage
-	lockProvider LockProvider
-	migrations   []*MigrationItem // 使用切片保持顺序
+	storage        MigrationStorage
+	lockProvider   LockProvider
+	migrations     []*MigrationItem // 使用切片保持顺序
+	db             func() *gorm.DB  // 数据库连接提供者
+	useTransaction bool             // 是否在事务中执行每个迁移
+	lockTimeout    int              // 迁移锁超时时间（秒）
+	timeout        time.Duration    // 单个迁移的执行超时，0表示不限制
+	checksumMode   ChecksumMode     // 校验和不一致时的处理方式
+	retryFailed    bool             // 是否重试之前失败的迁移
+	maxAttempts    int              // 失败迁移的最大尝试次数，0表示不限制
 }
 
 func NewMigrationManager(storage MigrationStorage, lockProvider LockProvider) *MigrationManager {
@@ -57,9 +152,126 @@ func NewMigrationManager(storage MigrationStorage, lockProvider LockProvider) *M
 		storage:      storage,
 		lockProvider: lockProvider,
 		migrations:   make([]*MigrationItem, 0),
+		lockTimeout:  defaultMigrationLockTimeout,
+		checksumMode: ChecksumModeWarn,
+		retryFailed:  true,
+		maxAttempts:  defaultMaxAttempts,
+	}
+}
+
+// SetRetryFailed 设置是否重试之前失败的迁移（默认开启）
+// 关闭时，存在失败记录的迁移不会再执行，需通过 ForceMarkApplied 人工处理
+func (m *MigrationManager) SetRetryFailed(enabled bool) {
+	m.retryFailed = enabled
+}
+
+// SetMaxAttempts 设置失败迁移的最大尝试次数，达到后 RunMigrations 返回 ErrMigrationGaveUp，0表示不限制
+func (m *MigrationManager) SetMaxAttempts(attempts int) {
+	if attempts >= 0 {
+		m.maxAttempts = attempts
+	}
+}
+
+// SetChecksumMode 设置已应用迁移的校验和不一致时的处理方式
+func (m *MigrationManager) SetChecksumMode(mode ChecksumMode) {
+	m.checksumMode = mode
+}
+
+// SetLockTimeout 设置迁移锁超时时间（秒）
+// 锁提供者实现 RenewableLockProvider 时，执行期间会按超时时间的三分之一间隔自动续期
+func (m *MigrationManager) SetLockTimeout(seconds int) {
+	if seconds > 0 {
+		m.lockTimeout = seconds
 	}
 }
 
+// SetMigrationTimeout 设置单个迁移的执行超时，0表示不限制
+// 超时后迁移的 context 被取消，迁移被标记为失败并中止本次执行
+// 超时不会强行终止迁移函数：只有通过 Migration.DB() 执行的查询会被取消，
+// 其他耗时操作需要迁移函数自行检查 Migration.Context()，否则会一直执行到结束
+func (m *MigrationManager) SetMigrationTimeout(timeout time.Duration) {
+	m.timeout = timeout
+}
+
+// SetDB 设置迁移使用的数据库连接提供者
+func (m *MigrationManager) SetDB(db func() *gorm.DB) {
+	m.db = db
+}
+
+// UseTransaction 设置是否在 GORM 事务中执行每个迁移（默认关闭）
+// 迁移函数返回 nil 时提交事务，否则回滚
+// 注意：部分数据库（如 MySQL）的 DDL 语句会隐式提交事务，无法随事务回滚
+func (m *MigrationManager) UseTransaction(enabled bool) {
+	m.useTransaction = enabled
+}
+
+// newMigration 创建迁移执行上下文
+func (m *MigrationManager) newMigration() *Migration {
+	migration := &Migration{
+		storage: m.storage,
+	}
+	if m.db != nil {
+		migration.db = m.db()
+	}
+	return migration
+}
+
+// execute 执行迁移函数，启用事务时在事务中执行
+// 锁在执行期间丢失或执行超时时，事务不会被提交
+func (m *MigrationManager) execute(ctx context.Context, fn MigrationFunc, migration *Migration) error {
+	if m.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeoutCause(ctx, m.timeout, ErrMigrationTimeout)
+		defer cancel()
+	}
+
+	db := migration.db
+	migration.ctx = ctx
+	defer func() {
+		migration.db = db
+		migration.ctx = nil
+	}()
+
+	if db == nil {
+		return contextError(ctx, fn(migration))
+	}
+	if !m.useTransaction {
+		migration.db = db.WithContext(ctx)
+		return contextError(ctx, fn(migration))
+	}
+
+	err := db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		migration.db = tx
+		if err := fn(migration); err != nil {
+			return err
+		}
+		return lockError(ctx)
+	})
+	return contextError(ctx, err)
+}
+
+// contextError 迁移因锁丢失或超时失败时，将错误包装为 ErrMigrationLockLost 或 ErrMigrationTimeout
+func contextError(ctx context.Context, err error) error {
+	if err == nil || errors.Is(err, ErrMigrationLockLost) {
+		return err
+	}
+	if errors.Is(context.Cause(ctx), ErrMigrationLockLost) {
+		return fmt.Errorf("%w: %v", ErrMigrationLockLost, err)
+	}
+	return timeoutError(ctx, err)
+}
+
+// timeoutError 迁移因超时失败时，将错误包装为 ErrMigrationTimeout
+func timeoutError(ctx context.Context, err error) error {
+	if err == nil || errors.Is(err, ErrMigrationTimeout) {
+		return err
+	}
+	if errors.Is(context.Cause(ctx), ErrMigrationTimeout) {
+		return fmt.Errorf("%w: %v", ErrMigrationTimeout, err)
+	}
+	return err
+}
+
 func (m *MigrationManager) Register(namespace, name string, fn MigrationFunc) {
 	m.migrations = append(m.migrations, &MigrationItem{
 		Namespace: namespace,
@@ -68,25 +280,126 @@ func (m *MigrationManager) Register(namespace, name string, fn MigrationFunc) {
 	})
 }
 
-func (m *MigrationManager) RunMigrations() error {
-	slog.Info("RunMigrations", "count", len(m.migrations))
-	const lockKey = "migrate_lock"
-	const lockTimeout = 60
+// RegisterWithDown 注册带回滚函数的迁移
+func (m *MigrationManager) RegisterWithDown(namespace, name string, fn, down MigrationFunc) {
+	m.migrations = append(m.migrations, &MigrationItem{
+		Namespace: namespace,
+		Name:      name,
+		Func:      fn,
+		Down:      down,
+	})
+}
 
-	// 获取分布式锁
-	locked, err := m.lockProvider.Lock(lockKey, lockTimeout)
+// RegisterWithChecksum 注册带校验和的迁移，down 为可选的回滚函数
+// checksum 可以是迁移内容的哈希或手工维护的版本号，迁移应用后校验和变化时按 ChecksumMode 处理
+func (m *MigrationManager) RegisterWithChecksum(namespace, name, checksum string, fn, down MigrationFunc) {
+	m.migrations = append(m.migrations, &MigrationItem{
+		Namespace: namespace,
+		Name:      name,
+		Func:      fn,
+		Down:      down,
+		Checksum:  checksum,
+	})
+}
+
+// RegisterItem 注册完整的迁移项，可同时设置回滚函数、校验和和依赖
+func (m *MigrationManager) RegisterItem(item MigrationItem) {
+	m.migrations = append(m.migrations, &item)
+}
+
+const migrationLockKey = "migrate_lock"
+const defaultMigrationLockTimeout = 60
+const defaultMaxAttempts = 3
+
+// acquireLock 获取迁移分布式锁，返回释放函数
+// 返回的 context 在锁续期失败时被取消
+func (m *MigrationManager) acquireLock() (context.Context, func(), error) {
+	locked, err := m.lockProvider.Lock(migrationLockKey, m.lockTimeout)
 	if err != nil {
-		return fmt.Errorf("failed to acquire migration lock: %w", err)
+		return nil, nil, fmt.Errorf("failed to acquire migration lock: %w", err)
 	}
 	if !locked {
-		return fmt.Errorf("migration is already running")
+		return nil, nil, fmt.Errorf("migration is already running")
+	}
+
+	ctx, cancel := context.WithCancelCause(context.Background())
+	done := make(chan struct{})
+
+	if renewer, ok := m.lockProvider.(RenewableLockProvider); ok {
+		go m.renewLock(renewer, cancel, done)
+	}
+
+	return ctx, func() {
+		close(done)
+		cancel(nil)
+		m.lockProvider.Unlock(migrationLockKey)
+	}, nil
+}
+
+// renewLock 定期续期迁移锁，续期失败时取消 context
+func (m *MigrationManager) renewLock(renewer RenewableLockProvider, cancel context.CancelCauseFunc, done chan struct{}) {
+	interval := time.Duration(m.lockTimeout) * time.Second / 3
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+			renewed, err := renewer.Renew(migrationLockKey, m.lockTimeout)
+			if err != nil || !renewed {
+				slog.Error("Failed to renew migration lock", "error", err)
+				cancel(ErrMigrationLockLost)
+				return
+			}
+		}
+	}
+}
+
+// lockError 锁丢失时返回 ErrMigrationLockLost
+func lockError(ctx context.Context) error {
+	if ctx.Err() != nil {
+		return context.Cause(ctx)
+	}
+	return nil
+}
+
+// PlanAction 迁移计划中的动作
+type PlanAction string
+
+const (
+	PlanActionRun  PlanAction = "run"  // 将执行迁移函数
+	PlanActionSkip PlanAction = "skip" // 新环境，仅标记为跳过
+)
+
+// PlannedMigration 迁移计划项
+type PlannedMigration struct {
+	Namespace string         `json:"namespace"`
+	Name      string         `json:"name"`
+	Action    PlanAction     `json:"action"`
+	Item      *MigrationItem `json:"-"`
+}
+
+// Plan 计算待执行的迁移计划但不执行任何迁移函数，也不写入任何日志记录
+// 新环境中会被标记为跳过的迁移以 PlanActionSkip 的形式返回
+func (m *MigrationManager) Plan() ([]PlannedMigration, error) {
+	// 获取分布式锁，保证计划与正在执行的迁移不冲突
+	_, unlock, err := m.acquireLock()
+	if err != nil {
+		return nil, err
 	}
-	defer m.lockProvider.Unlock(lockKey)
+	defer unlock()
+
+	return m.buildPlan()
+}
 
+// buildPlan 根据已应用的迁移计算迁移计划，调用方需持有迁移锁
+func (m *MigrationManager) buildPlan() ([]PlannedMigration, error) {
 	// 获取已应用的迁移
 	appliedMigrations, err := m.storage.GetAppliedMigrations()
 	if err != nil {
-		return fmt.Errorf("failed to get applied migrations: %w", err)
+		return nil, fmt.Errorf("failed to get applied migrations: %w", err)
 	}
 
 	appliedSet := make(map[string]bool)
@@ -103,36 +416,243 @@ func (m *MigrationManager) RunMigrations() error {
 		}
 	}
 
-	// 对于新环境的 namespace，将所有迁移标记为跳过
+	var skipped []PlannedMigration
 	for _, item := range m.migrations {
 		slog.Info("Processing migration", "name", item.Name, "namespace", item.Namespace)
 		key := fmt.Sprintf("%s:%s", item.Namespace, item.Name)
 
 		// 如果该 namespace 没有任何记录，说明是新环境
 		if !namespaceHasRecords[item.Namespace] && !appliedSet[key] {
-			err = m.storage.MarkMigrationSkipped(item.Namespace, item.Name)
-			if err != nil {
-				return fmt.Errorf("failed to mark migration %s as skipped: %w", key, err)
-			}
+			skipped = append(skipped, PlannedMigration{
+				Namespace: item.Namespace,
+				Name:      item.Name,
+				Action:    PlanActionSkip,
+				Item:      item,
+			})
 			appliedSet[key] = true
 			namespaceHasRecords[item.Namespace] = true
 		}
 	}
 
-	// 执行未应用的迁移
+	var pending []*MigrationItem
 	for _, item := range m.migrations {
 		key := fmt.Sprintf("%s:%s", item.Namespace, item.Name)
 		if appliedSet[key] {
 			continue
 		}
+		pending = append(pending, item)
+	}
+
+	ordered, err := m.sortByDependencies(pending, appliedSet)
+	if err != nil {
+		return nil, err
+	}
+
+	plan := skipped
+	for _, item := range ordered {
+		plan = append(plan, PlannedMigration{
+			Namespace: item.Namespace,
+			Name:      item.Name,
+			Action:    PlanActionRun,
+			Item:      item,
+		})
+	}
+
+	return plan, nil
+}
+
+// sortByDependencies 按依赖关系对待执行的迁移排序，没有依赖关系的迁移保持注册顺序
+// 依赖未注册的迁移或存在循环依赖时返回错误
+func (m *MigrationManager) sortByDependencies(pending []*MigrationItem, appliedSet map[string]bool) ([]*MigrationItem, error) {
+	registered := make(map[string]bool, len(m.migrations))
+	for _, item := range m.migrations {
+		registered[fmt.Sprintf("%s:%s", item.Namespace, item.Name)] = true
+	}
+
+	done := make(map[string]bool, len(appliedSet))
+	for key := range appliedSet {
+		done[key] = true
+	}
+
+	for _, item := range pending {
+		for _, dep := range item.DependsOn {
+			if !registered[dep] && !done[dep] {
+				return nil, fmt.Errorf("migration %s:%s depends on unknown migration %s", item.Namespace, item.Name, dep)
+			}
+		}
+	}
 
-		migration := &Migration{
-			storage: m.storage,
+	ordered := make([]*MigrationItem, 0, len(pending))
+	remaining := pending
+	for len(remaining) > 0 {
+		// 每轮选出注册顺序最靠前且依赖均已满足的迁移
+		next := -1
+		for i, item := range remaining {
+			ready := true
+			for _, dep := range item.DependsOn {
+				if !done[dep] {
+					ready = false
+					break
+				}
+			}
+			if ready {
+				next = i
+				break
+			}
 		}
 
+		if next < 0 {
+			keys := make([]string, len(remaining))
+			for i, item := range remaining {
+				keys[i] = fmt.Sprintf("%s:%s", item.Namespace, item.Name)
+			}
+			return nil, fmt.Errorf("circular migration dependency among: %s", strings.Join(keys, ", "))
+		}
+
+		item := remaining[next]
+		ordered = append(ordered, item)
+		done[fmt.Sprintf("%s:%s", item.Namespace, item.Name)] = true
+		remaining = append(remaining[:next:next], remaining[next+1:]...)
+	}
+
+	return ordered, nil
+}
+
+// MigrationState 迁移状态
+type MigrationState string
+
+const (
+	MigrationStateApplied MigrationState = "applied" // 已执行成功
+	MigrationStateFailed  MigrationState = "failed"  // 最近一次执行失败
+	MigrationStateSkipped MigrationState = "skipped" // 新环境中标记为跳过
+	MigrationStatePending MigrationState = "pending" // 尚未执行或已回滚
+)
+
+// MigrationStatus 已注册迁移的状态
+type MigrationStatus struct {
+	Namespace string         `json:"namespace"`
+	Name      string         `json:"name"`
+	State     MigrationState `json:"state"`
+	Error     string         `json:"error,omitempty"`
+	At        *time.Time     `json:"at,omitempty"` // 最近一次记录的时间
+}
+
+// Status 按注册顺序返回所有迁移的状态，不获取迁移锁也不执行任何迁移
+// 存储未实现 MigrationRecordStorage 时只区分 applied 和 pending
+func (m *MigrationManager) Status() ([]MigrationStatus, error) {
+	appliedMigrations, err := m.storage.GetAppliedMigrations()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get applied migrations: %w", err)
+	}
+
+	appliedSet := make(map[string]bool)
+	for _, name := range appliedMigrations {
+		appliedSet[name] = true
+	}
+
+	// 每个迁移只保留最近一条记录
+	latest := make(map[string]MigrationRecord)
+	if recordStorage, ok := m.storage.(MigrationRecordStorage); ok {
+		records, err := recordStorage.GetMigrationRecords()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get migration records: %w", err)
+		}
+		for _, record := range records {
+			latest[fmt.Sprintf("%s:%s", record.Namespace, record.Name)] = record
+		}
+	}
+
+	statuses := make([]MigrationStatus, 0, len(m.migrations))
+	for _, item := range m.migrations {
+		key := fmt.Sprintf("%s:%s", item.Namespace, item.Name)
+		status := MigrationStatus{
+			Namespace: item.Namespace,
+			Name:      item.Name,
+			State:     MigrationStatePending,
+		}
+		if appliedSet[key] {
+			status.State = MigrationStateApplied
+		}
+
+		if record, ok := latest[key]; ok {
+			at := record.At
+			status.At = &at
+			switch record.State {
+			case MigrationStateSkipped, MigrationStateFailed:
+				status.State = record.State
+				status.Error = record.Error
+			}
+		}
+
+		statuses = append(statuses, status)
+	}
+
+	return statuses, nil
+}
+
+func (m *MigrationManager) RunMigrations() error {
+	slog.Info("RunMigrations", "count", len(m.migrations))
+
+	// 获取分布式锁
+	ctx, unlock, err := m.acquireLock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	if err := m.verifyChecksums(); err != nil {
+		return err
+	}
+
+	plan, err := m.buildPlan()
+	if err != nil {
+		return err
+	}
+
+	// 对于新环境的 namespace，将迁移标记为跳过
+	for _, planned := range plan {
+		if planned.Action != PlanActionSkip {
+			continue
+		}
+		key := fmt.Sprintf("%s:%s", planned.Namespace, planned.Name)
+		err = m.storage.MarkMigrationSkipped(planned.Namespace, planned.Name)
+		if err != nil {
+			return fmt.Errorf("failed to mark migration %s as skipped: %w", key, err)
+		}
+		if err := m.saveChecksum(planned.Item); err != nil {
+			return err
+		}
+	}
+
+	failedAttempts, err := m.failedAttempts()
+	if err != nil {
+		return err
+	}
+
+	// 执行未应用的迁移
+	for _, planned := range plan {
+		if planned.Action != PlanActionRun {
+			continue
+		}
+		item := planned.Item
+
+		// 锁已丢失，其他实例可能已开始执行迁移，立即中止
+		if err := lockError(ctx); err != nil {
+			return fmt.Errorf("migration aborted before %s:%s: %w", item.Namespace, item.Name, err)
+		}
+
+		if attempts := failedAttempts[fmt.Sprintf("%s:%s", item.Namespace, item.Name)]; attempts > 0 {
+			if !m.retryFailed || (m.maxAttempts > 0 && attempts >= m.maxAttempts) {
+				return fmt.Errorf("%w %s:%s after %d attempts, fix it and use ForceMarkApplied or remove its failure records", ErrMigrationGaveUp, item.Namespace, item.Name, attempts)
+			}
+			slog.Warn("Retrying failed migration", "name", item.Name, "namespace", item.Namespace, "attempts", attempts)
+		}
+
+		migration := m.newMigration()
+
 		migration.Log("Starting migration: %s:%s at %s", item.Namespace, item.Name, time.Now().Format(time.RFC3339))
 
-		err := item.Func(migration)
+		err := m.execute(ctx, item.Func, migration)
 		if err != nil {
 			errorMsg := fmt.Sprintf("Migration failed: %v\nLogs:\n%s", err, migration.LogString())
 			m.storage.MarkMigrationFailed(item.Namespace, item.Name, errorMsg)
@@ -145,7 +665,193 @@ func (m *MigrationManager) RunMigrations() error {
 		if err != nil {
 			return fmt.Errorf("failed to mark migration %s:%s as applied: %w", item.Namespace, item.Name, err)
 		}
+		if err := m.saveChecksum(item); err != nil {
+			return err
+		}
 	}
 
 	return nil
 }
+
+// failedAttempts 获取各迁移连续失败的次数，存储未实现 FailureStorage 时返回nil
+func (m *MigrationManager) failedAttempts() (map[string]int, error) {
+	failureStorage, ok := m.storage.(FailureStorage)
+	if !ok {
+		return nil, nil
+	}
+	attempts, err := failureStorage.GetFailedAttempts()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get failed migration attempts: %w", err)
+	}
+	return attempts, nil
+}
+
+// ForceMarkApplied 将迁移标记为已应用而不执行，用于人工处理失败的迁移
+func (m *MigrationManager) ForceMarkApplied(namespace, name string) error {
+	_, unlock, err := m.acquireLock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	if err := m.storage.MarkMigrationApplied(namespace, name); err != nil {
+		return fmt.Errorf("failed to mark migration %s:%s as applied: %w", namespace, name, err)
+	}
+
+	for _, item := range m.migrations {
+		if item.Namespace == namespace && item.Name == name {
+			if err := m.saveChecksum(item); err != nil {
+				return err
+			}
+			break
+		}
+	}
+
+	slog.Warn("Migration force marked as applied", "name", name, "namespace", namespace)
+	return nil
+}
+
+// verifyChecksums 检查已应用迁移的校验和是否与注册时一致
+// 存储未实现 ChecksumStorage，或迁移未设置校验和、应用时未记录校验和时不检查
+func (m *MigrationManager) verifyChecksums() error {
+	checksumStorage, ok := m.storage.(ChecksumStorage)
+	if !ok {
+		return nil
+	}
+
+	checksums, err := checksumStorage.GetMigrationChecksums()
+	if err != nil {
+		return fmt.Errorf("failed to get migration checksums: %w", err)
+	}
+
+	for _, item := range m.migrations {
+		key := fmt.Sprintf("%s:%s", item.Namespace, item.Name)
+		stored := checksums[key]
+		if item.Checksum == "" || stored == "" || stored == item.Checksum {
+			continue
+		}
+
+		if m.checksumMode == ChecksumModeFail {
+			return fmt.Errorf("migration %s was modified after being applied: checksum %s, recorded %s", key, item.Checksum, stored)
+		}
+		slog.Warn("Applied migration was modified", "name", item.Name, "namespace", item.Namespace, "checksum", item.Checksum, "recorded", stored)
+	}
+	return nil
+}
+
+// saveChecksum 记录迁移的校验和
+func (m *MigrationManager) saveChecksum(item *MigrationItem) error {
+	checksumStorage, ok := m.storage.(ChecksumStorage)
+	if !ok || item.Checksum == "" {
+		return nil
+	}
+	if err := checksumStorage.SetMigrationChecksum(item.Namespace, item.Name, item.Checksum); err != nil {
+		return fmt.Errorf("failed to save checksum of migration %s:%s: %w", item.Namespace, item.Name, err)
+	}
+	return nil
+}
+
+// Rollback 按应用顺序的逆序回滚指定 namespace 最近应用的 steps 个迁移
+// 存储需要实现 RollbackStorage；实现 MigrationRecordStorage 时按迁移日志中的应用顺序回滚，
+// 跳过被标记为 skipped 的迁移（它们从未执行过，没有可回滚的内容），否则按 GetAppliedMigrations 返回的顺序
+// 遇到未注册回滚函数的迁移时停止并返回错误
+// 注意：回滚后该迁移记录会被标记为未成功，下次 RunMigrations 时会重新执行
+func (m *MigrationManager) Rollback(namespace string, steps int) error {
+	if steps <= 0 {
+		return nil
+	}
+
+	rollbackStorage, ok := m.storage.(RollbackStorage)
+	if !ok {
+		return fmt.Errorf("migration storage %T does not support rollback", m.storage)
+	}
+
+	// 获取分布式锁
+	ctx, unlock, err := m.acquireLock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	applied, err := m.appliedOrder()
+	if err != nil {
+		return err
+	}
+
+	items := make(map[string]*MigrationItem, len(m.migrations))
+	for _, item := range m.migrations {
+		items[fmt.Sprintf("%s:%s", item.Namespace, item.Name)] = item
+	}
+
+	for i := len(applied) - 1; i >= 0 && steps > 0; i-- {
+		key := applied[i]
+		item, ok := items[key]
+		if !ok {
+			if strings.HasPrefix(key, namespace+":") {
+				return fmt.Errorf("migration %s is applied but not registered, cannot rollback", key)
+			}
+			continue
+		}
+		if item.Namespace != namespace {
+			continue
+		}
+
+		if item.Down == nil {
+			return fmt.Errorf("migration %s has no down function, cannot rollback", key)
+		}
+
+		if err := lockError(ctx); err != nil {
+			return fmt.Errorf("rollback aborted before %s: %w", key, err)
+		}
+
+		migration := m.newMigration()
+
+		migration.Log("Starting rollback: %s at %s", key, time.Now().Format(time.RFC3339))
+
+		if err := m.execute(ctx, item.Down, migration); err != nil {
+			return fmt.Errorf("rollback of migration %s failed: %w\nLogs:\n%s", key, err, migration.LogString())
+		}
+
+		if err := rollbackStorage.MarkMigrationRolledBack(item.Namespace, item.Name); err != nil {
+			return fmt.Errorf("failed to mark migration %s as rolled back: %w", key, err)
+		}
+
+		slog.Info("Migration rolled back", "name", item.Name, "namespace", item.Namespace)
+		steps--
+	}
+
+	return nil
+}
+
+// appliedOrder 返回当前处于已应用状态的迁移，按应用顺序排列，键为 "namespace:name"
+// 存储实现 MigrationRecordStorage 时以每个迁移最近一条记录为准，跳过的、失败的和已回滚的迁移不包含在内
+func (m *MigrationManager) appliedOrder() ([]string, error) {
+	recordStorage, ok := m.storage.(MigrationRecordStorage)
+	if !ok {
+		applied, err := m.storage.GetAppliedMigrations()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get applied migrations: %w", err)
+		}
+		return applied, nil
+	}
+
+	records, err := recordStorage.GetMigrationRecords()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get migration records: %w", err)
+	}
+
+	// 每个迁移只保留最近一条记录及其位置
+	latest := make(map[string]int, len(records))
+	for i, record := range records {
+		latest[fmt.Sprintf("%s:%s", record.Namespace, record.Name)] = i
+	}
+
+	applied := make([]string, 0, len(latest))
+	for i, record := range records {
+		key := fmt.Sprintf("%s:%s", record.Namespace, record.Name)
+		if latest[key] == i && record.State == MigrationStateApplied {
+			applied = append(applied, key)
+		}
+	}
+	return applied, nil
+}
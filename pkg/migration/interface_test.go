@@ -0,0 +1,321 @@
+package migration
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// memoryStorage 内存中的迁移存储，记录语义与 DefaultDatabaseMigrationStorage 一致
+type memoryStorage struct {
+	records []MigrationRecord
+}
+
+func (s *memoryStorage) GetAppliedMigrations() ([]string, error) {
+	var names []string
+	seen := make(map[string]bool)
+	for _, record := range s.records {
+		key := fmt.Sprintf("%s:%s", record.Namespace, record.Name)
+		if (record.State == MigrationStateApplied || record.State == MigrationStateSkipped) && !seen[key] {
+			seen[key] = true
+			names = append(names, key)
+		}
+	}
+	return names, nil
+}
+
+func (s *memoryStorage) add(namespace, name string, state MigrationState, errorMsg string) error {
+	s.records = append(s.records, MigrationRecord{Namespace: namespace, Name: name, State: state, Error: errorMsg, At: time.Now()})
+	return nil
+}
+
+func (s *memoryStorage) MarkMigrationApplied(namespace, name string) error {
+	return s.add(namespace, name, MigrationStateApplied, "")
+}
+
+func (s *memoryStorage) MarkMigrationFailed(namespace, name string, errorMsg string) error {
+	return s.add(namespace, name, MigrationStateFailed, errorMsg)
+}
+
+func (s *memoryStorage) MarkMigrationSkipped(namespace, name string) error {
+	return s.add(namespace, name, MigrationStateSkipped, "")
+}
+
+func (s *memoryStorage) MarkMigrationRolledBack(namespace, name string) error {
+	for i, record := range s.records {
+		if record.Namespace == namespace && record.Name == name && record.State == MigrationStateApplied {
+			s.records[i].State = MigrationStatePending
+		}
+	}
+	return nil
+}
+
+func (s *memoryStorage) GetMigrationRecords() ([]MigrationRecord, error) {
+	return s.records, nil
+}
+
+// minimalStorage 只实现必需的 MigrationStorage 方法
+type minimalStorage struct{ s *memoryStorage }
+
+func (m minimalStorage) GetAppliedMigrations() ([]string, error) { return m.s.GetAppliedMigrations() }
+func (m minimalStorage) MarkMigrationApplied(namespace, name string) error {
+	return m.s.MarkMigrationApplied(namespace, name)
+}
+func (m minimalStorage) MarkMigrationFailed(namespace, name string, errorMsg string) error {
+	return m.s.MarkMigrationFailed(namespace, name, errorMsg)
+}
+func (m minimalStorage) MarkMigrationSkipped(namespace, name string) error {
+	return m.s.MarkMigrationSkipped(namespace, name)
+}
+
+// memoryLock 进程内的迁移锁
+type memoryLock struct {
+	held map[string]bool
+}
+
+func (l *memoryLock) Lock(key string, seconds int) (bool, error) {
+	if l.held == nil {
+		l.held = make(map[string]bool)
+	}
+	if l.held[key] {
+		return false, nil
+	}
+	l.held[key] = true
+	return true, nil
+}
+
+func (l *memoryLock) Unlock(key string) error {
+	delete(l.held, key)
+	return nil
+}
+
+// expiringLock 续期成功 renewals 次后模拟锁过期，之后的 Renew 返回 false
+type expiringLock struct {
+	memoryLock
+	mutex    sync.Mutex
+	renewals int
+	calls    int
+}
+
+func (l *expiringLock) Renew(key string, seconds int) (bool, error) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	l.calls++
+	return l.calls <= l.renewals, nil
+}
+
+func noop(*Migration) error { return nil }
+
+func TestRollbackFollowsAppliedOrderAndSkipsSkipped(t *testing.T) {
+	storage := &memoryStorage{}
+	m := NewMigrationManager(storage, &memoryLock{})
+
+	var rolledBack []string
+	down := func(name string) MigrationFunc {
+		return func(*Migration) error {
+			rolledBack = append(rolledBack, name)
+			return nil
+		}
+	}
+
+	// 新环境中第一个迁移被标记为跳过；c 依赖 d，因此应用顺序为 d、c，与注册顺序相反
+	m.RegisterWithDown("app", "init", noop, down("init"))
+	m.RegisterItem(MigrationItem{Namespace: "app", Name: "c", Func: noop, Down: down("c"), DependsOn: []string{"app:d"}})
+	m.RegisterWithDown("app", "d", noop, down("d"))
+
+	if err := m.RunMigrations(); err != nil {
+		t.Fatalf("RunMigrations: %v", err)
+	}
+
+	if err := m.Rollback("app", 3); err != nil {
+		t.Fatalf("Rollback: %v", err)
+	}
+	if got := strings.Join(rolledBack, ","); got != "c,d" {
+		t.Fatalf("rolled back %s, want c,d (skipped init must not be rolled back)", got)
+	}
+
+	applied, _ := storage.GetAppliedMigrations()
+	if len(applied) != 1 || applied[0] != "app:init" {
+		t.Fatalf("applied after rollback = %v, want only app:init", applied)
+	}
+}
+
+func TestRollbackStopsAtSteps(t *testing.T) {
+	storage := &memoryStorage{}
+	storage.MarkMigrationApplied("app", "base")
+	m := NewMigrationManager(storage, &memoryLock{})
+
+	var rolledBack []string
+	for _, name := range []string{"base", "one", "two"} {
+		name := name
+		m.RegisterWithDown("app", name, noop, func(*Migration) error {
+			rolledBack = append(rolledBack, name)
+			return nil
+		})
+	}
+	if err := m.RunMigrations(); err != nil {
+		t.Fatalf("RunMigrations: %v", err)
+	}
+
+	if err := m.Rollback("app", 1); err != nil {
+		t.Fatalf("Rollback: %v", err)
+	}
+	if got := strings.Join(rolledBack, ","); got != "two" {
+		t.Fatalf("rolled back %s, want two", got)
+	}
+}
+
+func TestRollbackRequiresRollbackStorage(t *testing.T) {
+	m := NewMigrationManager(minimalStorage{&memoryStorage{}}, &memoryLock{})
+	m.RegisterWithDown("app", "one", noop, noop)
+
+	err := m.Rollback("app", 1)
+	if err == nil || !strings.Contains(err.Error(), "does not support rollback") {
+		t.Fatalf("error = %v, want unsupported rollback", err)
+	}
+}
+
+func TestMigrationTimeout(t *testing.T) {
+	storage := &memoryStorage{}
+	storage.MarkMigrationApplied("app", "base")
+	m := NewMigrationManager(storage, &memoryLock{})
+	m.SetMigrationTimeout(20 * time.Millisecond)
+
+	m.Register("app", "base", noop)
+	m.Register("app", "slow", func(migration *Migration) error {
+		select {
+		case <-migration.Context().Done():
+			return migration.Context().Err()
+		case <-time.After(time.Second):
+			return nil
+		}
+	})
+
+	err := m.RunMigrations()
+	if !errors.Is(err, ErrMigrationTimeout) {
+		t.Fatalf("error = %v, want ErrMigrationTimeout", err)
+	}
+
+	last := storage.records[len(storage.records)-1]
+	if last.Name != "slow" || last.State != MigrationStateFailed {
+		t.Fatalf("last record = %+v, want slow marked as failed", last)
+	}
+}
+func TestPlanOrdersDependencyChain(t *testing.T) {
+	storage := &memoryStorage{}
+	storage.MarkMigrationApplied("app", "base")
+	m := NewMigrationManager(storage, &memoryLock{})
+
+	m.Register("app", "base", noop)
+	m.RegisterItem(MigrationItem{Namespace: "app", Name: "a", Func: noop, DependsOn: []string{"billing:b"}})
+	m.RegisterItem(MigrationItem{Namespace: "billing", Name: "b", Func: noop, DependsOn: []string{"app:c"}})
+	m.RegisterItem(MigrationItem{Namespace: "app", Name: "c", Func: noop})
+	storage.MarkMigrationApplied("billing", "base")
+
+	plan, err := m.Plan()
+	if err != nil {
+		t.Fatalf("Plan: %v", err)
+	}
+
+	var order []string
+	for _, planned := range plan {
+		order = append(order, fmt.Sprintf("%s:%s:%s", planned.Action, planned.Namespace, planned.Name))
+	}
+	if got := strings.Join(order, ","); got != "run:app:c,run:billing:b,run:app:a" {
+		t.Fatalf("plan = %s, want c, b, a", got)
+	}
+}
+
+func TestPlanRejectsDependencyCycle(t *testing.T) {
+	storage := &memoryStorage{}
+	storage.MarkMigrationApplied("app", "base")
+	m := NewMigrationManager(storage, &memoryLock{})
+
+	m.Register("app", "base", noop)
+	m.RegisterItem(MigrationItem{Namespace: "app", Name: "a", Func: noop, DependsOn: []string{"app:b"}})
+	m.RegisterItem(MigrationItem{Namespace: "app", Name: "b", Func: noop, DependsOn: []string{"app:a"}})
+
+	_, err := m.Plan()
+	if err == nil || !strings.Contains(err.Error(), "circular migration dependency") {
+		t.Fatalf("error = %v, want circular dependency", err)
+	}
+}
+
+func TestRunMigrationsAbortsWhenLockExpires(t *testing.T) {
+	storage := &memoryStorage{}
+	storage.MarkMigrationApplied("app", "base")
+	lock := &expiringLock{renewals: 1}
+	m := NewMigrationManager(storage, lock)
+	m.SetLockTimeout(1) // 约每333ms续期一次，第二次续期失败
+
+	laterRan := false
+	m.Register("app", "base", noop)
+	m.Register("app", "slow", func(migration *Migration) error {
+		select {
+		case <-migration.Context().Done():
+			return migration.Context().Err()
+		case <-time.After(5 * time.Second):
+			return nil
+		}
+	})
+	m.Register("app", "later", func(*Migration) error {
+		laterRan = true
+		return nil
+	})
+
+	err := m.RunMigrations()
+	if !errors.Is(err, ErrMigrationLockLost) {
+		t.Fatalf("error = %v, want ErrMigrationLockLost", err)
+	}
+	if laterRan {
+		t.Fatal("migrations after the lock was lost must not run")
+	}
+
+	applied, _ := storage.GetAppliedMigrations()
+	if strings.Join(applied, ",") != "app:base" {
+		t.Fatalf("applied = %v, want only app:base", applied)
+	}
+	lock.mutex.Lock()
+	calls := lock.calls
+	lock.mutex.Unlock()
+	if calls != 2 {
+		t.Fatalf("Renew called %d times, want 2 (one success, then the failure)", calls)
+	}
+	if lock.held[migrationLockKey] {
+		t.Fatal("lock should be released after the aborted run")
+	}
+}
+
+func TestRollbackStopsAtMigrationWithoutDown(t *testing.T) {
+	storage := &memoryStorage{}
+	storage.MarkMigrationApplied("app", "base")
+	m := NewMigrationManager(storage, &memoryLock{})
+
+	downRan := false
+	m.Register("app", "base", noop)
+	m.RegisterWithDown("app", "reversible", noop, func(*Migration) error {
+		downRan = true
+		return nil
+	})
+	m.Register("app", "irreversible", noop)
+	if err := m.RunMigrations(); err != nil {
+		t.Fatalf("RunMigrations: %v", err)
+	}
+	before, _ := storage.GetAppliedMigrations()
+
+	err := m.Rollback("app", 2)
+	if err == nil || !strings.Contains(err.Error(), "app:irreversible") || !strings.Contains(err.Error(), "no down function") {
+		t.Fatalf("error = %v, want no down function for app:irreversible", err)
+	}
+	if downRan {
+		t.Fatal("no down function should run when the latest migration cannot be rolled back")
+	}
+
+	after, _ := storage.GetAppliedMigrations()
+	if strings.Join(after, ",") != strings.Join(before, ",") {
+		t.Fatalf("applied = %v, want unchanged %v", after, before)
+	}
+}
@@ -2,32 +2,95 @@ package migration
 
 import (
 	"context"
+	"crypto/rand"
+	"encoding/hex"
 	"fmt"
+	"sync"
 	"time"
 
 	"github.com/flaboy/aira-core/pkg/database"
 	"github.com/flaboy/aira-core/pkg/redis"
 	"github.com/flaboy/aira-web/pkg/config"
+
+	goredis "github.com/redis/go-redis/v9"
 )
 
 func init() {
 	defaultStorage := &DefaultDatabaseMigrationStorage{}
 	lockProvider := &RedisLockProvider{}
 	migrationManager = NewMigrationManager(defaultStorage, lockProvider)
+	migrationManager.SetDB(database.Database)
 }
 
 // RedisLockProvider 基于Redis的分布式锁实现
-type RedisLockProvider struct{}
+// 加锁时为每个持有者写入随机令牌，续期和解锁只在令牌匹配时生效，
+// 锁过期后被其他实例获取时，原持有者不会误续期或误删别人的锁
+type RedisLockProvider struct {
+	mutex  sync.Mutex
+	tokens map[string]string // 当前实例持有的锁及其令牌
+}
+
+var (
+	// renewLockScript 令牌匹配时刷新过期时间
+	renewLockScript = goredis.NewScript(`if redis.call("GET", KEYS[1]) == ARGV[1] then return redis.call("PEXPIRE", KEYS[1], ARGV[2]) else return 0 end`)
+	// unlockScript 令牌匹配时删除锁
+	unlockScript = goredis.NewScript(`if redis.call("GET", KEYS[1]) == ARGV[1] then return redis.call("DEL", KEYS[1]) else return 0 end`)
+)
 
 func (r *RedisLockProvider) Lock(key string, seconds int) (bool, error) {
+	token, err := newLockToken()
+	if err != nil {
+		return false, err
+	}
+
 	ctx := context.Background()
-	result := redis.RedisClient.SetNX(ctx, key, "locked", time.Duration(seconds)*time.Second)
-	return result.Val(), result.Err()
+	ok, err := redis.RedisClient.SetNX(ctx, key, token, time.Duration(seconds)*time.Second).Result()
+	if err != nil || !ok {
+		return false, err
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if r.tokens == nil {
+		r.tokens = make(map[string]string)
+	}
+	r.tokens[key] = token
+	return true, nil
 }
 
 func (r *RedisLockProvider) Unlock(key string) error {
+	r.mutex.Lock()
+	token, ok := r.tokens[key]
+	delete(r.tokens, key)
+	r.mutex.Unlock()
+	if !ok {
+		return nil
+	}
+
+	ctx := context.Background()
+	return unlockScript.Run(ctx, redis.RedisClient, []string{key}, token).Err()
+}
+
+func (r *RedisLockProvider) Renew(key string, seconds int) (bool, error) {
+	r.mutex.Lock()
+	token, ok := r.tokens[key]
+	r.mutex.Unlock()
+	if !ok {
+		return false, nil
+	}
+
 	ctx := context.Background()
-	return redis.RedisClient.Del(ctx, key).Err()
+	renewed, err := renewLockScript.Run(ctx, redis.RedisClient, []string{key}, token, (time.Duration(seconds) * time.Second).Milliseconds()).Int()
+	return renewed == 1, err
+}
+
+// newLockToken 生成锁持有者的随机令牌
+func newLockToken() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
 }
 
 // MigrationLog 迁移日志模型
@@ -38,10 +101,12 @@ type MigrationLog struct {
 	AppliedAt time.Time
 	Logs      string `gorm:"type:text"`
 	Success   bool
+	Checksum  string `gorm:"size:64"`
+	Attempts  int    // 失败记录的连续尝试次数
 }
 
 func (m *MigrationLog) TableName() string {
-	return config.Config.AiraTablePreifix + "migration_logs"
+	return config.TablePrefix() + "migration_logs"
 }
 
 // DefaultDatabaseMigrationStorage 默认的数据库存储实现
@@ -89,16 +154,50 @@ func (d *DefaultDatabaseMigrationStorage) MarkMigrationFailed(namespace, name st
 		return err
 	}
 
+	var logs []MigrationLog
+	err := database.Database().Where("namespace = ? AND migration = ?", namespace, name).Order("id").Find(&logs).Error
+	if err != nil {
+		return err
+	}
+
 	log := MigrationLog{
 		Migration: name,
 		Namespace: namespace,
 		AppliedAt: time.Now(),
 		Success:   false,
 		Logs:      errorMsg,
+		Attempts:  failedAttempts(logs)[fmt.Sprintf("%s:%s", namespace, name)] + 1,
 	}
 	return database.Database().Create(&log).Error
 }
 
+func (d *DefaultDatabaseMigrationStorage) GetFailedAttempts() (map[string]int, error) {
+	// 确保迁移日志表存在
+	if err := database.Database().AutoMigrate(&MigrationLog{}); err != nil {
+		return nil, err
+	}
+
+	var logs []MigrationLog
+	if err := database.Database().Order("id").Find(&logs).Error; err != nil {
+		return nil, err
+	}
+	return failedAttempts(logs), nil
+}
+
+// failedAttempts 按日志顺序统计各迁移自最近一次成功或回滚以来的失败次数
+func failedAttempts(logs []MigrationLog) map[string]int {
+	attempts := make(map[string]int)
+	for _, log := range logs {
+		key := fmt.Sprintf("%s:%s", log.Namespace, log.Migration)
+		if log.Success || log.Logs == "rollback" {
+			delete(attempts, key)
+			continue
+		}
+		attempts[key]++
+	}
+	return attempts
+}
+
 func (d *DefaultDatabaseMigrationStorage) MarkMigrationSkipped(namespace, name string) error {
 	// 确保迁移日志表存在
 	if err := database.Database().AutoMigrate(&MigrationLog{}); err != nil {
@@ -115,6 +214,82 @@ func (d *DefaultDatabaseMigrationStorage) MarkMigrationSkipped(namespace, name s
 	return database.Database().Create(&log).Error
 }
 
+func (d *DefaultDatabaseMigrationStorage) MarkMigrationRolledBack(namespace, name string) error {
+	// 确保迁移日志表存在
+	if err := database.Database().AutoMigrate(&MigrationLog{}); err != nil {
+		return err
+	}
+
+	return database.Database().Model(&MigrationLog{}).
+		Where("namespace = ? AND migration = ? AND success = ?", namespace, name, true).
+		Updates(map[string]interface{}{"success": false, "logs": "rollback"}).Error
+}
+
+// GetMigrationChecksums 返回已应用迁移最近记录的校验和
+func (d *DefaultDatabaseMigrationStorage) GetMigrationChecksums() (map[string]string, error) {
+	// 确保迁移日志表存在
+	if err := database.Database().AutoMigrate(&MigrationLog{}); err != nil {
+		return nil, err
+	}
+
+	var logs []MigrationLog
+	err := database.Database().Where("success = ? AND checksum <> ?", true, "").Order("id").Find(&logs).Error
+	if err != nil {
+		return nil, err
+	}
+
+	checksums := make(map[string]string, len(logs))
+	for _, log := range logs {
+		checksums[fmt.Sprintf("%s:%s", log.Namespace, log.Migration)] = log.Checksum
+	}
+	return checksums, nil
+}
+
+func (d *DefaultDatabaseMigrationStorage) SetMigrationChecksum(namespace, name, checksum string) error {
+	// 确保迁移日志表存在
+	if err := database.Database().AutoMigrate(&MigrationLog{}); err != nil {
+		return err
+	}
+
+	return database.Database().Model(&MigrationLog{}).
+		Where("namespace = ? AND migration = ? AND success = ?", namespace, name, true).
+		Update("checksum", checksum).Error
+}
+
+// GetMigrationRecords 按执行顺序返回所有迁移日志记录
+func (d *DefaultDatabaseMigrationStorage) GetMigrationRecords() ([]MigrationRecord, error) {
+	// 确保迁移日志表存在
+	if err := database.Database().AutoMigrate(&MigrationLog{}); err != nil {
+		return nil, err
+	}
+
+	var logs []MigrationLog
+	if err := database.Database().Order("id").Find(&logs).Error; err != nil {
+		return nil, err
+	}
+
+	records := make([]MigrationRecord, 0, len(logs))
+	for _, log := range logs {
+		record := MigrationRecord{
+			Namespace: log.Namespace,
+			Name:      log.Migration,
+			State:     MigrationStateApplied,
+			At:        log.AppliedAt,
+		}
+		switch {
+		case log.Success && log.Logs == "skip":
+			record.State = MigrationStateSkipped
+		case !log.Success && log.Logs == "rollback":
+			record.State = MigrationStatePending
+		case !log.Success:
+			record.State = MigrationStateFailed
+			record.Error = log.Logs
+		}
+		records = append(records, record)
+	}
+	return records, nil
+}
+
 // 全局迁移管理器实例
 var migrationManager *MigrationManager
 
@@ -122,9 +297,46 @@ func SetMigrationManager(storage MigrationStorage) {
 	if migrationManager == nil {
 		lockProvider := &RedisLockProvider{}
 		migrationManager = NewMigrationManager(storage, lockProvider)
+		migrationManager.SetDB(database.Database)
 	}
 }
 
+// SetLockTimeout 设置迁移锁超时时间（秒），执行期间会自动续期
+func SetLockTimeout(seconds int) {
+	migrationManager.SetLockTimeout(seconds)
+}
+
+// SetChecksumMode 设置已应用迁移的校验和不一致时的处理方式（默认仅警告）
+func SetChecksumMode(mode ChecksumMode) {
+	migrationManager.SetChecksumMode(mode)
+}
+
+// SetRetryFailed 设置是否重试之前失败的迁移（默认开启）
+func SetRetryFailed(enabled bool) {
+	migrationManager.SetRetryFailed(enabled)
+}
+
+// SetMaxAttempts 设置失败迁移的最大尝试次数（默认3次），0表示不限制
+func SetMaxAttempts(attempts int) {
+	migrationManager.SetMaxAttempts(attempts)
+}
+
+// ForceMarkApplied 将迁移标记为已应用而不执行
+func ForceMarkApplied(namespace, name string) error {
+	return migrationManager.ForceMarkApplied(namespace, name)
+}
+
+// SetMigrationTimeout 设置单个迁移的执行超时，0表示不限制
+// 迁移函数需要使用 Migration.DB() 或检查 Migration.Context() 才能在超时后及时返回
+func SetMigrationTimeout(timeout time.Duration) {
+	migrationManager.SetMigrationTimeout(timeout)
+}
+
+// UseTransaction 设置是否在数据库事务中执行每个迁移（默认关闭）
+func UseTransaction(enabled bool) {
+	migrationManager.UseTransaction(enabled)
+}
+
 func Start() error {
 	for _, model := range needAutoMigrations {
 		if err := database.Database().AutoMigrate(model); err != nil {
@@ -139,6 +351,36 @@ func AddMigrateWithNamespace(namespace, name string, fn func(*Migration) error)
 	migrationManager.Register(namespace, name, fn)
 }
 
+// AddMigrateWithDown 注册带回滚函数的迁移
+func AddMigrateWithDown(namespace, name string, fn, down func(*Migration) error) {
+	migrationManager.RegisterWithDown(namespace, name, fn, down)
+}
+
+// AddMigrateWithChecksum 注册带校验和的迁移，down 为可选的回滚函数
+func AddMigrateWithChecksum(namespace, name, checksum string, fn, down func(*Migration) error) {
+	migrationManager.RegisterWithChecksum(namespace, name, checksum, fn, down)
+}
+
+// AddMigrationItem 注册完整的迁移项，可同时设置回滚函数、校验和和依赖
+func AddMigrationItem(item MigrationItem) {
+	migrationManager.RegisterItem(item)
+}
+
+// Rollback 回滚指定 namespace 下最近应用的 steps 个迁移
+func Rollback(namespace string, steps int) error {
+	return migrationManager.Rollback(namespace, steps)
+}
+
+// Plan 返回待执行的迁移计划（不执行任何迁移）
+func Plan() ([]PlannedMigration, error) {
+	return migrationManager.Plan()
+}
+
+// Status 返回所有已注册迁移的状态
+func Status() ([]MigrationStatus, error) {
+	return migrationManager.Status()
+}
+
 // AddMigrate 注册迁移函数（保持向后兼容）
 func AddMigrate(name string, fn func(*Migration) error) {
 	AddMigrateWithNamespace("app", name, fn)
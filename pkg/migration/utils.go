@@ -1,50 +1,128 @@
 package migration
 
 import (
+	"fmt"
+	"slices"
+	"strings"
+
 	"github.com/flaboy/aira-core/pkg/database"
 	"gorm.io/gorm"
 )
 
+// DefaultProtectedColumnPrefixes 默认受保护的列名前缀，匹配的列不会被删除
+var DefaultProtectedColumnPrefixes = []string{"legacy_"}
+
+// DropColumnsOptions 删除未使用列的选项
+type DropColumnsOptions struct {
+	// DryRun 为 true 时只生成报告，不执行删除
+	DryRun bool
+	// Tables 允许删除列的表名，为空时不处理任何表，必须显式指定
+	Tables []string
+	// ProtectedPrefixes 受保护的列名前缀，为nil时使用 DefaultProtectedColumnPrefixes
+	ProtectedPrefixes []string
+}
+
+// ColumnRef 数据表中的一列
+type ColumnRef struct {
+	Table  string `json:"table"`
+	Column string `json:"column"`
+}
+
+// DropColumnsReport 删除未使用列的结果报告
+type DropColumnsReport struct {
+	DryRun    bool        `json:"dry_run"`
+	Dropped   []ColumnRef `json:"dropped"`   // 已删除的列，DryRun 时为将要删除的列
+	Protected []ColumnRef `json:"protected"` // 因匹配受保护前缀而保留的列
+}
+
+// AutoDropUnusedColumns 删除所有已注册模型对应表中不再出现在结构体中的列，受保护前缀的列会被保留
+//
+// Deprecated: 会处理所有已注册模型的表且不返回报告，请使用 DropUnusedColumns 显式指定表名，并先以 DryRun 模式确认
 func AutoDropUnusedColumns() error {
 	if database.Database() == nil {
 		return nil
 	}
 
-	// Drop unused columns in all registered models
+	opts := DropColumnsOptions{}
 	for _, model := range needAutoMigrations {
-		err := dropUnusedColumns(model)
-		if err != nil {
-			return err
+		stmt := &gorm.Statement{DB: database.Database()}
+		if err := stmt.Parse(model); err != nil {
+			return fmt.Errorf("failed to parse model %T: %v", model, err)
 		}
+		opts.Tables = append(opts.Tables, stmt.Schema.Table)
 	}
-	return nil
+	_, err := DropUnusedColumns(opts)
+	return err
 }
 
-func dropUnusedColumns(dst interface{}) error {
+// DropUnusedColumns 删除已注册模型对应表中不再出现在结构体中的列
+// 只处理 opts.Tables 中列出的表；建议先以 DryRun 模式确认报告
+func DropUnusedColumns(opts DropColumnsOptions) (*DropColumnsReport, error) {
+	report := &DropColumnsReport{DryRun: opts.DryRun}
+	if database.Database() == nil {
+		return report, nil
+	}
+
+	if opts.ProtectedPrefixes == nil {
+		opts.ProtectedPrefixes = DefaultProtectedColumnPrefixes
+	}
+
+	for _, model := range needAutoMigrations {
+		if err := dropModelUnusedColumns(model, opts, report); err != nil {
+			return report, err
+		}
+	}
+	return report, nil
+}
+
+func dropModelUnusedColumns(dst interface{}, opts DropColumnsOptions, report *DropColumnsReport) error {
 	db := database.Database()
 	stmt := &gorm.Statement{DB: db}
-	stmt.Parse(dst)
-	fields := stmt.Schema.Fields
-	columns, _ := db.Migrator().ColumnTypes(dst)
+	if err := stmt.Parse(dst); err != nil {
+		return fmt.Errorf("failed to parse model %T: %v", dst, err)
+	}
+
+	table := stmt.Schema.Table
+	if !slices.Contains(opts.Tables, table) {
+		return nil
+	}
+
+	columns, err := db.Migrator().ColumnTypes(dst)
+	if err != nil {
+		return fmt.Errorf("failed to read columns of %s: %v", table, err)
+	}
 
 	for i := range columns {
-		found := false
-		for j := range fields {
-			if columns[i].Name() == fields[j].DBName {
-				found = true
-				break
-			}
+		name := columns[i].Name()
+		if _, ok := stmt.Schema.FieldsByDBName[name]; ok {
+			continue
+		}
+
+		ref := ColumnRef{Table: table, Column: name}
+		if hasProtectedPrefix(name, opts.ProtectedPrefixes) {
+			report.Protected = append(report.Protected, ref)
+			continue
 		}
-		if !found {
-			err := db.Debug().Migrator().DropColumn(dst, columns[i].Name())
-			if err != nil {
-				return err
+
+		if !opts.DryRun {
+			if err := db.Migrator().DropColumn(dst, name); err != nil {
+				return fmt.Errorf("failed to drop column %s.%s: %v", table, name, err)
 			}
 		}
+		report.Dropped = append(report.Dropped, ref)
 	}
 	return nil
 }
 
+func hasProtectedPrefix(column string, prefixes []string) bool {
+	for _, prefix := range prefixes {
+		if prefix != "" && strings.HasPrefix(column, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
 var needAutoMigrations []interface{}
 
 func RegisterAutoMigrateModels(models ...interface{}) {
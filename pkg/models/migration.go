@@ -70,6 +70,12 @@ func (g *GormMigrationStorage) MarkMigrationFailed(namespace, name string, error
 	return database.Database().Create(&log).Error
 }
 
+func (g *GormMigrationStorage) MarkMigrationRolledBack(namespace, name string) error {
+	return database.Database().Model(&MigrationLogs{}).
+		Where("namespace = ? AND migration = ? AND success = ?", namespace, name, true).
+		Updates(map[string]interface{}{"success": false, "logs": "rollback"}).Error
+}
+
 func init() {
 	migration.RegisterAutoMigrateModels(&MigrationLogs{})
 	migration.SetMigrationManager(&GormMigrationStorage{})
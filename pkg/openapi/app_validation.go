@@ -0,0 +1,85 @@
+package openapi
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/flaboy/aira-web/pkg/openapi/interfaces"
+
+	"github.com/flaboy/pin/usererrors"
+)
+
+// 应用名称与描述的最大长度（按字符计）
+var (
+	MaxAppNameLength        = 100
+	MaxAppDescriptionLength = 500
+)
+
+// ValidateApplicationInput 校验并规范化应用名称与描述，返回去除首尾空白后的值
+// 错误码按字段区分（app_name_*、app_description_*），前端可据此定位输入项
+func ValidateApplicationInput(name, description string) (string, string, *usererrors.Error) {
+	name, verr := validateAppName(name)
+	if verr != nil {
+		return "", "", verr
+	}
+	description, verr = validateAppDescription(description)
+	if verr != nil {
+		return "", "", verr
+	}
+	return name, description, nil
+}
+
+// validateApplicationPatch 校验部分更新中提供的名称与描述，并就地替换为规范化后的值
+func validateApplicationPatch(patch *interfaces.ApplicationPatch) *usererrors.Error {
+	if patch.Name != nil {
+		name, verr := validateAppName(*patch.Name)
+		if verr != nil {
+			return verr
+		}
+		patch.Name = &name
+	}
+	if patch.Description != nil {
+		description, verr := validateAppDescription(*patch.Description)
+		if verr != nil {
+			return verr
+		}
+		patch.Description = &description
+	}
+	return nil
+}
+
+func validateAppName(name string) (string, *usererrors.Error) {
+	name = strings.TrimSpace(name)
+	switch {
+	case name == "":
+		return "", usererrors.New("app_name_required", "name: application name is required")
+	case utf8.RuneCountInString(name) > MaxAppNameLength:
+		return "", usererrors.New("app_name_too_long", fmt.Sprintf("name: application name must not exceed %d characters", MaxAppNameLength))
+	case strings.IndexFunc(name, unicode.IsControl) >= 0:
+		return "", usererrors.New("app_name_invalid_characters", "name: application name must not contain control characters")
+	}
+	return name, nil
+}
+
+func validateAppDescription(description string) (string, *usererrors.Error) {
+	description = strings.TrimSpace(description)
+	switch {
+	case utf8.RuneCountInString(description) > MaxAppDescriptionLength:
+		return "", usererrors.New("app_description_too_long", fmt.Sprintf("description: description must not exceed %d characters", MaxAppDescriptionLength))
+	case strings.IndexFunc(description, isDisallowedDescriptionRune) >= 0:
+		return "", usererrors.New("app_description_invalid_characters", "description: description must not contain control characters")
+	}
+	return description, nil
+}
+
+// isDisallowedDescriptionRune 描述允许换行和制表符，其余控制字符不允许
+func isDisallowedDescriptionRune(r rune) bool {
+	return unicode.IsControl(r) && r != '\n' && r != '\r' && r != '\t'
+}
+
+// newDuplicateAppNameError DeveloperService 报告重名时返回的字段级错误
+func newDuplicateAppNameError() *usererrors.Error {
+	return usererrors.New("app_name_duplicate", "name: an application with this name already exists")
+}
@@ -0,0 +1,98 @@
+package openapi
+
+import (
+	"context"
+
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/sns"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+)
+
+// SQSAPI 事件投递使用的SQS客户端接口，*sqs.Client 实现了此接口
+type SQSAPI interface {
+	SendMessage(ctx context.Context, params *sqs.SendMessageInput, optFns ...func(*sqs.Options)) (*sqs.SendMessageOutput, error)
+	SendMessageBatch(ctx context.Context, params *sqs.SendMessageBatchInput, optFns ...func(*sqs.Options)) (*sqs.SendMessageBatchOutput, error)
+}
+
+// SNSAPI 事件投递使用的SNS客户端接口，*sns.Client 实现了此接口
+type SNSAPI interface {
+	Publish(ctx context.Context, params *sns.PublishInput, optFns ...func(*sns.Options)) (*sns.PublishOutput, error)
+}
+
+// standaloneEndpoint 不属于任何端点的投递（Notifier.Send、死信队列）共用，以复用AWS客户端
+var standaloneEndpoint = &Endpoint{}
+
+// SetSQSClient 设置SQS投递使用的客户端，设置后不再从默认配置创建，可用于测试或自定义配置
+func (e *Endpoint) SetSQSClient(client SQSAPI) {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	e.sqsClient = client
+}
+
+// SetSNSClient 设置SNS投递使用的客户端，设置后不再从默认配置创建
+func (e *Endpoint) SetSNSClient(client SNSAPI) {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	e.snsClient = client
+}
+
+// loadAWSClients 首次使用时加载AWS默认配置并创建客户端，只尝试一次，失败时记录错误
+func (e *Endpoint) loadAWSClients() {
+	e.awsOnce.Do(func() {
+		cfg, err := config.LoadDefaultConfig(context.Background())
+		if err != nil {
+			e.log().Warn("Failed to load AWS config", "error", err)
+			e.mutex.Lock()
+			e.awsErr = err
+			e.mutex.Unlock()
+			return
+		}
+
+		e.mutex.Lock()
+		defer e.mutex.Unlock()
+		if e.sqsClient == nil {
+			e.sqsClient = sqs.NewFromConfig(cfg)
+		}
+		if e.snsClient == nil {
+			e.snsClient = sns.NewFromConfig(cfg)
+		}
+	})
+}
+
+// sqsAPI 返回SQS客户端，AWS配置加载失败时返回加载错误
+func (e *Endpoint) sqsAPI() (SQSAPI, error) {
+	e.mutex.RLock()
+	client := e.sqsClient
+	e.mutex.RUnlock()
+	if client != nil {
+		return client, nil
+	}
+
+	e.loadAWSClients()
+
+	e.mutex.RLock()
+	defer e.mutex.RUnlock()
+	if e.sqsClient == nil {
+		return nil, e.awsErr
+	}
+	return e.sqsClient, nil
+}
+
+// snsAPI 返回SNS客户端，AWS配置加载失败时返回加载错误
+func (e *Endpoint) snsAPI() (SNSAPI, error) {
+	e.mutex.RLock()
+	client := e.snsClient
+	e.mutex.RUnlock()
+	if client != nil {
+		return client, nil
+	}
+
+	e.loadAWSClients()
+
+	e.mutex.RLock()
+	defer e.mutex.RUnlock()
+	if e.snsClient == nil {
+		return nil, e.awsErr
+	}
+	return e.snsClient, nil
+}
@@ -0,0 +1,69 @@
+package openapi
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+const benchmarkQueueURL = "https://sqs.us-east-1.amazonaws.com/123456789012/q"
+
+func TestSendSQSBatchFallsBackToLogWhenAWSConfigFails(t *testing.T) {
+	e := newTestEndpoint()
+	// 模拟AWS配置已加载失败，不会再次加载
+	e.awsOnce.Do(func() {})
+	e.awsErr = errors.New("no credentials")
+
+	failed, err := e.sendSQSBatch(context.Background(), benchmarkQueueURL, []EventPayload{{EventID: "a"}})
+	if err != nil || failed != nil {
+		t.Fatalf("sendSQSBatch = %v, %v, want log fallback without error", failed, err)
+	}
+	if _, err := e.sendSQS(context.Background(), benchmarkQueueURL, EventPayload{EventID: "a"}); err != nil {
+		t.Fatalf("sendSQS error = %v, want log fallback without error", err)
+	}
+}
+
+func TestSendSQSReusesEndpointClient(t *testing.T) {
+	client := &fakeSQS{}
+	e := newTestEndpoint()
+	e.SetSQSClient(client)
+
+	for i := 0; i < 3; i++ {
+		if _, err := e.sendSQSBatch(context.Background(), benchmarkQueueURL, []EventPayload{{EventID: "a"}}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if len(client.batches) != 3 {
+		t.Fatalf("client received %d batches, want 3", len(client.batches))
+	}
+}
+
+// BenchmarkSendSQS 每次发送复用端点缓存的客户端，分配只包含消息编码和请求本身
+func BenchmarkSendSQS(b *testing.B) {
+	e := newTestEndpoint()
+	e.SetSQSClient(&fakeSQS{})
+	payload := EventPayload{EventID: "a", EventCode: "order.created", Data: map[string]interface{}{"id": 1}}
+	ctx := context.Background()
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := e.sendSQS(ctx, benchmarkQueueURL, payload); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// BenchmarkSQSAPI 获取已缓存的客户端不产生分配
+func BenchmarkSQSAPI(b *testing.B) {
+	e := newTestEndpoint()
+	e.SetSQSClient(&fakeSQS{})
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := e.sqsAPI(); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,191 @@
+package openapi
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/flaboy/aira-web/pkg/openapi/interfaces"
+)
+
+// CircuitState 应用投递熔断器状态
+type CircuitState string
+
+const (
+	CircuitClosed   CircuitState = "closed"    // 正常投递
+	CircuitOpen     CircuitState = "open"      // 已熔断，冷却期内事件转入死信
+	CircuitHalfOpen CircuitState = "half_open" // 冷却期结束，允许一次探测投递
+)
+
+// ErrCircuitOpen 应用处于熔断状态，事件未投递
+var ErrCircuitOpen = errors.New("delivery circuit is open")
+
+// CircuitBreakerConfig 熔断器配置
+type CircuitBreakerConfig struct {
+	FailureThreshold int           // 连续失败多少次后熔断，不大于0表示不熔断
+	Cooldown         time.Duration // 熔断后的冷却时间
+}
+
+// DefaultCircuitBreakerConfig 默认熔断器配置
+var DefaultCircuitBreakerConfig = CircuitBreakerConfig{
+	FailureThreshold: 5,
+	Cooldown:         5 * time.Minute,
+}
+
+// DeadLetterSink 死信处理函数，接收熔断期间未投递的事件
+type DeadLetterSink func(app interfaces.ApplicationInfo, payload EventPayload, reason error)
+
+// CircuitBreakerStatus 应用熔断器状态快照
+type CircuitBreakerStatus struct {
+	ApplicationID       string       `json:"application_id"`
+	State               CircuitState `json:"state"`
+	ConsecutiveFailures int          `json:"consecutive_failures"`
+	LastError           string       `json:"last_error,omitempty"`
+	OpenedAt            *time.Time   `json:"opened_at,omitempty"`
+	RetryAt             *time.Time   `json:"retry_at,omitempty"` // 冷却期结束时间，之后进行探测投递
+}
+
+type circuitEntry struct {
+	state     CircuitState
+	failures  int
+	lastError string
+	openedAt  time.Time
+	probing   bool
+}
+
+type circuitBreaker struct {
+	mutex   sync.Mutex
+	config  CircuitBreakerConfig
+	entries map[string]*circuitEntry
+	sink    DeadLetterSink
+}
+
+var breaker = &circuitBreaker{
+	config:  DefaultCircuitBreakerConfig,
+	entries: make(map[string]*circuitEntry),
+}
+
+// SetCircuitBreakerConfig 设置事件投递熔断器配置
+func SetCircuitBreakerConfig(config CircuitBreakerConfig) {
+	breaker.mutex.Lock()
+	defer breaker.mutex.Unlock()
+	breaker.config = config
+}
+
+// SetDeadLetterSink 设置死信处理函数，传入nil时熔断期间的事件仅记录日志
+func SetDeadLetterSink(sink DeadLetterSink) {
+	breaker.mutex.Lock()
+	defer breaker.mutex.Unlock()
+	breaker.sink = sink
+}
+
+// NewSQSDeadLetterSink 创建将死信事件发送到SQS队列的处理函数
+func NewSQSDeadLetterSink(queueURL string) DeadLetterSink {
+	return func(app interfaces.ApplicationInfo, payload EventPayload, reason error) {
+		if _, err := standaloneEndpoint.sendSQS(context.Background(), queueURL, payload); err != nil {
+			standaloneEndpoint.log().Error("Failed to send event to dead-letter queue", "app_id", app.GetID(), "event_id", payload.EventID, "error", err)
+		}
+	}
+}
+
+// GetCircuitBreakerStatus 获取应用的熔断器状态
+func GetCircuitBreakerStatus(appID string) CircuitBreakerStatus {
+	breaker.mutex.Lock()
+	defer breaker.mutex.Unlock()
+
+	status := CircuitBreakerStatus{ApplicationID: appID, State: CircuitClosed}
+	entry, ok := breaker.entries[appID]
+	if !ok {
+		return status
+	}
+
+	status.State = entry.state
+	status.ConsecutiveFailures = entry.failures
+	status.LastError = entry.lastError
+	if entry.state != CircuitClosed {
+		openedAt := entry.openedAt
+		retryAt := openedAt.Add(breaker.config.Cooldown)
+		status.OpenedAt = &openedAt
+		status.RetryAt = &retryAt
+	}
+	return status
+}
+
+// ResetCircuitBreaker 手动恢复应用的投递
+func ResetCircuitBreaker(appID string) {
+	breaker.mutex.Lock()
+	defer breaker.mutex.Unlock()
+	delete(breaker.entries, appID)
+}
+
+// allow 判断是否允许向应用投递；冷却期结束后只放行一次探测投递
+func (b *circuitBreaker) allow(appID string) bool {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	entry, ok := b.entries[appID]
+	if !ok {
+		return true
+	}
+
+	switch entry.state {
+	case CircuitOpen:
+		if time.Since(entry.openedAt) < b.config.Cooldown {
+			return false
+		}
+		entry.state = CircuitHalfOpen
+		entry.probing = true
+		return true
+	case CircuitHalfOpen:
+		if entry.probing {
+			return false
+		}
+		entry.probing = true
+		return true
+	default:
+		return true
+	}
+}
+
+// record 记录投递结果，连续失败达到阈值或探测失败时熔断，熔断日志写入投递端点的 logger
+func (b *circuitBreaker) record(logger *slog.Logger, appID string, deliveryErr error) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	if deliveryErr == nil {
+		delete(b.entries, appID)
+		return
+	}
+
+	entry, ok := b.entries[appID]
+	if !ok {
+		entry = &circuitEntry{state: CircuitClosed}
+		b.entries[appID] = entry
+	}
+	entry.failures++
+	entry.lastError = deliveryErr.Error()
+
+	if entry.state == CircuitHalfOpen || (b.config.FailureThreshold > 0 && entry.failures >= b.config.FailureThreshold) {
+		if entry.state != CircuitOpen {
+			logger.Warn("Event delivery circuit opened", "app_id", appID, "failures", entry.failures, "error", deliveryErr)
+		}
+		entry.state = CircuitOpen
+		entry.openedAt = time.Now()
+		entry.probing = false
+	}
+}
+
+// deadLetter 将未投递的事件交给死信处理函数，没有设置处理函数时记录到投递端点的 logger
+func (b *circuitBreaker) deadLetter(logger *slog.Logger, app interfaces.ApplicationInfo, payload EventPayload) {
+	b.mutex.Lock()
+	sink := b.sink
+	b.mutex.Unlock()
+
+	if sink == nil {
+		logger.Warn("Event dropped by open delivery circuit", "app_id", app.GetID(), "event_id", payload.EventID, "event_code", payload.EventCode)
+		return
+	}
+	sink(app, payload, ErrCircuitOpen)
+}
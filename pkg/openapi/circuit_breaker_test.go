@@ -0,0 +1,34 @@
+package openapi
+
+import (
+	"bytes"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestCircuitBreakerLogsThroughEndpointLogger(t *testing.T) {
+	app := &testApp{id: "breaker-logger", notifyType: "webhook", notifyURL: "https://example.com/hook"}
+	defer ResetCircuitBreaker(app.id)
+	defer SetDeadLetterSink(nil)
+	SetDeadLetterSink(nil)
+
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&buf, nil))
+
+	for i := 0; i < DefaultCircuitBreakerConfig.FailureThreshold; i++ {
+		breaker.record(logger, app.id, errors.New("boom"))
+	}
+	breaker.deadLetter(logger, app, EventPayload{EventID: "evt-1", EventCode: "order.created"})
+
+	out := buf.String()
+	for _, want := range []string{"Event delivery circuit opened", "Event dropped by open delivery circuit", "app_id=breaker-logger", "event_id=evt-1"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("log output missing %q:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "appId") || strings.Contains(out, "eventId") {
+		t.Errorf("log output uses camelCase keys:\n%s", out)
+	}
+}
@@ -1,8 +1,10 @@
 package openapi
 
 import (
-	"strings"
+	"strconv"
+	"time"
 
+	"github.com/flaboy/aira-web/pkg/crud"
 	"github.com/flaboy/aira-web/pkg/openapi/interfaces"
 
 	"github.com/flaboy/pin"
@@ -33,6 +35,7 @@ func init() {
 // HandleDeveloperRequest 处理开发者相关请求的统一入口（使用简化的路由处理器）
 func HandleDeveloperRequest(c *pin.Context, endpointType interfaces.EndpointType, service interfaces.DeveloperService, path string, userID uint) error {
 	method := c.Request.Method
+	c.Set("developer_endpoint_type", endpointType)
 
 	// 使用新的简化处理器
 	return developerAPIHandler.HandleRequest(c, path, method, service, userID)
@@ -47,247 +50,127 @@ func HandleDeveloperRequestLegacy(c *pin.Context, endpointType interfaces.Endpoi
 	return endpoint.HandleDeveloperAPI(c, path, method, service, userID)
 }
 
+// HandleDeveloperAPI 处理开发者API请求
+// 与 HandleDeveloperRequest 共用 developerAPIHandler 的路由表，按路径段精确匹配，
+// 避免应用ID与子资源名称相同（如 apps/event-subscriptions）时路由错误
 func (e *Endpoint) HandleDeveloperAPI(c *pin.Context, path string, method string, service interfaces.DeveloperService, userID uint) error {
-
-	// 清理path，移除前导斜杠
-	path = strings.TrimPrefix(path, "/")
-
-	// 根据path和method路由到具体的处理函数
-	switch {
-	case path == "apps" && method == "GET":
-		return e.handleGetApps(c, service, userID)
-	case path == "apps" && method == "POST":
-		return e.handleCreateApp(c, service, userID)
-	// Handle /apps/:id/event-subscriptions and related routes before generic /apps/:id
-	case strings.Contains(path, "/event-subscriptions/") && method == "DELETE":
-		parts := strings.Split(path, "/")
-		if len(parts) >= 4 {
-			appID := parts[1]
-			eventCode := parts[3]
-			return e.handleUnsubscribeEvent(c, service, userID, appID, eventCode)
-		}
-	case strings.Contains(path, "/event-subscriptions") && method == "GET":
-		parts := strings.Split(path, "/")
-		if len(parts) >= 2 {
-			appID := parts[1]
-			return e.handleGetEventSubscriptions(c, service, userID, appID)
-		}
-	case strings.Contains(path, "/event-subscriptions") && method == "POST":
-		parts := strings.Split(path, "/")
-		if len(parts) >= 2 {
-			appID := parts[1]
-			return e.handleSubscribeEvent(c, service, userID, appID)
-		}
-	case strings.Contains(path, "/regenerate-secret") && method == "POST":
-		parts := strings.Split(path, "/")
-		if len(parts) >= 2 {
-			appID := parts[1]
-			return e.handleRegenerateSecret(c, service, userID, appID)
-		}
-	case strings.Contains(path, "/notify-config") && method == "PUT":
-		parts := strings.Split(path, "/")
-		if len(parts) >= 2 {
-			appID := parts[1]
-			return e.handleUpdateNotifyConfig(c, service, userID, appID)
-		}
-	case strings.Contains(path, "/test-notify") && method == "POST":
-		parts := strings.Split(path, "/")
-		if len(parts) >= 2 {
-			appID := parts[1]
-			return e.handleTestNotify(c, service, userID, appID)
-		}
-	case strings.HasPrefix(path, "apps/") && method == "GET":
-		appID := strings.TrimPrefix(path, "apps/")
-		return e.handleGetApp(c, service, userID, appID)
-	case strings.HasPrefix(path, "apps/") && method == "PUT":
-		appID := strings.TrimPrefix(path, "apps/")
-		return e.handleUpdateApp(c, service, userID, appID)
-	case strings.HasPrefix(path, "apps/") && method == "DELETE":
-		appID := strings.TrimPrefix(path, "apps/")
-		return e.handleDeleteApp(c, service, userID, appID)
-	case path == "api-docs" && method == "GET":
-		return e.handleGetApiDocs(c, service)
-	case path == "event-docs" && method == "GET":
-		return e.handleGetEventDocs(c, service)
-	case path == "aws-config" && method == "GET":
-		return e.handleGetAWSConfig(c, service)
-	case path == "send-test-event" && method == "POST":
-		return e.handleSendTestEvent(c, service, userID)
-	default:
-		return usererrors.New("API endpoint not found")
-	}
-
-	return usererrors.New("API endpoint not found")
-}
-
-// 具体的处理函数
-func (e *Endpoint) handleGetApps(c *pin.Context, service interfaces.DeveloperService, userID uint) error {
-	apps, err := service.GetApplications(userID)
-	if err != nil {
-		return usererrors.New("Failed to get applications: " + err.Error())
-	}
-	return c.Render(map[string]interface{}{"items": apps})
-}
-
-func (e *Endpoint) handleCreateApp(c *pin.Context, service interfaces.DeveloperService, userID uint) error {
-	var form struct {
-		Name        string `json:"name" binding:"required"`
-		Description string `json:"description"`
-	}
-	if err := c.BindJSON(&form); err != nil {
-		return usererrors.New("Invalid request body")
-	}
-
-	app, err := service.CreateApplication(userID, form.Name, form.Description)
-	if err != nil {
-		return usererrors.New("Failed to create application: " + err.Error())
-	}
-	return c.Render(app)
+	c.Set("developer_endpoint_type", e.Name)
+	return developerAPIHandler.HandleRequest(c, path, method, service, userID)
 }
 
-func (e *Endpoint) handleGetApp(c *pin.Context, service interfaces.DeveloperService, userID uint, appID string) error {
-	app, err := service.GetApplication(appID, userID)
-	if err != nil {
-		return usererrors.New("Failed to get application: " + err.Error())
-	}
-	return c.Render(app)
-}
+// 列表默认及最大每页数量
+const (
+	defaultListPageSize = 20
+	maxListPageSize     = 100
+)
 
-func (e *Endpoint) handleUpdateApp(c *pin.Context, service interfaces.DeveloperService, userID uint, appID string) error {
-	var form struct {
-		Name        string `json:"name" binding:"required"`
-		Description string `json:"description"`
-		Status      string `json:"status"`
-	}
-	if err := c.BindJSON(&form); err != nil {
-		return usererrors.New("Invalid request body")
+// bindApplicationQuery 从查询参数解析应用列表的过滤与分页条件
+// 支持 status、name 过滤以及 page、page_size 分页参数
+func bindApplicationQuery(c *pin.Context) (interfaces.ApplicationQuery, error) {
+	var filter struct {
+		Status string `json:"status"`
+		Name   string `json:"name"`
 	}
-
-	app, err := service.UpdateApplication(appID, userID, form.Name, form.Description, form.Status)
-	if err != nil {
-		return usererrors.New("Failed to update application: " + err.Error())
+	if _, err := crud.BindQuery(c, &filter); err != nil {
+		return interfaces.ApplicationQuery{}, err
 	}
-	return c.Render(app)
-}
 
-func (e *Endpoint) handleDeleteApp(c *pin.Context, service interfaces.DeveloperService, userID uint, appID string) error {
-	err := service.DeleteApplication(appID, userID)
-	if err != nil {
-		return usererrors.New("Failed to delete application: " + err.Error())
-	}
-	return c.Render(map[string]interface{}{"message": "Application deleted successfully"})
+	page, pageSize := bindPageQuery(c)
+	return interfaces.ApplicationQuery{
+		Page:     page,
+		PageSize: pageSize,
+		Status:   filter.Status,
+		Name:     filter.Name,
+	}, nil
 }
 
-func (e *Endpoint) handleRegenerateSecret(c *pin.Context, service interfaces.DeveloperService, userID uint, appID string) error {
-	app, err := service.RegenerateSecret(appID, userID)
-	if err != nil {
-		return usererrors.New("Failed to regenerate secret: " + err.Error())
+// bindDeliveryQuery 从查询参数解析投递记录的过滤与分页条件
+func bindDeliveryQuery(c *pin.Context) interfaces.DeliveryQuery {
+	page, pageSize := bindPageQuery(c)
+	return interfaces.DeliveryQuery{
+		Page:      page,
+		PageSize:  pageSize,
+		EventCode: c.Query("event_code"),
 	}
-	return c.Render(app)
 }
 
-func (e *Endpoint) handleUpdateNotifyConfig(c *pin.Context, service interfaces.DeveloperService, userID uint, appID string) error {
-	var form struct {
-		NotifyType string `json:"notify_type" binding:"required"`
-		NotifyURL  string `json:"notify_url" binding:"required"`
+// bindPageQuery 解析 page、page_size 分页参数，并限制每页最大数量
+func bindPageQuery(c *pin.Context) (int, int) {
+	page, pageSize := 1, defaultListPageSize
+	if v, err := strconv.Atoi(c.Query("page")); err == nil && v > 0 {
+		page = v
 	}
-	if err := c.BindJSON(&form); err != nil {
-		return usererrors.New("Invalid request body")
+	if v, err := strconv.Atoi(c.Query("page_size")); err == nil && v > 0 {
+		pageSize = v
 	}
-
-	app, err := service.UpdateNotifyConfig(appID, userID, form.NotifyType, form.NotifyURL)
-	if err != nil {
-		return usererrors.New("Failed to update notify config: " + err.Error())
+	if pageSize > maxListPageSize {
+		pageSize = maxListPageSize
 	}
-	return c.Render(app)
+	return page, pageSize
 }
 
-func (e *Endpoint) handleTestNotify(c *pin.Context, service interfaces.DeveloperService, userID uint, appID string) error {
-	var form struct {
-		NotifyType string `json:"notify_type" binding:"required"`
-		NotifyURL  string `json:"notify_url" binding:"required"`
-	}
-	if err := c.BindJSON(&form); err != nil {
-		return usererrors.New("Invalid request body")
-	}
-
-	err := service.TestNotify(appID, userID, form.NotifyType, form.NotifyURL)
-	if err != nil {
-		return usererrors.New("Failed to test notify: " + err.Error())
-	}
-	return c.Render(map[string]interface{}{"message": "Test notification sent successfully"})
-}
+// 调用量统计支持的时间窗口
+const defaultUsageWindow = "24h"
 
-func (e *Endpoint) handleGetEventSubscriptions(c *pin.Context, service interfaces.DeveloperService, userID uint, appID string) error {
-	subscriptions, err := service.GetEventSubscriptions(appID, userID)
-	if err != nil {
-		return usererrors.New("Failed to get event subscriptions: " + err.Error())
-	}
-	return c.Render(subscriptions)
+var usageWindows = map[string]time.Duration{
+	"24h": 24 * time.Hour,
+	"7d":  7 * 24 * time.Hour,
+	"30d": 30 * 24 * time.Hour,
 }
 
-func (e *Endpoint) handleSubscribeEvent(c *pin.Context, service interfaces.DeveloperService, userID uint, appID string) error {
-	var form struct {
-		EventCode string `json:"event_code" binding:"required"`
-	}
-	if err := c.BindJSON(&form); err != nil {
-		return usererrors.New("Invalid request body")
+// bindUsageWindow 解析 window 查询参数，为空时使用24h
+func bindUsageWindow(c *pin.Context) (string, time.Duration, *usererrors.Error) {
+	window := c.Query("window")
+	if window == "" {
+		window = defaultUsageWindow
 	}
-
-	subscription, err := service.SubscribeEvent(appID, userID, form.EventCode)
-	if err != nil {
-		return usererrors.New("Failed to subscribe event: " + err.Error())
+	duration, ok := usageWindows[window]
+	if !ok {
+		return "", 0, usererrors.New("invalid_usage_window", "window must be one of 24h, 7d, 30d")
 	}
-	return c.Render(subscription)
+	return window, duration, nil
 }
 
-func (e *Endpoint) handleUnsubscribeEvent(c *pin.Context, service interfaces.DeveloperService, userID uint, appID string, eventCode string) error {
-	err := service.UnsubscribeEvent(appID, userID, eventCode)
-	if err != nil {
-		return usererrors.New("Failed to unsubscribe event: " + err.Error())
+// newUsageResult 补全调用量统计的时间窗口与失败率，没有数据时返回全零的统计
+func newUsageResult(stats *interfaces.UsageStats, window string) *interfaces.UsageStats {
+	result := interfaces.UsageStats{}
+	if stats != nil {
+		result = *stats
 	}
-	return c.Render(map[string]interface{}{"message": "Event unsubscribed successfully"})
-}
-
-func (e *Endpoint) handleGetApiDocs(c *pin.Context, service interfaces.DeveloperService) error {
-	docs, err := service.GetApiDocs()
-	if err != nil {
-		return usererrors.New("Failed to get API docs: " + err.Error())
+	result.Window = window
+	result.ErrorRate = 0
+	if result.RequestCount > 0 {
+		result.ErrorRate = float64(result.ErrorCount) / float64(result.RequestCount)
 	}
-	return c.Render(docs)
+	return &result
 }
 
-func (e *Endpoint) handleGetEventDocs(c *pin.Context, service interfaces.DeveloperService) error {
-	docs, err := service.GetEventDocs()
-	if err != nil {
-		return usererrors.New("Failed to get event docs: " + err.Error())
+// newApplicationListResult 构造带分页信息的应用列表响应
+func newApplicationListResult(apps []interfaces.ApplicationInfo, query interfaces.ApplicationQuery, total int64) *crud.QueryResult {
+	if apps == nil {
+		apps = []interfaces.ApplicationInfo{}
 	}
-	return c.Render(docs)
-}
-
-func (e *Endpoint) handleGetAWSConfig(c *pin.Context, service interfaces.DeveloperService) error {
-	config, err := service.GetAWSConfig()
-	if err != nil {
-		return usererrors.New("Failed to get AWS config: " + err.Error())
+	return &crud.QueryResult{
+		Items: apps,
+		Pagination: &crud.Pagination{
+			Page:  query.Page,
+			Size:  query.PageSize,
+			Total: total,
+		},
 	}
-	return c.Render(config)
 }
 
-func (e *Endpoint) handleSendTestEvent(c *pin.Context, service interfaces.DeveloperService, userID uint) error {
-	var form struct {
-		AppID      string      `json:"app_id" binding:"required"`
-		EventCode  string      `json:"event_code" binding:"required"`
-		NotifyType string      `json:"notify_type" binding:"required"`
-		NotifyURL  string      `json:"notify_url" binding:"required"`
-		TestData   interface{} `json:"test_data"`
+// newDeliveryListResult 构造带分页信息的投递记录响应
+func newDeliveryListResult(records []interfaces.DeliveryRecord, query interfaces.DeliveryQuery, total int64) *crud.QueryResult {
+	if records == nil {
+		records = []interfaces.DeliveryRecord{}
 	}
-	if err := c.BindJSON(&form); err != nil {
-		return usererrors.New("Invalid request body")
-	}
-
-	err := service.SendTestEvent(form.AppID, userID, form.EventCode, form.NotifyType, form.NotifyURL, form.TestData)
-	if err != nil {
-		return usererrors.New("Failed to send test event: " + err.Error())
+	return &crud.QueryResult{
+		Items: records,
+		Pagination: &crud.Pagination{
+			Page:  query.Page,
+			Size:  query.PageSize,
+			Total: total,
+		},
 	}
-	return c.Render(map[string]interface{}{"message": "Test event sent successfully"})
 }
@@ -1,6 +1,8 @@
 package openapi
 
 import (
+	"errors"
+
 	"github.com/flaboy/aira-web/pkg/openapi/interfaces"
 	"github.com/flaboy/aira-web/pkg/routes"
 
@@ -29,6 +31,7 @@ func (h *DeveloperAPIHandler) registerRoutes() {
 	h.router.POST("/apps", h.handleCreateApp)
 	h.router.GET("/apps/:id", h.handleGetApp)
 	h.router.PUT("/apps/:id", h.handleUpdateApp)
+	h.router.PATCH("/apps/:id", h.handlePatchApp)
 	h.router.DELETE("/apps/:id", h.handleDeleteApp)
 
 	// 应用配置路由
@@ -41,6 +44,13 @@ func (h *DeveloperAPIHandler) registerRoutes() {
 	h.router.POST("/apps/:id/event-subscriptions", h.handleSubscribeEvent)
 	h.router.DELETE("/apps/:id/event-subscriptions/:event_code", h.handleUnsubscribeEvent)
 
+	// 投递记录路由
+	h.router.GET("/apps/:id/deliveries", h.handleGetDeliveryHistory)
+	h.router.GET("/apps/:id/delivery-status", h.handleGetDeliveryStatus)
+
+	// 调用量统计路由
+	h.router.GET("/apps/:id/usage", h.handleGetUsage)
+
 	// 文档和配置路由
 	h.router.GET("/api-docs", h.handleGetApiDocs)
 	h.router.GET("/event-docs", h.handleGetEventDocs)
@@ -63,11 +73,16 @@ func (h *DeveloperAPIHandler) handleGetApps(c *pin.Context) error {
 	service := c.MustGet("developer_service").(interfaces.DeveloperService)
 	userID := c.MustGet("user_id").(uint)
 
-	apps, err := service.GetApplications(userID)
+	query, err := bindApplicationQuery(c)
+	if err != nil {
+		return usererrors.New("Invalid query: " + err.Error())
+	}
+
+	apps, total, err := service.GetApplications(userID, query)
 	if err != nil {
 		return usererrors.New("Failed to get applications: " + err.Error())
 	}
-	return c.Render(map[string]interface{}{"items": apps})
+	return c.Render(newApplicationListResult(apps, query, total))
 }
 
 func (h *DeveloperAPIHandler) handleCreateApp(c *pin.Context) error {
@@ -81,9 +96,16 @@ func (h *DeveloperAPIHandler) handleCreateApp(c *pin.Context) error {
 	if err := c.BindJSON(&form); err != nil {
 		return usererrors.New("Invalid request body")
 	}
+	name, description, verr := ValidateApplicationInput(form.Name, form.Description)
+	if verr != nil {
+		return verr
+	}
 
-	app, err := service.CreateApplication(userID, form.Name, form.Description)
+	app, err := service.CreateApplication(userID, name, description)
 	if err != nil {
+		if errors.Is(err, interfaces.ErrDuplicateApplicationName) {
+			return newDuplicateAppNameError()
+		}
 		return usererrors.New("Failed to create application: " + err.Error())
 	}
 	return c.Render(app)
@@ -114,9 +136,39 @@ func (h *DeveloperAPIHandler) handleUpdateApp(c *pin.Context) error {
 	if err := c.BindJSON(&form); err != nil {
 		return usererrors.New("Invalid request body")
 	}
+	name, description, verr := ValidateApplicationInput(form.Name, form.Description)
+	if verr != nil {
+		return verr
+	}
 
-	app, err := service.UpdateApplication(appID, userID, form.Name, form.Description, form.Status)
+	app, err := service.UpdateApplication(appID, userID, name, description, form.Status)
 	if err != nil {
+		if errors.Is(err, interfaces.ErrDuplicateApplicationName) {
+			return newDuplicateAppNameError()
+		}
+		return usererrors.New("Failed to update application: " + err.Error())
+	}
+	return c.Render(app)
+}
+
+func (h *DeveloperAPIHandler) handlePatchApp(c *pin.Context) error {
+	service := c.MustGet("developer_service").(interfaces.DeveloperService)
+	userID := c.MustGet("user_id").(uint)
+	appID := routes.GetParam(c, "id")
+
+	var patch interfaces.ApplicationPatch
+	if err := c.BindJSON(&patch); err != nil {
+		return usererrors.New("Invalid request body")
+	}
+	if verr := validateApplicationPatch(&patch); verr != nil {
+		return verr
+	}
+
+	app, err := service.PatchApplication(appID, userID, patch)
+	if err != nil {
+		if errors.Is(err, interfaces.ErrDuplicateApplicationName) {
+			return newDuplicateAppNameError()
+		}
 		return usererrors.New("Failed to update application: " + err.Error())
 	}
 	return c.Render(app)
@@ -158,6 +210,9 @@ func (h *DeveloperAPIHandler) handleUpdateNotifyConfig(c *pin.Context) error {
 	if err := c.BindJSON(&form); err != nil {
 		return usererrors.New("Invalid request body")
 	}
+	if err := ValidateNotifyConfig(form.NotifyType, form.NotifyURL); err != nil {
+		return err
+	}
 
 	app, err := service.UpdateNotifyConfig(appID, userID, form.NotifyType, form.NotifyURL)
 	if err != nil {
@@ -178,6 +233,9 @@ func (h *DeveloperAPIHandler) handleTestNotify(c *pin.Context) error {
 	if err := c.BindJSON(&form); err != nil {
 		return usererrors.New("Invalid request body")
 	}
+	if err := ValidateNotifyConfig(form.NotifyType, form.NotifyURL); err != nil {
+		return err
+	}
 
 	err := service.TestNotify(appID, userID, form.NotifyType, form.NotifyURL)
 	if err != nil {
@@ -230,6 +288,48 @@ func (h *DeveloperAPIHandler) handleUnsubscribeEvent(c *pin.Context) error {
 	return c.Render(map[string]interface{}{"message": "Event unsubscribed successfully"})
 }
 
+func (h *DeveloperAPIHandler) handleGetDeliveryHistory(c *pin.Context) error {
+	service := c.MustGet("developer_service").(interfaces.DeveloperService)
+	userID := c.MustGet("user_id").(uint)
+	appID := routes.GetParam(c, "id")
+	query := bindDeliveryQuery(c)
+
+	records, total, err := service.GetDeliveryHistory(appID, userID, query)
+	if err != nil {
+		return usererrors.New("Failed to get delivery history: " + err.Error())
+	}
+	return c.Render(newDeliveryListResult(records, query, total))
+}
+
+func (h *DeveloperAPIHandler) handleGetDeliveryStatus(c *pin.Context) error {
+	service := c.MustGet("developer_service").(interfaces.DeveloperService)
+	userID := c.MustGet("user_id").(uint)
+	appID := routes.GetParam(c, "id")
+
+	// 先校验应用归属
+	if _, err := service.GetApplication(appID, userID); err != nil {
+		return usererrors.New("Failed to get application: " + err.Error())
+	}
+	return c.Render(GetCircuitBreakerStatus(appID))
+}
+
+func (h *DeveloperAPIHandler) handleGetUsage(c *pin.Context) error {
+	service := c.MustGet("developer_service").(interfaces.DeveloperService)
+	userID := c.MustGet("user_id").(uint)
+	appID := routes.GetParam(c, "id")
+
+	window, duration, verr := bindUsageWindow(c)
+	if verr != nil {
+		return verr
+	}
+
+	stats, err := service.GetUsage(appID, userID, duration)
+	if err != nil {
+		return usererrors.New("Failed to get usage: " + err.Error())
+	}
+	return c.Render(newUsageResult(stats, window))
+}
+
 func (h *DeveloperAPIHandler) handleGetApiDocs(c *pin.Context) error {
 	service := c.MustGet("developer_service").(interfaces.DeveloperService)
 
@@ -243,6 +343,13 @@ func (h *DeveloperAPIHandler) handleGetApiDocs(c *pin.Context) error {
 func (h *DeveloperAPIHandler) handleGetEventDocs(c *pin.Context) error {
 	service := c.MustGet("developer_service").(interfaces.DeveloperService)
 
+	// 端点注册了事件时返回根据事件对象生成的结构化文档，否则使用服务提供的文档
+	if endpointType, ok := c.Get("developer_endpoint_type"); ok {
+		if endpoint := GetEndpoint(endpointType.(interfaces.EndpointType)); len(endpoint.GetAllEvents()) > 0 {
+			return c.Render(endpoint.GenerateEventDocumentation())
+		}
+	}
+
 	docs, err := service.GetEventDocs()
 	if err != nil {
 		return usererrors.New("Failed to get event docs: " + err.Error())
@@ -274,10 +381,13 @@ func (h *DeveloperAPIHandler) handleSendTestEvent(c *pin.Context) error {
 	if err := c.BindJSON(&form); err != nil {
 		return usererrors.New("Invalid request body")
 	}
+	if err := ValidateNotifyConfig(form.NotifyType, form.NotifyURL); err != nil {
+		return err
+	}
 
-	err := service.SendTestEvent(form.AppID, userID, form.EventCode, form.NotifyType, form.NotifyURL, form.TestData)
+	result, err := service.SendTestEvent(form.AppID, userID, form.EventCode, form.NotifyType, form.NotifyURL, form.TestData)
 	if err != nil {
 		return usererrors.New("Failed to send test event: " + err.Error())
 	}
-	return c.Render(map[string]interface{}{"message": "Test event sent successfully"})
+	return c.Render(result)
 }
@@ -0,0 +1,82 @@
+package openapi
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/flaboy/aira-web/pkg/openapi/interfaces"
+
+	"github.com/flaboy/pin"
+	"github.com/gin-gonic/gin"
+)
+
+// recordingDeveloperService 记录被调用的方法及参数，未覆盖的方法调用时会panic
+type recordingDeveloperService struct {
+	interfaces.DeveloperService
+	calls []string
+}
+
+func (s *recordingDeveloperService) GetApplication(appID string, userID uint) (interfaces.ApplicationInfo, error) {
+	s.calls = append(s.calls, "GetApplication "+appID)
+	return &testApp{id: appID}, nil
+}
+
+func (s *recordingDeveloperService) DeleteApplication(appID string, userID uint) error {
+	s.calls = append(s.calls, "DeleteApplication "+appID)
+	return nil
+}
+
+func (s *recordingDeveloperService) GetEventSubscriptions(appID string, userID uint) ([]interfaces.EventSubscriptionInfo, error) {
+	s.calls = append(s.calls, "GetEventSubscriptions "+appID)
+	return nil, nil
+}
+
+func (s *recordingDeveloperService) UnsubscribeEvent(appID string, userID uint, eventCode string) error {
+	s.calls = append(s.calls, "UnsubscribeEvent "+appID+" "+eventCode)
+	return nil
+}
+
+func TestHandleDeveloperAPIAmbiguousPaths(t *testing.T) {
+	tests := []struct {
+		method string
+		path   string
+		want   string
+	}{
+		// 应用ID与子资源名称相同
+		{http.MethodGet, "/apps/event-subscriptions", "GetApplication event-subscriptions"},
+		{http.MethodDelete, "/apps/event-subscriptions", "DeleteApplication event-subscriptions"},
+		{http.MethodGet, "/apps/event-subscriptions/event-subscriptions", "GetEventSubscriptions event-subscriptions"},
+		{http.MethodGet, "/apps/deliveries", "GetApplication deliveries"},
+		// 删除应用与取消订阅按路径段数区分
+		{http.MethodDelete, "/apps/app-1", "DeleteApplication app-1"},
+		{http.MethodDelete, "/apps/app-1/event-subscriptions/order.created", "UnsubscribeEvent app-1 order.created"},
+		{http.MethodDelete, "/apps/apps/event-subscriptions/apps", "UnsubscribeEvent apps apps"},
+	}
+
+	endpoint := GetEndpoint(interfaces.EndpointType("test-" + t.Name()))
+	gin.SetMode(gin.TestMode)
+	for _, tt := range tests {
+		t.Run(tt.method+" "+tt.path, func(t *testing.T) {
+			for name, handle := range map[string]func(c *pin.Context, service interfaces.DeveloperService) error{
+				"legacy": func(c *pin.Context, service interfaces.DeveloperService) error {
+					return endpoint.HandleDeveloperAPI(c, tt.path, tt.method, service, 1)
+				},
+				"ginified": func(c *pin.Context, service interfaces.DeveloperService) error {
+					return HandleDeveloperRequest(c, endpoint.Name, service, tt.path, 1)
+				},
+			} {
+				service := &recordingDeveloperService{}
+				ginCtx, _ := gin.CreateTestContext(httptest.NewRecorder())
+				ginCtx.Request = httptest.NewRequest(tt.method, tt.path, nil)
+
+				if err := handle(&pin.Context{Context: ginCtx}, service); err != nil {
+					t.Fatalf("%s: unexpected error %v", name, err)
+				}
+				if len(service.calls) != 1 || service.calls[0] != tt.want {
+					t.Fatalf("%s: calls = %v, want [%s]", name, service.calls, tt.want)
+				}
+			}
+		})
+	}
+}
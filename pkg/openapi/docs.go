@@ -1,14 +1,21 @@
 package openapi
 
 import (
+	"encoding/json"
 	"fmt"
 	"reflect"
+	"slices"
+	"strconv"
 	"strings"
 	"time"
+
+	"github.com/flaboy/aira-web/pkg/config"
 )
 
 type ApiDocumentation struct {
-	Apis []ApiEndpoint `json:"apis"`
+	Info    ApiInfo       `json:"info"`
+	Servers []ApiServer   `json:"servers,omitempty"`
+	Apis    []ApiEndpoint `json:"apis"`
 }
 
 // ApiInfo represents basic API information
@@ -32,14 +39,17 @@ type apiError struct {
 
 // ApiEndpoint represents API endpoint information
 type ApiEndpoint struct {
-	Method      string         `json:"method"`
-	Path        string         `json:"path"`
-	Description string         `json:"description"`
-	Request     *ApiSchema     `json:"request,omitempty"`
-	Response    *ApiSchema     `json:"response,omitempty"`
-	Errors      []apiError     `json:"errors,omitempty"`
-	Parameters  []ApiParameter `json:"parameters,omitempty"`
-	Tags        []string       `json:"tags,omitempty"`
+	Method      string                `json:"method"`
+	Path        string                `json:"path"`
+	Summary     string                `json:"summary,omitempty"`
+	Description string                `json:"description"`
+	Deprecated  bool                  `json:"deprecated,omitempty"`
+	Request     *ApiSchema            `json:"request,omitempty"`
+	Response    *ApiSchema            `json:"response,omitempty"`
+	Responses   map[string]*ApiSchema `json:"responses,omitempty"` // Keyed by HTTP status code
+	Errors      []apiError            `json:"errors,omitempty"`
+	Parameters  []ApiParameter        `json:"parameters,omitempty"`
+	Tags        []string              `json:"tags,omitempty"`
 }
 
 // ApiSchema represents data structure information
@@ -60,6 +70,13 @@ type ApiProperty struct {
 	Properties     map[string]ApiProperty `json:"properties,omitempty"`     // Properties of nested objects
 	RequiredFields []string               `json:"requiredFields,omitempty"` // Required fields of nested objects
 	Items          *ApiProperty           `json:"items,omitempty"`          // Type information of array items
+	Enum           []interface{}          `json:"enum,omitempty"`           // Allowed values from binding oneof
+	Minimum        *float64               `json:"minimum,omitempty"`        // Minimum value of numeric types
+	Maximum        *float64               `json:"maximum,omitempty"`        // Maximum value of numeric types
+	MinLength      *int                   `json:"minLength,omitempty"`      // Minimum length of strings and arrays
+	MaxLength      *int                   `json:"maxLength,omitempty"`      // Maximum length of strings and arrays
+
+	AdditionalProperties *ApiProperty `json:"additionalProperties,omitempty"` // Value type of map types
 }
 
 // ApiParameter represents parameter information
@@ -77,11 +94,14 @@ func (e *Endpoint) GenerateApiDocumentation(info ApiInfo, servers []ApiServer) *
 		info.GeneratedAt = time.Now().Format(time.RFC3339)
 	}
 
+	apilist := e.GetApiList()
 	doc := &ApiDocumentation{
-		Apis: make([]ApiEndpoint, 0, len(e.apilist)),
+		Info:    info,
+		Servers: servers,
+		Apis:    make([]ApiEndpoint, 0, len(apilist)),
 	}
 
-	for _, router := range e.apilist {
+	for _, router := range apilist {
 		endpoint := e.generateEndpointDoc(router)
 		doc.Apis = append(doc.Apis, endpoint)
 	}
@@ -94,12 +114,22 @@ func (e *Endpoint) generateEndpointDoc(router ApiRouter) ApiEndpoint {
 	endpoint := ApiEndpoint{
 		Method:      router.Method,
 		Path:        router.Path,
+		Summary:     router.Summary,
 		Description: router.Name,
+		Deprecated:  router.Deprecated,
 		Parameters:  e.extractPathParameters(router.Path),
-		Tags:        extractTags(router.Path),
+		Tags:        router.Tags,
 		Errors:      make([]apiError, 0),
 	}
 
+	// Explicit tags take precedence over the path-derived default
+	if len(endpoint.Tags) == 0 {
+		endpoint.Tags = extractTags(router.Path)
+	}
+
+	endpoint.Parameters = append(endpoint.Parameters, e.extractStructParameters(router.QueryParams, "query")...)
+	endpoint.Parameters = append(endpoint.Parameters, e.extractStructParameters(router.HeaderParams, "header")...)
+
 	for _, err := range router.Errors {
 		endpoint.Errors = append(endpoint.Errors, apiError{
 			Code:    err.Code(),
@@ -118,79 +148,159 @@ func (e *Endpoint) generateEndpointDoc(router ApiRouter) ApiEndpoint {
 
 	// Generate response structure documentation
 	if router.Response != nil {
-		// First generate the actual response data schema
-		actualResponseSchema := e.generateSchemaDoc(router.Response)
+		endpoint.Response = e.generateSuccessResponseDoc(router.Response, router.ResponseExample, router.RawResponse)
+	}
+
+	// Responses keyed by status code; the single Response is documented as 200
+	if endpoint.Response != nil || len(router.Responses) > 0 {
+		endpoint.Responses = make(map[string]*ApiSchema, len(router.Responses)+1)
+		if endpoint.Response != nil {
+			endpoint.Responses["200"] = endpoint.Response
+		}
+		for status, spec := range router.Responses {
+			endpoint.Responses[strconv.Itoa(status)] = e.generateStatusResponseDoc(status, spec, router.RawResponse)
+		}
+	}
+
+	return endpoint
+}
+
+// generateStatusResponseDoc generates documentation for a response registered with WithResponse
+// Success responses (2xx) are wrapped in the response envelope unless raw, other responses are documented as-is
+func (e *Endpoint) generateStatusResponseDoc(status int, spec ApiResponseSpec, raw bool) *ApiSchema {
+	if status >= 200 && status < 300 {
+		if spec.Schema == nil {
+			return &ApiSchema{Type: "object", Example: spec.Example}
+		}
+		return e.generateSuccessResponseDoc(spec.Schema, spec.Example, raw)
+	}
+
+	if spec.Schema == nil {
+		return &ApiSchema{Type: "object", Example: spec.Example}
+	}
+	schema := e.generateSchemaDoc(spec.Schema)
+	if spec.Example != nil {
+		schema.Example = spec.Example
+	}
+	return schema
+}
+
+// ResponseEnvelope describes the wrapper documented around success responses
+type ResponseEnvelope struct {
+	DataField  string                             // Property holding the response data
+	Properties map[string]ApiProperty             // Envelope properties besides the data field
+	Example    func(data interface{}) interface{} // Builds the wrapped example; nil wraps data under DataField
+}
 
-		// Wrap in pin response format
-		endpoint.Response = &ApiSchema{
-			Type: "object",
+// DefaultResponseEnvelope is the pin response format: data, meta, trace_id and error
+var DefaultResponseEnvelope = ResponseEnvelope{
+	DataField: "data",
+	Properties: map[string]ApiProperty{
+		"meta": {
+			Type:        "object",
+			Description: "Metadata (optional)",
+		},
+		"trace_id": {
+			Type:        "string",
+			Description: "Request trace ID",
+		},
+		"error": {
+			Type:        "object",
+			Description: "Error information (only present when error occurs)",
 			Properties: map[string]ApiProperty{
-				"data": {
-					Type:           actualResponseSchema.Type,
-					Description:    "Response data",
-					Properties:     actualResponseSchema.Properties,
-					RequiredFields: actualResponseSchema.Required,
-					Items:          nil, // Will be set below if needed
-				},
-				"meta": {
-					Type:        "object",
-					Description: "Metadata (optional)",
+				"message": {
+					Type:        "string",
+					Description: "Human readable error message",
 				},
-				"trace_id": {
+				"type": {
 					Type:        "string",
-					Description: "Request trace ID",
+					Description: "Error type (e.g., 'user')",
 				},
-				"error": {
-					Type:        "object",
-					Description: "Error information (only present when error occurs)",
-					Properties: map[string]ApiProperty{
-						"message": {
-							Type:        "string",
-							Description: "Human readable error message",
-						},
-						"type": {
-							Type:        "string",
-							Description: "Error type (e.g., 'user')",
-						},
-						"key": {
-							Type:        "string",
-							Description: "Error code for programmatic handling",
-						},
-					},
-					RequiredFields: []string{"message", "type", "key"},
+				"key": {
+					Type:        "string",
+					Description: "Error code for programmatic handling",
 				},
 			},
-			Required: []string{}, // No required fields as response structure varies
-		}
-
-		// Handle array responses
-		if actualResponseSchema.Type == "array" {
-			endpoint.Response.Properties["data"] = ApiProperty{
-				Type:        "array",
-				Description: "Response data",
-				Items: &ApiProperty{
-					Type:           "object",
-					Properties:     actualResponseSchema.Properties,
-					RequiredFields: actualResponseSchema.Required,
-				},
-			}
+			RequiredFields: []string{"message", "type", "key"},
+		},
+	},
+	Example: func(data interface{}) interface{} {
+		return map[string]interface{}{
+			"data":     data,
+			"trace_id": "example-trace-id-123",
 		}
+	},
+}
 
-		// Set wrapped example (success case)
-		if router.ResponseExample != nil {
-			endpoint.Response.Example = map[string]interface{}{
-				"data":     router.ResponseExample,
-				"trace_id": "example-trace-id-123",
-			}
-		} else if actualResponseSchema.Example != nil {
-			endpoint.Response.Example = map[string]interface{}{
-				"data":     actualResponseSchema.Example,
-				"trace_id": "example-trace-id-123",
-			}
+var responseEnvelope = DefaultResponseEnvelope
+
+// SetResponseEnvelope customizes the envelope documented around success responses
+// It should be called during initialization, before documentation is generated
+func SetResponseEnvelope(envelope ResponseEnvelope) {
+	if envelope.DataField == "" {
+		envelope.DataField = DefaultResponseEnvelope.DataField
+	}
+	responseEnvelope = envelope
+}
+
+// generateSuccessResponseDoc generates a success response schema wrapped in the response envelope
+// Raw responses (WithRawResponse) are documented without the envelope
+func (e *Endpoint) generateSuccessResponseDoc(response interface{}, example interface{}, raw bool) *ApiSchema {
+	// First generate the actual response data schema
+	actualResponseSchema := e.generateSchemaDoc(response)
+
+	if example == nil {
+		example = actualResponseSchema.Example
+	}
+
+	if raw {
+		actualResponseSchema.Example = example
+		return actualResponseSchema
+	}
+
+	envelope := responseEnvelope
+
+	dataProperty := ApiProperty{
+		Type:           actualResponseSchema.Type,
+		Description:    "Response data",
+		Properties:     actualResponseSchema.Properties,
+		RequiredFields: actualResponseSchema.Required,
+	}
+
+	// Handle array responses
+	if actualResponseSchema.Type == "array" {
+		dataProperty = ApiProperty{
+			Type:        "array",
+			Description: "Response data",
+			Items: &ApiProperty{
+				Type:           "object",
+				Properties:     actualResponseSchema.Properties,
+				RequiredFields: actualResponseSchema.Required,
+			},
 		}
 	}
 
-	return endpoint
+	// Wrap in the response envelope
+	wrapped := &ApiSchema{
+		Type:       "object",
+		Properties: make(map[string]ApiProperty, len(envelope.Properties)+1),
+		Required:   []string{}, // No required fields as response structure varies
+	}
+	for name, prop := range envelope.Properties {
+		wrapped.Properties[name] = prop
+	}
+	wrapped.Properties[envelope.DataField] = dataProperty
+
+	// Set wrapped example (success case)
+	if example != nil {
+		if envelope.Example != nil {
+			wrapped.Example = envelope.Example(example)
+		} else {
+			wrapped.Example = map[string]interface{}{envelope.DataField: example}
+		}
+	}
+
+	return wrapped
 }
 
 // generateSchemaDoc generates data structure documentation
@@ -219,20 +329,29 @@ func (e *Endpoint) generateSchemaDoc(obj interface{}) *ApiSchema {
 
 	// Handle struct
 	if objType.Kind() == reflect.Struct {
+		var embedded []*ApiSchema
 		for i := 0; i < objType.NumField(); i++ {
 			field := objType.Field(i)
 
-			// Skip private fields
-			if !field.IsExported() {
-				continue
-			}
-
 			// Get JSON tag
 			jsonTag := field.Tag.Get("json")
 			if jsonTag == "-" {
 				continue
 			}
 
+			// Embedded structs are flattened into the parent, matching encoding/json
+			if embeddedType, ok := flattenedStructType(field); ok {
+				if nestedSchema := e.generateSchemaDoc(reflect.New(embeddedType).Interface()); nestedSchema != nil {
+					embedded = append(embedded, nestedSchema)
+				}
+				continue
+			}
+
+			// Skip private fields
+			if !field.IsExported() {
+				continue
+			}
+
 			fieldName := field.Name
 			if jsonTag != "" {
 				parts := strings.Split(jsonTag, ",")
@@ -244,6 +363,12 @@ func (e *Endpoint) generateSchemaDoc(obj interface{}) *ApiSchema {
 			prop := ApiProperty{
 				Type:        e.getTypeString(field.Type),
 				Description: e.buildDescription(field),
+				Format:      e.getFormatString(field.Type),
+			}
+
+			// Explicit format tag overrides the detected format
+			if format := field.Tag.Get("format"); format != "" {
+				prop.Format = format
 			}
 
 			// Check if required
@@ -253,6 +378,9 @@ func (e *Endpoint) generateSchemaDoc(obj interface{}) *ApiSchema {
 				schema.Required = append(schema.Required, fieldName)
 			}
 
+			// Apply machine-readable binding constraints
+			e.applyBindingConstraints(&prop, field)
+
 			// Handle nested objects
 			if field.Type.Kind() == reflect.Struct || (field.Type.Kind() == reflect.Ptr && field.Type.Elem().Kind() == reflect.Struct) {
 				nestedType := field.Type
@@ -270,8 +398,8 @@ func (e *Endpoint) generateSchemaDoc(obj interface{}) *ApiSchema {
 				}
 			}
 
-			// Handle array types
-			if field.Type.Kind() == reflect.Slice || field.Type.Kind() == reflect.Array {
+			// Handle array types ([]byte is documented as a base64 string)
+			if (field.Type.Kind() == reflect.Slice || field.Type.Kind() == reflect.Array) && !isByteSlice(field.Type) {
 				elemType := field.Type.Elem()
 				if elemType.Kind() == reflect.Struct || (elemType.Kind() == reflect.Ptr && elemType.Elem().Kind() == reflect.Struct) {
 					if elemType.Kind() == reflect.Ptr {
@@ -288,7 +416,25 @@ func (e *Endpoint) generateSchemaDoc(obj interface{}) *ApiSchema {
 					}
 				} else {
 					prop.Items = &ApiProperty{
-						Type: e.getTypeString(elemType),
+						Type:   e.getTypeString(elemType),
+						Format: e.getFormatString(elemType),
+					}
+				}
+			}
+
+			// Handle map types
+			if field.Type.Kind() == reflect.Map || (field.Type.Kind() == reflect.Ptr && field.Type.Elem().Kind() == reflect.Map) {
+				mapType := field.Type
+				if mapType.Kind() == reflect.Ptr {
+					mapType = mapType.Elem()
+				}
+				prop.AdditionalProperties = e.generateTypeProperty(mapType.Elem())
+				if keyType := e.getTypeString(mapType.Key()); keyType != "string" {
+					note := fmt.Sprintf("Map keys are %s", keyType)
+					if prop.Description != "" {
+						prop.Description += " " + note
+					} else {
+						prop.Description = note
 					}
 				}
 			}
@@ -298,6 +444,22 @@ func (e *Endpoint) generateSchemaDoc(obj interface{}) *ApiSchema {
 			schema.Properties[fieldName] = prop
 		}
 
+		// Promoted fields never override fields declared on the parent
+		for _, nestedSchema := range embedded {
+			promoted := make(map[string]bool)
+			for name, prop := range nestedSchema.Properties {
+				if _, exists := schema.Properties[name]; !exists {
+					schema.Properties[name] = prop
+					promoted[name] = true
+				}
+			}
+			for _, name := range nestedSchema.Required {
+				if promoted[name] {
+					schema.Required = append(schema.Required, name)
+				}
+			}
+		}
+
 		// Generate overall example
 		schema.Example = e.generateStructExample(objType)
 	}
@@ -305,6 +467,37 @@ func (e *Endpoint) generateSchemaDoc(obj interface{}) *ApiSchema {
 	return schema
 }
 
+// generateTypeProperty generates property information describing a type (used for map values)
+func (e *Endpoint) generateTypeProperty(t reflect.Type) *ApiProperty {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	prop := &ApiProperty{
+		Type:   e.getTypeString(t),
+		Format: e.getFormatString(t),
+	}
+
+	switch t.Kind() {
+	case reflect.Struct:
+		if t.PkgPath() == "time" && t.Name() == "Time" {
+			break
+		}
+		if schema := e.generateSchemaDoc(reflect.New(t).Interface()); schema != nil {
+			prop.Properties = schema.Properties
+			prop.RequiredFields = schema.Required
+		}
+	case reflect.Slice, reflect.Array:
+		if !isByteSlice(t) {
+			prop.Items = e.generateTypeProperty(t.Elem())
+		}
+	case reflect.Map:
+		prop.AdditionalProperties = e.generateTypeProperty(t.Elem())
+	}
+
+	return prop
+}
+
 // buildDescription builds description information, merging description and binding information
 func (e *Endpoint) buildDescription(field reflect.StructField) string {
 	desc := field.Tag.Get("description")
@@ -339,6 +532,97 @@ func (e *Endpoint) buildDescription(field reflect.StructField) string {
 	return strings.Join(parts, " ")
 }
 
+// applyBindingConstraints fills structured constraints from the binding tag
+func (e *Endpoint) applyBindingConstraints(prop *ApiProperty, field reflect.StructField) {
+	bindingTag := field.Tag.Get("binding")
+	if bindingTag == "" {
+		return
+	}
+
+	fieldType := field.Type
+	if fieldType.Kind() == reflect.Ptr {
+		fieldType = fieldType.Elem()
+	}
+
+	isNumeric := false
+	isSized := false
+	switch fieldType.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		isNumeric = true
+	case reflect.String, reflect.Slice, reflect.Array, reflect.Map:
+		isSized = true
+	}
+
+	for _, part := range strings.Split(bindingTag, ",") {
+		part = strings.TrimSpace(part)
+		name, value, _ := strings.Cut(part, "=")
+		switch name {
+		case "oneof":
+			values := strings.Fields(value)
+			if len(values) > 0 {
+				prop.Enum = e.parseEnumValues(field.Type, values)
+			}
+		case "min", "max":
+			if isNumeric {
+				if v, err := strconv.ParseFloat(value, 64); err == nil {
+					if name == "min" {
+						prop.Minimum = &v
+					} else {
+						prop.Maximum = &v
+					}
+				}
+			} else if isSized {
+				if v, err := strconv.Atoi(value); err == nil {
+					if name == "min" {
+						prop.MinLength = &v
+					} else {
+						prop.MaxLength = &v
+					}
+				}
+			}
+		case "len":
+			if isSized {
+				if v, err := strconv.Atoi(value); err == nil {
+					prop.MinLength = &v
+					prop.MaxLength = &v
+				}
+			}
+		}
+	}
+}
+
+// parseEnumValues converts oneof values to the field's type (numbers stay numbers)
+func (e *Endpoint) parseEnumValues(t reflect.Type, values []string) []interface{} {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	enum := make([]interface{}, 0, len(values))
+	for _, value := range values {
+		switch t.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			if v, err := strconv.ParseInt(value, 10, 64); err == nil {
+				enum = append(enum, v)
+				continue
+			}
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			if v, err := strconv.ParseUint(value, 10, 64); err == nil {
+				enum = append(enum, v)
+				continue
+			}
+		case reflect.Float32, reflect.Float64:
+			if v, err := strconv.ParseFloat(value, 64); err == nil {
+				enum = append(enum, v)
+				continue
+			}
+		}
+		enum = append(enum, value)
+	}
+	return enum
+}
+
 // getTypeString gets type string
 func (e *Endpoint) getTypeString(t reflect.Type) string {
 	switch t.Kind() {
@@ -353,6 +637,9 @@ func (e *Endpoint) getTypeString(t reflect.Type) string {
 	case reflect.Bool:
 		return "boolean"
 	case reflect.Slice, reflect.Array:
+		if isByteSlice(t) {
+			return "string"
+		}
 		return "array"
 	case reflect.Map:
 		return "object"
@@ -369,6 +656,25 @@ func (e *Endpoint) getTypeString(t reflect.Type) string {
 	}
 }
 
+// getFormatString gets the OpenAPI string format of a type
+func (e *Endpoint) getFormatString(t reflect.Type) string {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t.Kind() == reflect.Struct && t.PkgPath() == "time" && t.Name() == "Time" {
+		return "date-time"
+	}
+	if isByteSlice(t) {
+		return "byte"
+	}
+	return ""
+}
+
+// isByteSlice reports whether t is []byte, which is encoded as a base64 string in JSON
+func isByteSlice(t reflect.Type) bool {
+	return t.Kind() == reflect.Slice && t.Elem().Kind() == reflect.Uint8
+}
+
 // getExampleValue gets example value
 func (e *Endpoint) getExampleValue(t reflect.Type) interface{} {
 	switch t.Kind() {
@@ -383,6 +689,17 @@ func (e *Endpoint) getExampleValue(t reflect.Type) interface{} {
 	case reflect.Bool:
 		return true
 	case reflect.Slice, reflect.Array:
+		if isByteSlice(t) {
+			return "ZXhhbXBsZQ=="
+		}
+		// Slices of structs get one element so nested example tags show up
+		elemType := t.Elem()
+		if elemType.Kind() == reflect.Ptr {
+			elemType = elemType.Elem()
+		}
+		if elemType.Kind() == reflect.Struct {
+			return []interface{}{e.getExampleValue(elemType)}
+		}
 		return []interface{}{}
 	case reflect.Map:
 		return map[string]interface{}{}
@@ -398,6 +715,63 @@ func (e *Endpoint) getExampleValue(t reflect.Type) interface{} {
 	}
 }
 
+// fieldExampleValue returns the example for a struct field: the example tag first,
+// then the first oneof value, then the synthetic default for its type
+func (e *Endpoint) fieldExampleValue(field reflect.StructField) interface{} {
+	if tag, ok := field.Tag.Lookup("example"); ok {
+		return e.parseExampleTag(field.Type, tag)
+	}
+
+	for _, part := range strings.Split(field.Tag.Get("binding"), ",") {
+		name, value, _ := strings.Cut(strings.TrimSpace(part), "=")
+		if name == "oneof" {
+			if values := strings.Fields(value); len(values) > 0 {
+				return e.parseEnumValues(field.Type, values[:1])[0]
+			}
+		}
+	}
+
+	return e.getExampleValue(field.Type)
+}
+
+// parseExampleTag converts an example tag value to the field's type
+// Slices accept a JSON array or comma-separated values, maps and structs accept JSON
+func (e *Endpoint) parseExampleTag(t reflect.Type, tag string) interface{} {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		if v, err := strconv.ParseBool(tag); err == nil {
+			return v
+		}
+		return tag
+	case reflect.Slice, reflect.Array:
+		if isByteSlice(t) {
+			return tag
+		}
+		var values []interface{}
+		if err := json.Unmarshal([]byte(tag), &values); err == nil {
+			return values
+		}
+		parts := strings.Split(tag, ",")
+		values = make([]interface{}, len(parts))
+		for i, part := range parts {
+			values[i] = e.parseExampleTag(t.Elem(), strings.TrimSpace(part))
+		}
+		return values
+	case reflect.Map, reflect.Struct:
+		var value interface{}
+		if err := json.Unmarshal([]byte(tag), &value); err == nil {
+			return value
+		}
+		return tag
+	default:
+		return e.parseEnumValues(t, []string{tag})[0]
+	}
+}
+
 // generateStructExample generates struct example
 func (e *Endpoint) generateStructExample(t reflect.Type) interface{} {
 	if t.Kind() == reflect.Ptr {
@@ -409,17 +783,26 @@ func (e *Endpoint) generateStructExample(t reflect.Type) interface{} {
 	}
 
 	example := make(map[string]interface{})
+	var promoted []map[string]interface{}
 	for i := 0; i < t.NumField(); i++ {
 		field := t.Field(i)
-		if !field.IsExported() {
-			continue
-		}
 
 		jsonTag := field.Tag.Get("json")
 		if jsonTag == "-" {
 			continue
 		}
 
+		if embeddedType, ok := flattenedStructType(field); ok {
+			if nested, ok := e.generateStructExample(embeddedType).(map[string]interface{}); ok {
+				promoted = append(promoted, nested)
+			}
+			continue
+		}
+
+		if !field.IsExported() {
+			continue
+		}
+
 		fieldName := field.Name
 		if jsonTag != "" {
 			parts := strings.Split(jsonTag, ",")
@@ -428,12 +811,39 @@ func (e *Endpoint) generateStructExample(t reflect.Type) interface{} {
 			}
 		}
 
-		example[fieldName] = e.getExampleValue(field.Type)
+		example[fieldName] = e.fieldExampleValue(field)
+	}
+
+	for _, nested := range promoted {
+		for name, value := range nested {
+			if _, exists := example[name]; !exists {
+				example[name] = value
+			}
+		}
 	}
 
 	return example
 }
 
+// flattenedStructType reports whether a struct field's properties are promoted into the parent:
+// embedded structs without a JSON name, or fields using the ",inline" option
+func flattenedStructType(field reflect.StructField) (reflect.Type, bool) {
+	parts := strings.Split(field.Tag.Get("json"), ",")
+	inline := slices.Contains(parts[1:], "inline")
+	if !inline && (!field.Anonymous || parts[0] != "") {
+		return nil, false
+	}
+
+	t := field.Type
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct || (t.PkgPath() == "time" && t.Name() == "Time") {
+		return nil, false
+	}
+	return t, true
+}
+
 // extractPathParameters extracts path parameters
 func (e *Endpoint) extractPathParameters(path string) []ApiParameter {
 	var params []ApiParameter
@@ -455,6 +865,59 @@ func (e *Endpoint) extractPathParameters(path string) []ApiParameter {
 	return params
 }
 
+// extractStructParameters extracts query or header parameters from a struct
+func (e *Endpoint) extractStructParameters(obj interface{}, in string) []ApiParameter {
+	if obj == nil {
+		return nil
+	}
+
+	t := reflect.TypeOf(obj)
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return nil
+	}
+
+	// query parameters are bound by form tag, header parameters by header tag
+	nameTag := "form"
+	if in == "header" {
+		nameTag = "header"
+	}
+
+	var params []ApiParameter
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		name := ""
+		for _, tag := range []string{nameTag, "json"} {
+			if value := field.Tag.Get(tag); value != "" {
+				name = strings.Split(value, ",")[0]
+				break
+			}
+		}
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+
+		params = append(params, ApiParameter{
+			Name:        name,
+			In:          in,
+			Type:        e.getTypeString(field.Type),
+			Required:    strings.Contains(field.Tag.Get("binding"), "required"),
+			Description: e.buildDescription(field),
+		})
+	}
+
+	return params
+}
+
 // extractTags extracts tags from path
 func extractTags(path string) []string {
 	parts := strings.Split(path, "/")
@@ -465,23 +928,41 @@ func extractTags(path string) []string {
 }
 
 // GetApiDocumentation gets API documentation (JSON format)
+// Info and servers come from config.Config (API_TITLE, API_DESCRIPTION, API_VERSION, API_SERVERS)
 func (e *Endpoint) GetApiDocumentation() *ApiDocumentation {
+	info, servers := defaultApiInfo()
+	return e.GenerateApiDocumentation(info, servers)
+}
+
+// defaultApiInfo builds API info and servers from the framework config
+func defaultApiInfo() (ApiInfo, []ApiServer) {
 	info := ApiInfo{
-		Title:       "project API",
-		Description: "project Platform API Documentation",
-		Version:     "1.0.0",
+		Title:   "API",
+		Version: "1.0.0",
 	}
 
-	servers := []ApiServer{
-		{
-			URL:         "https://api.project.com",
-			Description: "Production Environment",
-		},
-		{
-			URL:         "https://api-dev.project.com",
-			Description: "Development Environment",
-		},
+	cfg := config.Config
+	if cfg == nil {
+		return info, nil
 	}
 
-	return e.GenerateApiDocumentation(info, servers)
+	if cfg.ApiTitle != "" {
+		info.Title = cfg.ApiTitle
+	}
+	if cfg.ApiVersion != "" {
+		info.Version = cfg.ApiVersion
+	}
+	info.Description = cfg.ApiDescription
+
+	// API_SERVERS: "https://api.example.com|Production,https://api-dev.example.com|Development"
+	var servers []ApiServer
+	for _, entry := range strings.Split(cfg.ApiServers, ",") {
+		url, description, _ := strings.Cut(strings.TrimSpace(entry), "|")
+		if url == "" {
+			continue
+		}
+		servers = append(servers, ApiServer{URL: strings.TrimSpace(url), Description: strings.TrimSpace(description)})
+	}
+
+	return info, servers
 }
@@ -0,0 +1,113 @@
+package openapi
+
+import (
+	"strings"
+	"testing"
+)
+
+type price struct {
+	Amount   int64  `json:"amount" binding:"required"`
+	Currency string `json:"currency"`
+}
+
+type priceList struct {
+	Prices  map[string]price  `json:"prices"`
+	ByLevel map[int]*price    `json:"by_level"`
+	Labels  map[string]string `json:"labels"`
+}
+
+func TestSchemaDocMapAdditionalProperties(t *testing.T) {
+	schema := (&Endpoint{}).generateSchemaDoc(priceList{})
+
+	prices := schema.Properties["prices"]
+	if prices.Type != "object" || prices.AdditionalProperties == nil {
+		t.Fatalf("prices = %+v, want an object with additionalProperties", prices)
+	}
+	value := prices.AdditionalProperties
+	if value.Type != "object" {
+		t.Fatalf("prices value type = %q, want object", value.Type)
+	}
+	if value.Properties["amount"].Type != "integer" || value.Properties["currency"].Type != "string" {
+		t.Fatalf("prices value properties = %+v, want amount and currency", value.Properties)
+	}
+	if len(value.RequiredFields) != 1 || value.RequiredFields[0] != "amount" {
+		t.Fatalf("prices value required = %v, want [amount]", value.RequiredFields)
+	}
+	if strings.Contains(prices.Description, "Map keys") {
+		t.Fatalf("string-keyed map should not note its key type, got %q", prices.Description)
+	}
+
+	// Pointer values are dereferenced and non-string keys are noted in the description
+	byLevel := schema.Properties["by_level"]
+	if byLevel.AdditionalProperties == nil || byLevel.AdditionalProperties.Properties["amount"].Type != "integer" {
+		t.Fatalf("by_level = %+v, want price values", byLevel)
+	}
+	if !strings.Contains(byLevel.Description, "Map keys are integer") {
+		t.Fatalf("by_level description = %q, want key type note", byLevel.Description)
+	}
+
+	labels := schema.Properties["labels"]
+	if labels.AdditionalProperties == nil || labels.AdditionalProperties.Type != "string" {
+		t.Fatalf("labels = %+v, want string values", labels)
+	}
+}
+
+type baseModel struct {
+	ID        uint   `json:"id" binding:"required"`
+	CreatedAt int64  `json:"created_at"`
+	Secret    string `json:"-"`
+}
+
+type auditInfo struct {
+	UpdatedBy string `json:"updated_by"`
+}
+
+type product struct {
+	baseModel
+	*auditInfo
+	Extra auditInfo `json:",inline"`
+	Named baseModel `json:"named"`
+	Name  string    `json:"name,omitempty"`
+	Note  string    `json:"-"`
+	// ID declared on the parent wins over the promoted field
+	ID string `json:"id"`
+}
+
+func TestSchemaDocEmbeddedStruct(t *testing.T) {
+	schema := (&Endpoint{}).generateSchemaDoc(&product{})
+
+	for _, name := range []string{"id", "created_at", "updated_by", "named", "name"} {
+		if _, ok := schema.Properties[name]; !ok {
+			t.Fatalf("schema is missing %q, got %v", name, schema.Properties)
+		}
+	}
+	for _, name := range []string{"baseModel", "auditInfo", "Extra", "Secret", "Note", "-", "name,omitempty"} {
+		if _, ok := schema.Properties[name]; ok {
+			t.Fatalf("schema should not contain %q", name)
+		}
+	}
+	if got := schema.Properties["id"].Type; got != "string" {
+		t.Fatalf("id type = %q, parent field should shadow the promoted one", got)
+	}
+	for _, name := range schema.Required {
+		if name == "id" {
+			t.Fatal("shadowed promoted field should not add its required flag")
+		}
+	}
+	if named := schema.Properties["named"]; named.Properties["id"].Type != "integer" {
+		t.Fatalf("named embedded field = %+v, want nested object", named)
+	}
+
+	example, ok := schema.Example.(map[string]interface{})
+	if !ok {
+		t.Fatalf("example = %T, want map", schema.Example)
+	}
+	for _, name := range []string{"created_at", "updated_by"} {
+		if _, ok := example[name]; !ok {
+			t.Fatalf("example is missing promoted field %q: %v", name, example)
+		}
+	}
+	if _, ok := example["Secret"]; ok {
+		t.Fatal("example should skip json:\"-\" fields of embedded structs")
+	}
+}
@@ -1,14 +1,22 @@
 package openapi
 
 import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
+	"net/http"
 	"reflect"
+	"strconv"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/flaboy/aira-web/pkg/openapi/interfaces"
 
 	"github.com/flaboy/pin"
 	"github.com/flaboy/pin/usererrors"
+	"github.com/go-playground/validator/v10"
 )
 
 type EventCode string
@@ -24,14 +32,33 @@ type Endpoint struct {
 	Name      interfaces.EndpointType
 	Events    map[EventCode]*EventInfo
 	eventlist []*EventInfo
-	apilist   []ApiRouter
+	apilist   []*ApiRouter // 使用指针保存，保证 ApiBuilder 持有的引用在追加后仍然有效
 	mutex     sync.RWMutex
+
+	httpClient     *http.Client  // webhook投递使用的HTTP客户端，为空时使用共享的默认客户端
+	webhookTimeout time.Duration // 单次webhook请求超时，为0时使用 DefaultWebhookTimeout
+	logger         *slog.Logger  // 事件投递日志，为空时使用 slog.Default()
+
+	// AWS客户端在首次投递时创建并复用，也可通过 SetSQSClient / SetSNSClient 注入
+	awsOnce   sync.Once
+	awsErr    error
+	sqsClient SQSAPI
+	snsClient SNSAPI
 }
 
 var endpoints = make(map[interfaces.EndpointType]*Endpoint)
 var endpointsMutex sync.RWMutex
 
+// GetEndpoint 获取端点，不存在时创建
+// 已存在的端点只需读锁，仅在创建时获取写锁并再次检查
 func GetEndpoint(name interfaces.EndpointType) *Endpoint {
+	endpointsMutex.RLock()
+	ep, exists := endpoints[name]
+	endpointsMutex.RUnlock()
+	if exists {
+		return ep
+	}
+
 	endpointsMutex.Lock()
 	defer endpointsMutex.Unlock()
 
@@ -39,10 +66,10 @@ func GetEndpoint(name interfaces.EndpointType) *Endpoint {
 		return ep
 	}
 
-	ep := &Endpoint{
+	ep = &Endpoint{
 		Name:    name,
 		Events:  make(map[EventCode]*EventInfo),
-		apilist: make([]ApiRouter, 0),
+		apilist: make([]*ApiRouter, 0),
 	}
 	endpoints[name] = ep
 	return ep
@@ -55,8 +82,17 @@ func (e *Endpoint) AddEvent(event EventInfo) {
 	e.eventlist = append(e.eventlist, &event)
 }
 
+// GetAllEvents 按注册顺序返回所有事件的副本，修改返回值不影响端点内部状态
 func (e *Endpoint) GetAllEvents() []*EventInfo {
-	return e.eventlist
+	e.mutex.RLock()
+	defer e.mutex.RUnlock()
+
+	events := make([]*EventInfo, len(e.eventlist))
+	for i, event := range e.eventlist {
+		copied := *event
+		events[i] = &copied
+	}
+	return events
 }
 
 type ApiRouter struct {
@@ -67,37 +103,117 @@ type ApiRouter struct {
 	Request         interface{}
 	Response        interface{}
 	Errors          []*usererrors.Error
-	RequestExample  interface{} // 请求示例
-	ResponseExample interface{} // 响应示例
+	RequestExample  interface{}             // 请求示例
+	ResponseExample interface{}             // 响应示例
+	QueryParams     interface{}             // 查询参数结构体（仅用于文档）
+	HeaderParams    interface{}             // 请求头参数结构体（仅用于文档）
+	Summary         string                  // 简短摘要
+	Deprecated      bool                    // 是否已废弃
+	Tags            []string                // 文档分组标签，为空时根据路径第一段推断
+	Responses       map[int]ApiResponseSpec // 按HTTP状态码登记的其他响应（仅用于文档）
+	RawResponse     bool                    // 响应不使用统一包装（仅用于文档），如文件下载
+}
+
+// ApiResponseSpec 按状态码登记的响应结构及示例
+type ApiResponseSpec struct {
+	Schema  interface{}
+	Example interface{}
 }
 
 // ApiBuilder 用于支持链式调用的API构建器
+// 构建器持有所属端点和已注册路由的指针，修改在端点锁内进行，始终作用于正确的路由
 type ApiBuilder struct {
+	endpoint         *Endpoint
 	registeredRouter *ApiRouter // 已注册的API路由的引用
 }
 
-// WithExample 设置请求示例
-func (b *ApiBuilder) WithExample(example interface{}) *ApiBuilder {
-	if b.registeredRouter != nil {
-		b.registeredRouter.RequestExample = example
+// update 在端点锁内修改已注册的路由
+func (b *ApiBuilder) update(fn func(router *ApiRouter)) *ApiBuilder {
+	if b.registeredRouter == nil {
+		return b
+	}
+	if b.endpoint != nil {
+		b.endpoint.mutex.Lock()
+		defer b.endpoint.mutex.Unlock()
 	}
+	fn(b.registeredRouter)
 	return b
 }
 
+// WithExample 设置请求示例
+func (b *ApiBuilder) WithExample(example interface{}) *ApiBuilder {
+	return b.update(func(router *ApiRouter) {
+		router.RequestExample = example
+	})
+}
+
 // WithErrors sets error examples
 func (b *ApiBuilder) WithErrors(errors ...*usererrors.Error) *ApiBuilder {
-	if b.registeredRouter != nil {
-		b.registeredRouter.Errors = append(b.registeredRouter.Errors, errors...)
-	}
-	return b
+	return b.update(func(router *ApiRouter) {
+		router.Errors = append(router.Errors, errors...)
+	})
+}
+
+// WithQueryParams 通过结构体声明查询参数（使用 form/json、binding、description 标签）
+func (b *ApiBuilder) WithQueryParams(v interface{}) *ApiBuilder {
+	return b.update(func(router *ApiRouter) {
+		router.QueryParams = v
+	})
+}
+
+// WithHeaderParams 通过结构体声明请求头参数（使用 header/json、binding、description 标签）
+func (b *ApiBuilder) WithHeaderParams(v interface{}) *ApiBuilder {
+	return b.update(func(router *ApiRouter) {
+		router.HeaderParams = v
+	})
+}
+
+// WithSummary 设置简短摘要
+func (b *ApiBuilder) WithSummary(summary string) *ApiBuilder {
+	return b.update(func(router *ApiRouter) {
+		router.Summary = summary
+	})
+}
+
+// Deprecated 标记API已废弃
+func (b *ApiBuilder) Deprecated() *ApiBuilder {
+	return b.update(func(router *ApiRouter) {
+		router.Deprecated = true
+	})
+}
+
+// WithResponse 登记指定HTTP状态码的响应结构和示例，2xx响应在文档中会包装为pin响应格式
+// 处理器的默认响应仍以200登记
+func (b *ApiBuilder) WithResponse(status int, schema interface{}, example interface{}) *ApiBuilder {
+	return b.update(func(router *ApiRouter) {
+		responses := make(map[int]ApiResponseSpec, len(router.Responses)+1)
+		for code, spec := range router.Responses {
+			responses[code] = spec
+		}
+		responses[status] = ApiResponseSpec{Schema: schema, Example: example}
+		router.Responses = responses
+	})
+}
+
+// WithRawResponse 标记响应为原始内容，文档中不包装为统一响应格式
+func (b *ApiBuilder) WithRawResponse() *ApiBuilder {
+	return b.update(func(router *ApiRouter) {
+		router.RawResponse = true
+	})
+}
+
+// WithTags 设置文档分组标签，覆盖根据路径推断的默认标签
+func (b *ApiBuilder) WithTags(tags ...string) *ApiBuilder {
+	return b.update(func(router *ApiRouter) {
+		router.Tags = append([]string(nil), tags...)
+	})
 }
 
 // WithResponseExample 设置响应示例
 func (b *ApiBuilder) WithResponseExample(example interface{}) *ApiBuilder {
-	if b.registeredRouter != nil {
-		b.registeredRouter.ResponseExample = example
-	}
-	return b
+	return b.update(func(router *ApiRouter) {
+		router.ResponseExample = example
+	})
 }
 
 // buildAndRegister 构建并注册API，返回ApiBuilder引用
@@ -108,7 +224,7 @@ func buildAndRegister(
 	request, response interface{},
 	errors []*usererrors.Error,
 ) *ApiBuilder {
-	router := ApiRouter{
+	router := &ApiRouter{
 		Name:            apiName,
 		Method:          method,
 		Path:            path,
@@ -121,11 +237,24 @@ func buildAndRegister(
 	}
 
 	e := GetEndpoint(endpointType)
+
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	// 检测重复注册，API在初始化阶段注册，直接panic以便尽早发现
+	for _, existing := range e.apilist {
+		if existing.Method == method && existing.Path == path {
+			panic(fmt.Sprintf("openapi: duplicate API route %s %s on endpoint %s: %q conflicts with %q",
+				method, path, endpointType, apiName, existing.Name))
+		}
+	}
+
 	e.apilist = append(e.apilist, router)
 
 	// 返回ApiBuilder，引用刚刚添加的API
 	return &ApiBuilder{
-		registeredRouter: &e.apilist[len(e.apilist)-1],
+		endpoint:         e,
+		registeredRouter: router,
 	}
 }
 
@@ -228,6 +357,17 @@ func RegisterPutApi[Req any, Resp any](
 	return registerTypedApiRouter(t, "PUT", path, handler, apiName, errors...)
 }
 
+// 注册PATCH API（需要请求体，用于部分更新）
+func RegisterPatchApi[Req any, Resp any](
+	t interfaces.EndpointType,
+	path string,
+	handler func(c *pin.Context, request Req) (response Resp, err *usererrors.Error),
+	apiName string,
+	errors ...*usererrors.Error,
+) *ApiBuilder {
+	return registerTypedApiRouter(t, "PATCH", path, handler, apiName, errors...)
+}
+
 // 注册GET API（无请求体）
 func RegisterGetApi[Resp any](
 	t interfaces.EndpointType,
@@ -250,9 +390,60 @@ func RegisterDeleteApi[Resp any](
 	return registerNoRequestRouter(t, "DELETE", path, handler, apiName, errors...)
 }
 
-// 获取所有注册的API路由
+// 获取所有注册的API路由（返回副本）
 func (e *Endpoint) GetApiList() []ApiRouter {
-	return e.apilist
+	e.mutex.RLock()
+	defer e.mutex.RUnlock()
+
+	list := make([]ApiRouter, len(e.apilist))
+	for i, router := range e.apilist {
+		list[i] = *router
+	}
+	return list
+}
+
+// UnregisterApi 移除指定方法和路径的API路由，返回是否有路由被移除
+// 并发安全：HandleApiRequest 在分发前会复制路由，已在处理中的请求继续使用旧的处理器完成；
+// 移除后到达的请求返回 endpoint_not_found
+func (e *Endpoint) UnregisterApi(method, path string) bool {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	for i, router := range e.apilist {
+		if router.Method == method && router.Path == path {
+			e.apilist = append(e.apilist[:i:i], e.apilist[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// ReplaceApi 替换已注册API路由的处理器，返回是否找到该路由
+// 路由的文档信息（请求/响应类型、示例、错误等）保持不变；并发保证与 UnregisterApi 相同
+func (e *Endpoint) ReplaceApi(method, path string, handler func(c *pin.Context, request any) (response any, err *usererrors.Error)) bool {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	for _, router := range e.apilist {
+		if router.Method == method && router.Path == path {
+			router.Handler = handler
+			return true
+		}
+	}
+	return false
+}
+
+// findApiRouter 查找与请求方法和路径匹配的API路由，返回副本
+func (e *Endpoint) findApiRouter(method, path string) (ApiRouter, bool) {
+	e.mutex.RLock()
+	defer e.mutex.RUnlock()
+
+	for _, router := range e.apilist {
+		if router.Method == method && router.Path == path {
+			return *router, true
+		}
+	}
+	return ApiRouter{}, false
 }
 
 // API请求处理器
@@ -261,43 +452,78 @@ func (e *Endpoint) HandleApiRequest(c *pin.Context) error {
 	path := strings.TrimPrefix(c.Param("path"), "/")
 	method := c.Request.Method
 
-	// 遍历已注册的API路由
-	for _, router := range e.apilist {
-		if router.Method == method && router.Path == path {
-			// 解析请求体
-			var request interface{}
-			if router.Request != nil {
-				// 创建注册类型的新实例
-				requestType := reflect.TypeOf(router.Request)
-				isPointer := requestType.Kind() == reflect.Ptr
-				if isPointer {
-					requestType = requestType.Elem()
-				}
-
-				// 创建实例指针用于 JSON 绑定
-				newValue := reflect.New(requestType)
-
-				if err := c.BindJSON(newValue.Interface()); err != nil {
-					return usererrors.New("invalid_request", "Invalid request format")
-				}
-
-				// 如果原始类型是指针，返回指针；否则返回值
-				if isPointer {
-					request = newValue.Interface()
-				} else {
-					request = newValue.Elem().Interface()
-				}
-			}
+	// 按应用限流
+	if err := checkRateLimit(c); err != nil {
+		return err
+	}
+
+	// 查找已注册的API路由
+	router, ok := e.findApiRouter(method, path)
+	if !ok {
+		return usererrors.New("endpoint_not_found", "API endpoint not found")
+	}
+
+	// 解析请求体
+	var request interface{}
+	if router.Request != nil {
+		// 创建注册类型的新实例
+		requestType := reflect.TypeOf(router.Request)
+		isPointer := requestType.Kind() == reflect.Ptr
+		if isPointer {
+			requestType = requestType.Elem()
+		}
+
+		// 创建实例指针用于 JSON 绑定
+		newValue := reflect.New(requestType)
 
-			// 调用处理器
-			response, err := router.Handler(c, request)
-			if err != nil {
-				return err
+		// ShouldBindJSON 会同时执行请求结构体上的 binding 校验规则
+		if err := c.ShouldBindJSON(newValue.Interface()); err != nil {
+			var validationErrs validator.ValidationErrors
+			if errors.As(err, &validationErrs) {
+				return newValidationError(requestType, validationErrs)
 			}
+			return usererrors.New("invalid_request", "Invalid request format")
+		}
 
-			return c.Render(response)
+		// 如果原始类型是指针，返回指针；否则返回值
+		if isPointer {
+			request = newValue.Interface()
+		} else {
+			request = newValue.Elem().Interface()
 		}
 	}
 
-	return usererrors.New("endpoint_not_found", "API endpoint not found")
+	// 调用处理器
+	response, err := router.Handler(c, request)
+	if err != nil {
+		return err
+	}
+
+	return renderResponse(c, response)
+}
+
+// checkRateLimit 使用已配置的限流器检查当前应用是否超出请求频率限制
+// 未配置限流器时不做限制；超限时设置 Retry-After 响应头并返回 rate_limited 错误
+func checkRateLimit(c *pin.Context) error {
+	if limiter == nil {
+		return nil
+	}
+
+	appID := c.GetString("application_id")
+	if appID == "" {
+		return nil
+	}
+
+	allowed, retryAfter := limiter.Allow(appID)
+	if allowed {
+		return nil
+	}
+
+	seconds := int(math.Ceil(retryAfter.Seconds()))
+	if seconds < 1 {
+		seconds = 1
+	}
+	c.Header("Retry-After", strconv.Itoa(seconds))
+
+	return usererrors.New("rate_limited", fmt.Sprintf("Rate limit exceeded, retry after %d seconds", seconds))
 }
@@ -0,0 +1,243 @@
+package openapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/flaboy/aira-web/pkg/openapi/interfaces"
+
+	"github.com/flaboy/pin"
+	"github.com/flaboy/pin/usererrors"
+	"github.com/gin-gonic/gin"
+)
+
+type itemResponse struct {
+	ID string `json:"id"`
+}
+
+func getItem(c *pin.Context) (*itemResponse, *usererrors.Error) {
+	return &itemResponse{ID: "1"}, nil
+}
+
+// newIsolatedEndpoint 按测试名创建独立的端点，避免测试之间共享注册状态
+func newIsolatedEndpoint(t testing.TB) *Endpoint {
+	return GetEndpoint(interfaces.EndpointType("test-" + t.Name()))
+}
+
+func TestAddEventConcurrentWithGetAllEvents(t *testing.T) {
+	e := newIsolatedEndpoint(t)
+
+	const writers, perWriter = 4, 50
+	var wg sync.WaitGroup
+	for w := 0; w < writers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := 0; i < perWriter; i++ {
+				e.AddEvent(EventInfo{Code: EventCode(fmt.Sprintf("event.%d.%d", w, i)), Name: "event"})
+			}
+		}(w)
+	}
+
+	done := make(chan struct{})
+	var readers sync.WaitGroup
+	for r := 0; r < 4; r++ {
+		readers.Add(1)
+		go func() {
+			defer readers.Done()
+			for {
+				select {
+				case <-done:
+					return
+				default:
+				}
+				for _, event := range e.GetAllEvents() {
+					// 修改副本不影响端点内部状态
+					event.Name = "mutated"
+				}
+				e.GenerateEventDocumentation()
+			}
+		}()
+	}
+
+	wg.Wait()
+	close(done)
+	readers.Wait()
+
+	events := e.GetAllEvents()
+	if len(events) != writers*perWriter {
+		t.Fatalf("got %d events, want %d", len(events), writers*perWriter)
+	}
+	for _, event := range events {
+		if event.Name != "event" {
+			t.Fatalf("event %s name = %q, copies returned by GetAllEvents must not alias internal state", event.Code, event.Name)
+		}
+	}
+}
+
+func TestGetEndpointCreatesOnce(t *testing.T) {
+	name := interfaces.EndpointType("test-" + t.Name())
+
+	var wg sync.WaitGroup
+	results := make([]*Endpoint, 16)
+	for i := range results {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			results[i] = GetEndpoint(name)
+		}(i)
+	}
+	wg.Wait()
+
+	for i, ep := range results {
+		if ep != results[0] {
+			t.Fatalf("GetEndpoint call %d returned a different endpoint", i)
+		}
+	}
+	if results[0].Name != name || results[0].Events == nil {
+		t.Fatalf("endpoint not initialised: %+v", results[0])
+	}
+}
+
+// BenchmarkGetEndpointParallel 已存在端点的并发查找只需读锁，不会互相阻塞
+func BenchmarkGetEndpointParallel(b *testing.B) {
+	name := interfaces.EndpointType("bench-get-endpoint")
+	GetEndpoint(name)
+
+	b.ReportAllocs()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			GetEndpoint(name)
+		}
+	})
+}
+
+// BenchmarkGetEndpointWriteLocked 对照组：每次查找都获取写锁时的开销
+func BenchmarkGetEndpointWriteLocked(b *testing.B) {
+	name := interfaces.EndpointType("bench-get-endpoint")
+	GetEndpoint(name)
+
+	b.ReportAllocs()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			endpointsMutex.Lock()
+			_ = endpoints[name]
+			endpointsMutex.Unlock()
+		}
+	})
+}
+
+func TestDuplicateApiRegistrationPanics(t *testing.T) {
+	endpoint := interfaces.EndpointType("test-" + t.Name())
+	RegisterGetApi(endpoint, "items", getItem, "ListItems")
+
+	defer func() {
+		r := recover()
+		if r == nil {
+			t.Fatal("registering the same method and path twice should panic")
+		}
+		message := fmt.Sprint(r)
+		for _, want := range []string{"GET items", `"ListItemsCopy"`, `"ListItems"`} {
+			if !strings.Contains(message, want) {
+				t.Fatalf("panic message %q should contain %s", message, want)
+			}
+		}
+	}()
+	RegisterGetApi(endpoint, "items", getItem, "ListItemsCopy")
+}
+
+func TestSamePathDifferentMethodIsNotDuplicate(t *testing.T) {
+	endpoint := interfaces.EndpointType("test-" + t.Name())
+	RegisterGetApi(endpoint, "items", getItem, "GetItems")
+	RegisterDeleteApi(endpoint, "items", getItem, "DeleteItems")
+
+	if got := len(GetEndpoint(endpoint).GetApiList()); got != 2 {
+		t.Fatalf("got %d routes, want 2", got)
+	}
+}
+
+type patchItemRequest struct {
+	Name string `json:"name" binding:"required"`
+}
+
+// serveApi 通过 HandleApiRequest 分发一个请求，返回响应记录
+func serveApi(e *Endpoint, method, path, body string) (*httptest.ResponseRecorder, error) {
+	gin.SetMode(gin.TestMode)
+	w := httptest.NewRecorder()
+	ginCtx, _ := gin.CreateTestContext(w)
+	ginCtx.Request = httptest.NewRequest(method, "/api/"+path, strings.NewReader(body))
+	ginCtx.Request.Header.Set("Content-Type", "application/json")
+	ginCtx.Params = gin.Params{{Key: "path", Value: "/" + path}}
+	return w, e.HandleApiRequest(&pin.Context{Context: ginCtx})
+}
+
+func TestRegisterPatchApiRoundTrip(t *testing.T) {
+	endpoint := interfaces.EndpointType("test-" + t.Name())
+	var received patchItemRequest
+	RegisterPatchApi(endpoint, "items/1", func(c *pin.Context, req *patchItemRequest) (*itemResponse, *usererrors.Error) {
+		received = *req
+		return &itemResponse{ID: "1"}, nil
+	}, "PatchItem")
+
+	e := GetEndpoint(endpoint)
+	if list := e.GetApiList(); len(list) != 1 || list[0].Method != "PATCH" {
+		t.Fatalf("registered routes = %+v, want a single PATCH route", list)
+	}
+
+	w, err := serveApi(e, http.MethodPatch, "items/1", `{"name":"renamed"}`)
+	if err != nil {
+		t.Fatalf("HandleApiRequest returned error: %v", err)
+	}
+	if received.Name != "renamed" {
+		t.Fatalf("handler received %+v, want name renamed", received)
+	}
+	var body struct {
+		Data itemResponse `json:"data"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatalf("invalid response body %q: %v", w.Body.String(), err)
+	}
+	if body.Data.ID != "1" {
+		t.Fatalf("response data = %+v, want id 1", body.Data)
+	}
+
+	// 其他方法访问同一路径不会分发到 PATCH 处理器
+	_, err = serveApi(e, http.MethodPut, "items/1", `{"name":"renamed"}`)
+	if userErr, ok := err.(*usererrors.Error); !ok || userErr.Code() != "endpoint_not_found" {
+		t.Fatalf("PUT on a PATCH-only route returned %v, want endpoint_not_found", err)
+	}
+}
+
+func TestApiBuilderSurvivesLaterRegistrations(t *testing.T) {
+	endpoint := interfaces.EndpointType("test-" + t.Name())
+	notFound := usererrors.New("item_not_found", "Item not found")
+
+	first := RegisterGetApi(endpoint, "items/first", getItem, "First")
+	RegisterGetApi(endpoint, "items/second", getItem, "Second")
+	RegisterGetApi(endpoint, "items/third", getItem, "Third")
+
+	// 后续注册导致列表扩容后，第一个构建器的修改仍作用于第一个路由
+	example := &itemResponse{ID: "example"}
+	first.WithResponseExample(example).WithErrors(notFound)
+
+	list := GetEndpoint(endpoint).GetApiList()
+	if len(list) != 3 {
+		t.Fatalf("got %d routes, want 3", len(list))
+	}
+	if list[0].ResponseExample != example {
+		t.Fatalf("first route ResponseExample = %v, want %v", list[0].ResponseExample, example)
+	}
+	if len(list[0].Errors) != 1 || list[0].Errors[0] != notFound {
+		t.Fatalf("first route Errors = %v, want [item_not_found]", list[0].Errors)
+	}
+	for _, router := range list[1:] {
+		if router.ResponseExample != nil || len(router.Errors) != 0 {
+			t.Fatalf("route %s should be unchanged, got example=%v errors=%v", router.Name, router.ResponseExample, router.Errors)
+		}
+	}
+}
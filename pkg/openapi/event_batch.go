@@ -0,0 +1,245 @@
+package openapi
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/flaboy/aira-web/pkg/openapi/interfaces"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+	"github.com/aws/aws-sdk-go-v2/service/sqs/types"
+)
+
+// 批量投递时单个请求包含的事件数上限
+var (
+	WebhookBatchSize = 100
+	sqsBatchSize     = 10 // SQS SendMessageBatch 的上限
+)
+
+// sqsBatchMaxBytes SQS SendMessageBatch 所有消息（消息体及属性）合计的大小上限
+var sqsBatchMaxBytes = 256 * 1024
+
+// pendingDeliveries 同一应用待投递的事件
+type pendingDeliveries struct {
+	app      interfaces.ApplicationInfo
+	payloads []EventPayload
+}
+
+// EmitEvents 批量发送事件通知，每个事件码只查询一次订阅
+// 支持批量投递的应用（实现 interfaces.BatchDeliveryApplication）按 webhook/SQS 合并投递，其余应用逐条投递
+func (e *Endpoint) EmitEvents(events []EventPayload) error {
+	if ready, err := e.checkEventRepo("count", len(events)); !ready {
+		return err
+	}
+
+	subscribers := make(map[EventCode][]interfaces.ApplicationInfo)
+	var order []string
+	byApp := make(map[string]*pendingDeliveries)
+
+	now := time.Now().Unix()
+	for _, payload := range events {
+		if payload.EventID == "" {
+			payload.EventID = newEventID()
+		}
+		if payload.Timestamp == 0 {
+			payload.Timestamp = now
+		}
+
+		apps, ok := subscribers[payload.EventCode]
+		if !ok {
+			var err error
+			apps, err = findSubscribedApplications(payload.EventCode)
+			if err != nil {
+				e.log().Error("Failed to find event subscriptions", "event_code", payload.EventCode, "error", err)
+				return err
+			}
+			subscribers[payload.EventCode] = apps
+		}
+
+		for _, app := range apps {
+			pending, ok := byApp[app.GetID()]
+			if !ok {
+				pending = &pendingDeliveries{app: app}
+				byApp[app.GetID()] = pending
+				order = append(order, app.GetID())
+			}
+			pending.payloads = append(pending.payloads, payload)
+		}
+	}
+
+	// 异步投递，每个应用一个 goroutine，保持事件顺序
+	for _, appID := range order {
+		pending := byApp[appID]
+		if supportsBatch(pending.app) {
+			go e.sendBatchNotification(pending.app, pending.payloads)
+			continue
+		}
+		go func(p *pendingDeliveries) {
+			for _, payload := range p.payloads {
+				e.sendEventNotification(context.Background(), p.app, payload)
+			}
+		}(pending)
+	}
+
+	return nil
+}
+
+// supportsBatch 判断应用是否开启批量投递且通知类型支持批量
+func supportsBatch(app interfaces.ApplicationInfo) bool {
+	batchApp, ok := app.(interfaces.BatchDeliveryApplication)
+	if !ok || !batchApp.SupportsBatchDelivery() {
+		return false
+	}
+	// 只有内置的 webhook、SQS 通道支持合并投递，被自定义通道覆盖时逐条投递
+	notifier, err := getNotifier(app.GetNotifyType())
+	if err != nil {
+		return false
+	}
+	switch notifier.(type) {
+	case webhookNotifier, sqsNotifier:
+		return true
+	default:
+		return false
+	}
+}
+
+// sendBatchNotification 按批次投递同一应用的事件，每批经过熔断器并逐条记录投递结果
+func (e *Endpoint) sendBatchNotification(app interfaces.ApplicationInfo, payloads []EventPayload) {
+	if app.GetNotifyURL() == "" {
+		return
+	}
+
+	for _, batch := range splitBatches(app.GetNotifyType(), payloads) {
+		if !breaker.allow(app.GetID()) {
+			for _, payload := range batch {
+				breaker.deadLetter(e.log(), app, payload)
+				e.recordDelivery(app, payload, 0, ErrCircuitOpen)
+			}
+			continue
+		}
+
+		statusCode, failed, err := e.deliverBatch(context.Background(), app, batch)
+		// 部分消息发送失败的批次不能记为成功，否则熔断器会被重置
+		breakerErr := err
+		if breakerErr == nil && len(failed) > 0 {
+			breakerErr = fmt.Errorf("%d of %d messages in batch failed", len(failed), len(batch))
+		}
+		breaker.record(e.log(), app.GetID(), breakerErr)
+
+		for i, payload := range batch {
+			deliveryErr := err
+			if entryErr, ok := failed[i]; ok {
+				deliveryErr = entryErr
+			}
+			e.recordDelivery(app, payload, statusCode, deliveryErr)
+		}
+	}
+}
+
+// splitBatches 按通知类型的数量上限切分批次，SQS 批次同时受 sqsBatchMaxBytes 限制
+// 单条超过大小上限的消息单独成批，由SQS返回错误
+func splitBatches(notifyType string, payloads []EventPayload) [][]EventPayload {
+	size := WebhookBatchSize
+	if notifyType == "sqs" {
+		size = sqsBatchSize
+	}
+	if size <= 0 {
+		size = 1
+	}
+
+	var batches [][]EventPayload
+	start, batchBytes := 0, 0
+	for i, payload := range payloads {
+		msgBytes := 0
+		if notifyType == "sqs" {
+			msgBytes = sqsMessageSize(payload)
+		}
+		if i > start && (i-start >= size || batchBytes+msgBytes > sqsBatchMaxBytes) {
+			batches = append(batches, payloads[start:i])
+			start, batchBytes = i, 0
+		}
+		batchBytes += msgBytes
+	}
+	if start < len(payloads) {
+		batches = append(batches, payloads[start:])
+	}
+	return batches
+}
+
+// sqsMessageSize 估算消息计入SQS大小上限的字节数（消息体加属性名、类型和值）
+func sqsMessageSize(payload EventPayload) int {
+	jsonData, _ := json.Marshal(payload)
+	size := len(jsonData)
+	for name, attr := range eventMessageAttributes(payload) {
+		size += len(name) + len(attr.DataType) + len(attr.Value)
+	}
+	return size
+}
+
+// deliverBatch 投递一个批次，返回HTTP状态码（仅webhook）、按批次内下标记录的单条失败（仅SQS）和整体错误
+func (e *Endpoint) deliverBatch(ctx context.Context, app interfaces.ApplicationInfo, batch []EventPayload) (int, map[int]error, error) {
+	switch app.GetNotifyType() {
+	case "webhook":
+		statusCode, _, err := e.postWebhookBody(ctx, app.GetNotifyURL(), notifyHeaders(app), "", batch)
+		if err == nil && (statusCode < 200 || statusCode >= 300) {
+			err = fmt.Errorf("webhook returned non-success status: %d", statusCode)
+		}
+		return statusCode, nil, err
+	case "sqs":
+		failed, err := e.sendSQSBatch(ctx, app.GetNotifyURL(), batch)
+		return 0, failed, err
+	default:
+		return 0, nil, fmt.Errorf("notify type %s does not support batch delivery", app.GetNotifyType())
+	}
+}
+
+// sendSQSBatch 使用 SendMessageBatch 发送不超过10条消息，返回按批次内下标记录的单条发送失败
+// 条目ID使用批次内下标，同一批次中事件ID重复时结果也不会错位
+func (e *Endpoint) sendSQSBatch(ctx context.Context, sqsURL string, batch []EventPayload) (map[int]error, error) {
+	entries := make([]types.SendMessageBatchRequestEntry, 0, len(batch))
+	for i, payload := range batch {
+		jsonData, err := json.Marshal(payload)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal payload: %v", err)
+		}
+		entries = append(entries, types.SendMessageBatchRequestEntry{
+			Id:                aws.String(strconv.Itoa(i)),
+			MessageBody:       aws.String(string(jsonData)),
+			MessageAttributes: sqsMessageAttributes(payload),
+		})
+	}
+
+	// 与 sendSQS 共用端点缓存的客户端，AWS配置加载失败时同样回退到日志记录
+	sqsClient, err := e.sqsAPI()
+	if err != nil {
+		for _, entry := range entries {
+			e.log().Info("SQS notification sent", "notify_type", "sqs", "url", sqsURL, "payload", aws.ToString(entry.MessageBody))
+		}
+		return nil, nil
+	}
+
+	output, err := sqsClient.SendMessageBatch(ctx, &sqs.SendMessageBatchInput{
+		QueueUrl: aws.String(sqsURL),
+		Entries:  entries,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to send SQS message batch: %v", err)
+	}
+
+	if len(output.Failed) == 0 {
+		return nil, nil
+	}
+	failed := make(map[int]error, len(output.Failed))
+	for _, entry := range output.Failed {
+		i, err := strconv.Atoi(aws.ToString(entry.Id))
+		if err != nil || i < 0 || i >= len(batch) {
+			return nil, fmt.Errorf("SQS returned unknown batch entry id %q", aws.ToString(entry.Id))
+		}
+		failed[i] = fmt.Errorf("failed to send SQS message: %s %s", aws.ToString(entry.Code), aws.ToString(entry.Message))
+	}
+	return failed, nil
+}
@@ -0,0 +1,142 @@
+package openapi
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+	"github.com/aws/aws-sdk-go-v2/service/sqs/types"
+)
+
+// testApp 测试用的应用信息
+type testApp struct {
+	id         string
+	notifyType string
+	notifyURL  string
+}
+
+func (a *testApp) GetID() string           { return a.id }
+func (a *testApp) GetClientID() string     { return "client-" + a.id }
+func (a *testApp) GetClientSecret() string { return "secret" }
+func (a *testApp) GetStatus() string       { return "active" }
+func (a *testApp) GetNotifyType() string   { return a.notifyType }
+func (a *testApp) GetNotifyURL() string    { return a.notifyURL }
+func (a *testApp) UpdateLastUsed() error   { return nil }
+
+// fakeSQS 记录请求的SQS客户端，failIDs 中的批次条目返回失败
+type fakeSQS struct {
+	mutex   sync.Mutex
+	batches []*sqs.SendMessageBatchInput
+	failIDs map[string]bool
+}
+
+func (f *fakeSQS) SendMessage(ctx context.Context, params *sqs.SendMessageInput, optFns ...func(*sqs.Options)) (*sqs.SendMessageOutput, error) {
+	return &sqs.SendMessageOutput{MessageId: aws.String("msg-1")}, nil
+}
+
+func (f *fakeSQS) SendMessageBatch(ctx context.Context, params *sqs.SendMessageBatchInput, optFns ...func(*sqs.Options)) (*sqs.SendMessageBatchOutput, error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	f.batches = append(f.batches, params)
+
+	output := &sqs.SendMessageBatchOutput{}
+	for _, entry := range params.Entries {
+		if f.failIDs[aws.ToString(entry.Id)] {
+			output.Failed = append(output.Failed, types.BatchResultErrorEntry{
+				Id:      entry.Id,
+				Code:    aws.String("InternalError"),
+				Message: aws.String("boom"),
+			})
+			continue
+		}
+		output.Successful = append(output.Successful, types.SendMessageBatchResultEntry{Id: entry.Id, MessageId: aws.String("msg")})
+	}
+	return output, nil
+}
+
+// newTestEndpoint 创建不输出日志的端点
+func newTestEndpoint() *Endpoint {
+	e := &Endpoint{}
+	e.SetLogger(slog.New(slog.NewTextHandler(io.Discard, nil)))
+	return e
+}
+
+func TestSendSQSBatchUsesIndexAsEntryID(t *testing.T) {
+	client := &fakeSQS{failIDs: map[string]bool{"1": true}}
+	e := newTestEndpoint()
+	e.SetSQSClient(client)
+
+	// 同一批次中事件ID重复时，结果按下标对应，不会错位
+	batch := []EventPayload{
+		{EventID: "dup", EventCode: "order.created"},
+		{EventID: "dup", EventCode: "order.created"},
+		{EventID: "other", EventCode: "order.created"},
+	}
+	failed, err := e.sendSQSBatch(context.Background(), "https://sqs.us-east-1.amazonaws.com/123456789012/q", batch)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	for i, entry := range client.batches[0].Entries {
+		if got := aws.ToString(entry.Id); got != []string{"0", "1", "2"}[i] {
+			t.Errorf("entry %d id = %s, want index", i, got)
+		}
+	}
+	if len(failed) != 1 || failed[1] == nil {
+		t.Fatalf("failed = %v, want only index 1", failed)
+	}
+}
+
+func TestSplitBatchesRespectsSQSSizeLimit(t *testing.T) {
+	large := strings.Repeat("x", 100*1024)
+	payloads := make([]EventPayload, 5)
+	for i := range payloads {
+		payloads[i] = EventPayload{EventID: "id", EventCode: "file.uploaded", Data: large}
+	}
+
+	batches := splitBatches("sqs", payloads)
+	if len(batches) != 3 {
+		t.Fatalf("got %d batches, want 3", len(batches))
+	}
+	for _, batch := range batches {
+		total := 0
+		for _, payload := range batch {
+			total += sqsMessageSize(payload)
+		}
+		if total > sqsBatchMaxBytes {
+			t.Errorf("batch of %d messages is %d bytes, over the limit", len(batch), total)
+		}
+	}
+}
+
+func TestSplitBatchesRespectsCount(t *testing.T) {
+	payloads := make([]EventPayload, 25)
+
+	if got := len(splitBatches("sqs", payloads)); got != 3 {
+		t.Errorf("sqs batches = %d, want 3", got)
+	}
+	if got := len(splitBatches("webhook", payloads)); got != 1 {
+		t.Errorf("webhook batches = %d, want 1", got)
+	}
+}
+
+func TestPartiallyFailedBatchIsNotRecordedAsSuccess(t *testing.T) {
+	app := &testApp{id: "batch-partial", notifyType: "sqs", notifyURL: "https://sqs.us-east-1.amazonaws.com/123456789012/q"}
+	defer ResetCircuitBreaker(app.id)
+
+	e := newTestEndpoint()
+	// 之前已有一次失败，部分失败的批次不能重置计数
+	breaker.record(e.log(), app.id, io.ErrUnexpectedEOF)
+
+	e.SetSQSClient(&fakeSQS{failIDs: map[string]bool{"0": true}})
+	e.sendBatchNotification(app, []EventPayload{{EventID: "a"}, {EventID: "b"}})
+
+	if status := GetCircuitBreakerStatus(app.id); status.ConsecutiveFailures != 2 {
+		t.Fatalf("consecutive failures = %d, want 2", status.ConsecutiveFailures)
+	}
+}
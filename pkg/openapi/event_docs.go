@@ -0,0 +1,103 @@
+package openapi
+
+import "time"
+
+// EventDocumentation describes every event an endpoint can emit
+type EventDocumentation struct {
+	Info   ApiInfo    `json:"info"`
+	Events []EventDoc `json:"events"`
+}
+
+// EventDoc documents a single event and the payload subscribers receive
+type EventDoc struct {
+	Code        EventCode  `json:"code"`
+	Name        string     `json:"name"`
+	Description string     `json:"description"`
+	Payload     *ApiSchema `json:"payload"`
+}
+
+// exampleEventID is a fixed UUID used in generated payload examples
+const exampleEventID = "123e4567-e89b-42d3-a456-426614174000"
+
+// GenerateEventDocumentation generates payload schemas and examples for all registered events
+// Each schema is wrapped in the EventPayload envelope (event_id/event_code/data/timestamp/metadata)
+func (e *Endpoint) GenerateEventDocumentation() *EventDocumentation {
+	info, _ := defaultApiInfo()
+	info.GeneratedAt = time.Now().Format(time.RFC3339)
+
+	events := e.GetAllEvents()
+	doc := &EventDocumentation{
+		Info:   info,
+		Events: make([]EventDoc, 0, len(events)),
+	}
+
+	for _, event := range events {
+		doc.Events = append(doc.Events, EventDoc{
+			Code:        event.Code,
+			Name:        event.Name,
+			Description: event.Description,
+			Payload:     e.generateEventPayloadDoc(event),
+		})
+	}
+
+	return doc
+}
+
+// generateEventPayloadDoc wraps the schema of the event object in the EventPayload envelope
+func (e *Endpoint) generateEventPayloadDoc(event *EventInfo) *ApiSchema {
+	dataProperty := ApiProperty{
+		Type:        "object",
+		Description: "Event data",
+	}
+
+	var dataExample interface{}
+	if dataSchema := e.generateSchemaDoc(event.Object); dataSchema != nil {
+		dataExample = dataSchema.Example
+		dataProperty.Type = dataSchema.Type
+		if dataSchema.Type == "array" {
+			dataProperty.Items = &ApiProperty{
+				Type:           "object",
+				Properties:     dataSchema.Properties,
+				RequiredFields: dataSchema.Required,
+			}
+		} else {
+			dataProperty.Properties = dataSchema.Properties
+			dataProperty.RequiredFields = dataSchema.Required
+		}
+	}
+
+	return &ApiSchema{
+		Type: "object",
+		Properties: map[string]ApiProperty{
+			"event_id": {
+				Type:        "string",
+				Format:      "uuid",
+				Description: "Unique event ID, unchanged across retries; use it to deduplicate deliveries",
+				Example:     exampleEventID,
+			},
+			"event_code": {
+				Type:        "string",
+				Description: "Event code",
+				Example:     string(event.Code),
+			},
+			"data": dataProperty,
+			"timestamp": {
+				Type:        "integer",
+				Format:      "int64",
+				Description: "Unix timestamp (seconds) when the event was emitted",
+				Example:     int64(1700000000),
+			},
+			"metadata": {
+				Type:        "object",
+				Description: "Optional correlation metadata attached by the emitter",
+			},
+		},
+		Required: []string{"event_id", "event_code", "data", "timestamp"},
+		Example: map[string]interface{}{
+			"event_id":   exampleEventID,
+			"event_code": string(event.Code),
+			"data":       dataExample,
+			"timestamp":  int64(1700000000),
+		},
+	}
+}
@@ -3,160 +3,674 @@ package openapi
 import (
 	"bytes"
 	"context"
+	"crypto/rand"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"log/slog"
+	"net"
 	"net/http"
+	"slices"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/flaboy/aira-web/pkg/openapi/interfaces"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
-	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/sns"
+	snstypes "github.com/aws/aws-sdk-go-v2/service/sns/types"
 	"github.com/aws/aws-sdk-go-v2/service/sqs"
 	"github.com/aws/aws-sdk-go-v2/service/sqs/types"
 )
 
 type EventPayload struct {
+	EventID   string      `json:"event_id"` // 每次 EmitEvent 生成一次，重试时保持不变，供订阅方去重
 	EventCode EventCode   `json:"event_code"`
 	Data      interface{} `json:"data"`
 	Timestamp int64       `json:"timestamp"`
+	// Metadata 发送方附加的关联信息（如发起用户、租户、API版本），随webhook请求体发送，
+	// 字符串和数值类型的值同时映射为 SQS/SNS 消息属性，见 eventMessageAttributes
+	Metadata map[string]interface{} `json:"metadata,omitempty"`
+}
+
+// ErrEventRepoNotInitialized 未设置事件订阅仓储，事件无法投递
+var ErrEventRepoNotInitialized = errors.New("event repository not initialized")
+
+// allowMissingEventRepo 为 true 时未设置事件订阅仓储不视为错误
+var allowMissingEventRepo = false
+
+// SetAllowMissingEventRepository 设置未初始化事件订阅仓储时发送事件是否静默成功
+// 默认返回 ErrEventRepoNotInitialized，不使用事件订阅的部署可设为 true
+func SetAllowMissingEventRepository(allow bool) {
+	allowMissingEventRepo = allow
+}
+
+// checkEventRepo 检查事件订阅仓储是否已初始化，未初始化时记录日志，并按配置返回 ErrEventRepoNotInitialized 或nil
+func (e *Endpoint) checkEventRepo(logAttrs ...any) (bool, error) {
+	if eventRepo != nil {
+		return true, nil
+	}
+	e.log().Warn("Event repository not initialized", logAttrs...)
+	if allowMissingEventRepo {
+		return false, nil
+	}
+	return false, ErrEventRepoNotInitialized
 }
 
 func (e *Endpoint) EmitEvent(code EventCode, data interface{}) error {
+	return e.emitEvent(context.Background(), code, data, nil)
+}
+
+// EmitEventCtx 发送事件通知，ctx 贯穿异步投递：取消或超时后尚未开始的投递不再执行，进行中的请求被中断
+func (e *Endpoint) EmitEventCtx(ctx context.Context, code EventCode, data interface{}) error {
+	return e.emitEvent(ctx, code, data, nil)
+}
+
+// EmitEventWith 发送带有附加元数据的事件通知
+func (e *Endpoint) EmitEventWith(code EventCode, data interface{}, meta map[string]interface{}) error {
+	return e.emitEvent(context.Background(), code, data, meta)
+}
+
+func (e *Endpoint) emitEvent(ctx context.Context, code EventCode, data interface{}, meta map[string]interface{}) error {
 	// 检查仓储是否已初始化
-	if eventRepo == nil {
-		slog.Warn("Event repository not initialized")
-		return nil
+	if ready, err := e.checkEventRepo("event_code", code); !ready {
+		return err
 	}
 
-	// 查找订阅此事件的应用
-	subscriptions, err := eventRepo.FindByEventCode(string(code))
+	// 查找订阅此事件的应用（包含通配符订阅）
+	apps, err := findSubscribedApplications(code)
 	if err != nil {
-		slog.Error("Failed to find event subscriptions", "error", err)
+		e.log().Error("Failed to find event subscriptions", "event_code", code, "error", err)
 		return err
 	}
 
-	if len(subscriptions) == 0 {
-		slog.Info("No subscriptions found for event", "event", code)
+	if len(apps) == 0 {
+		e.log().Info("No subscriptions found for event", "event_code", code)
 		return nil
 	}
 
 	payload := EventPayload{
+		EventID:   newEventID(),
 		EventCode: code,
 		Data:      data,
 		Timestamp: time.Now().Unix(),
+		Metadata:  meta,
 	}
 
 	// 异步发送通知给所有订阅的应用
-	for _, sub := range subscriptions {
-		app := sub.GetApplication()
-		if app != nil {
-			go e.sendEventNotification(app, payload)
-		}
+	for _, app := range apps {
+		go e.sendEventNotification(ctx, app, payload)
 	}
 
 	return nil
 }
 
-// SendTestNotification 发送测试通知到指定的URL
-// 这是一个公开接口，供控制器直接调用来测试通知配置
-func (e *Endpoint) SendTestNotification(notifyType, notifyURL string, payload EventPayload) error {
-	if notifyType == "" || notifyURL == "" {
-		return fmt.Errorf("notify type and URL cannot be empty")
+// newEventID 生成随机的 UUID v4 作为事件ID
+func newEventID() string {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		// 随机源不可用时退化为基于时间的ID，仍保证同一次投递内稳定
+		return fmt.Sprintf("%x", time.Now().UnixNano())
 	}
+	b[6] = (b[6] & 0x0f) | 0x40
+	b[8] = (b[8] & 0x3f) | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+}
 
-	switch notifyType {
-	case "webhook":
-		return e.sendWebhook(notifyURL, payload)
-	case "sqs":
-		return e.sendSQS(notifyURL, payload)
-	default:
-		return fmt.Errorf("unsupported notify type: %s", notifyType)
+// eventCodePatterns 展开事件码的所有匹配模式：精确事件码及各级通配前缀
+// 例如 order.created → [order.created, order.*, *]
+func eventCodePatterns(code EventCode) []string {
+	patterns := []string{string(code)}
+	parts := strings.Split(string(code), ".")
+	for i := len(parts) - 1; i > 0; i-- {
+		patterns = append(patterns, strings.Join(parts[:i], ".")+".*")
+	}
+	if string(code) != "*" {
+		patterns = append(patterns, "*")
 	}
+	return patterns
 }
 
-func (e *Endpoint) sendEventNotification(app interfaces.ApplicationInfo, payload EventPayload) {
-	switch app.GetNotifyType() {
-	case "webhook":
-		if app.GetNotifyURL() != "" {
-			if err := e.sendWebhook(app.GetNotifyURL(), payload); err != nil {
-				slog.Error("Failed to send webhook", "url", app.GetNotifyURL(), "error", err)
-			}
+// findSubscribedApplications 查找精确订阅和通配符订阅该事件的应用
+// 同一应用同时匹配多个订阅时只返回一次，避免重复投递
+func findSubscribedApplications(code EventCode) ([]interfaces.ApplicationInfo, error) {
+	var apps []interfaces.ApplicationInfo
+	seen := make(map[string]bool)
+
+	for _, pattern := range eventCodePatterns(code) {
+		subscriptions, err := eventRepo.FindByEventCode(pattern)
+		if err != nil {
+			return nil, err
 		}
-	case "sqs":
-		if app.GetNotifyURL() != "" {
-			if err := e.sendSQS(app.GetNotifyURL(), payload); err != nil {
-				slog.Error("Failed to send SQS notification", "url", app.GetNotifyURL(), "error", err)
+
+		for _, sub := range subscriptions {
+			app := sub.GetApplication()
+			if app == nil || seen[app.GetID()] {
+				continue
 			}
+			seen[app.GetID()] = true
+			apps = append(apps, app)
 		}
-	default:
-		slog.Warn("Unknown notify type", "type", app.GetNotifyType(), "appId", app.GetID())
 	}
+
+	return apps, nil
 }
 
-func (e *Endpoint) sendWebhook(url string, payload EventPayload) error {
-	jsonData, err := json.Marshal(payload)
+// DeliveryResult 单个订阅应用的事件投递结果
+type DeliveryResult struct {
+	EventID       string `json:"event_id"`
+	ApplicationID string `json:"application_id"`
+	NotifyType    string `json:"notify_type"`
+	Success       bool   `json:"success"`
+	StatusCode    int    `json:"status_code,omitempty"` // 仅webhook有效
+	Error         error  `json:"-"`
+}
+
+// 同步投递的并发数与整体超时
+var (
+	SyncEmitConcurrency = 10
+	SyncEmitTimeout     = 30 * time.Second
+)
+
+// EmitEventSync 同步发送事件通知，等待所有订阅应用投递完成并返回每个应用的投递结果
+// 并发数受 SyncEmitConcurrency 限制，整体耗时受 SyncEmitTimeout 限制
+// 事件订阅仓储未初始化时与 EmitEvent 一致，按 SetAllowMissingEventRepository 返回错误或空结果
+func (e *Endpoint) EmitEventSync(code EventCode, data interface{}) ([]DeliveryResult, error) {
+	if ready, err := e.checkEventRepo("event_code", code); !ready {
+		return nil, err
+	}
+
+	apps, err := findSubscribedApplications(code)
 	if err != nil {
-		return err
+		e.log().Error("Failed to find event subscriptions", "event_code", code, "error", err)
+		return nil, err
+	}
+
+	payload := EventPayload{
+		EventID:   newEventID(),
+		EventCode: code,
+		Data:      data,
+		Timestamp: time.Now().Unix(),
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), SyncEmitTimeout)
+	defer cancel()
+
+	concurrency := SyncEmitConcurrency
+	if concurrency <= 0 {
+		concurrency = 1
+	}
+	sem := make(chan struct{}, concurrency)
+	results := make([]DeliveryResult, len(apps))
+	var wg sync.WaitGroup
+
+	for i, app := range apps {
+		results[i] = DeliveryResult{
+			EventID:       payload.EventID,
+			ApplicationID: app.GetID(),
+			NotifyType:    app.GetNotifyType(),
+		}
+
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+			results[i].Error = ctx.Err()
+			continue
+		}
+
+		wg.Add(1)
+		go func(i int, app interfaces.ApplicationInfo) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			results[i].StatusCode, results[i].Error = e.deliverWithBreaker(ctx, app, payload)
+			results[i].Success = results[i].Error == nil
+			e.recordDelivery(app, payload, results[i].StatusCode, results[i].Error)
+		}(i, app)
+	}
+
+	wg.Wait()
+	return results, nil
+}
+
+// deliverEventNotification 按应用的通知配置投递事件，返回HTTP状态码（仅webhook）和错误
+func (e *Endpoint) deliverEventNotification(ctx context.Context, app interfaces.ApplicationInfo, payload EventPayload) (int, error) {
+	if app.GetNotifyURL() == "" {
+		return 0, fmt.Errorf("notify URL is empty")
+	}
+
+	outcome, err := e.deliver(ctx, app.GetNotifyType(), app.GetNotifyURL(), notifyHeaders(app), payload)
+	return outcome.StatusCode, err
+}
+
+// deliveryOutcome 单次投递的结果
+type deliveryOutcome struct {
+	StatusCode   int    // 仅webhook有效
+	ResponseBody []byte // webhook响应体的开头部分
+	MessageID    string // SQS/SNS 返回的消息ID
+}
+
+// deliver 按通知类型查找已注册的投递通道执行一次投递，正式投递与测试投递共用此路径
+func (e *Endpoint) deliver(ctx context.Context, notifyType, notifyURL string, headers map[string]string, payload EventPayload) (deliveryOutcome, error) {
+	notifier, err := getNotifier(notifyType)
+	if err != nil {
+		return deliveryOutcome{}, err
+	}
+
+	if n, ok := notifier.(endpointNotifier); ok {
+		return n.deliver(e, ctx, notifyURL, headers, payload)
 	}
+	return deliveryOutcome{}, notifier.Send(notifyURL, payload)
+}
 
-	client := &http.Client{
-		Timeout: 30 * time.Second,
+// deliverWithBreaker 经过应用熔断器投递事件；熔断期间不投递，事件转入死信并返回 ErrCircuitOpen
+func (e *Endpoint) deliverWithBreaker(ctx context.Context, app interfaces.ApplicationInfo, payload EventPayload) (int, error) {
+	if !breaker.allow(app.GetID()) {
+		breaker.deadLetter(e.log(), app, payload)
+		return 0, ErrCircuitOpen
 	}
 
-	resp, err := client.Post(url, "application/json", bytes.NewBuffer(jsonData))
+	statusCode, err := e.deliverEventNotification(ctx, app, payload)
+	breaker.record(e.log(), app.GetID(), err)
+	return statusCode, err
+}
+
+// SendTestNotification 发送测试通知到指定的URL
+// 这是一个公开接口，供控制器直接调用来测试通知配置
+func (e *Endpoint) SendTestNotification(notifyType, notifyURL string, payload EventPayload) error {
+	result, err := e.SendTestDelivery(nil, notifyType, notifyURL, payload)
 	if err != nil {
 		return err
 	}
-	defer resp.Body.Close()
+	if !result.Success {
+		return errors.New(result.Error)
+	}
+	return nil
+}
+
+// SendTestDelivery 以与正式投递相同的路径发送一次测试通知（不经过熔断器，不重试）
+// app 不为nil时附带应用的自定义请求头；投递失败记录在返回结果中，仅参数无效时返回错误
+func (e *Endpoint) SendTestDelivery(app interfaces.ApplicationInfo, notifyType, notifyURL string, payload EventPayload) (*interfaces.TestDeliveryResult, error) {
+	if notifyType == "" || notifyURL == "" {
+		return nil, fmt.Errorf("notify type and URL cannot be empty")
+	}
+	if payload.EventID == "" {
+		payload.EventID = newEventID()
+	}
+
+	var headers map[string]string
+	if app != nil {
+		headers = notifyHeaders(app)
+	}
+
+	start := time.Now()
+	outcome, err := e.deliver(context.Background(), notifyType, notifyURL, headers, payload)
+	result := &interfaces.TestDeliveryResult{
+		EventID:      payload.EventID,
+		NotifyType:   notifyType,
+		TargetURL:    notifyURL,
+		Success:      err == nil,
+		StatusCode:   outcome.StatusCode,
+		ResponseBody: string(outcome.ResponseBody),
+		MessageID:    outcome.MessageID,
+		DurationMs:   time.Since(start).Milliseconds(),
+	}
+	if err != nil {
+		result.Error = err.Error()
+	}
+	return result, nil
+}
 
-	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		slog.Warn("Webhook returned non-success status", "statusCode", resp.StatusCode)
+func (e *Endpoint) sendEventNotification(ctx context.Context, app interfaces.ApplicationInfo, payload EventPayload) {
+	if app.GetNotifyURL() == "" {
+		return
+	}
+	if err := ctx.Err(); err != nil {
+		e.log().Warn("Event notification cancelled before delivery", deliveryLogAttrs(app, payload, 0, err)...)
+		return
+	}
+
+	statusCode, err := e.deliverWithBreaker(ctx, app, payload)
+	e.recordDelivery(app, payload, statusCode, err)
+}
+
+// deliveryLogAttrs 投递日志的结构化字段
+func deliveryLogAttrs(app interfaces.ApplicationInfo, payload EventPayload, statusCode int, deliveryErr error) []any {
+	attrs := []any{
+		"event_code", payload.EventCode,
+		"event_id", payload.EventID,
+		"app_id", app.GetID(),
+		"notify_type", app.GetNotifyType(),
+		"url", app.GetNotifyURL(),
+		"status", statusCode,
+		"attempt", 1,
+	}
+	if deliveryErr != nil {
+		attrs = append(attrs, "error", deliveryErr)
+	}
+	return attrs
+}
+
+// recordDelivery 记录投递日志，并通过投递记录仓储保存投递结果，未设置仓储时只记录日志
+func (e *Endpoint) recordDelivery(app interfaces.ApplicationInfo, payload EventPayload, statusCode int, deliveryErr error) {
+	if deliveryErr != nil {
+		e.log().Error("Failed to send event notification", deliveryLogAttrs(app, payload, statusCode, deliveryErr)...)
+	} else {
+		e.log().Info("Event notification delivered", deliveryLogAttrs(app, payload, statusCode, nil)...)
 	}
 
+	if deliveryRepo == nil {
+		return
+	}
+
+	record := interfaces.DeliveryRecord{
+		ApplicationID: app.GetID(),
+		EventID:       payload.EventID,
+		EventCode:     string(payload.EventCode),
+		NotifyType:    app.GetNotifyType(),
+		TargetURL:     app.GetNotifyURL(),
+		StatusCode:    statusCode,
+		Attempts:      1,
+		Success:       deliveryErr == nil,
+		Timestamp:     time.Now(),
+	}
+	if deliveryErr != nil {
+		record.Error = deliveryErr.Error()
+	}
+
+	if err := deliveryRepo.SaveDelivery(record); err != nil {
+		e.log().Error("Failed to save delivery record", "app_id", record.ApplicationID, "event_code", record.EventCode, "event_id", record.EventID, "error", err)
+	}
+}
+
+// DefaultWebhookTimeout 单次webhook请求的默认超时
+var DefaultWebhookTimeout = 30 * time.Second
+
+// defaultWebhookClient 共享的webhook客户端，复用连接池
+// NotifyURLPolicy 开启 BlockPrivateNetworks 时，连接时检查实际解析到的IP，重定向目标重新按策略校验，未开启时不做检查；
+// 不使用环境变量中的代理，否则连接检查的是代理地址而不是webhook地址
+var defaultWebhookClient = &http.Client{
+	Transport: &http.Transport{
+		DialContext: (&net.Dialer{
+			Timeout:   30 * time.Second,
+			KeepAlive: 30 * time.Second,
+			Control:   dialControl,
+		}).DialContext,
+		MaxIdleConns:          100,
+		MaxIdleConnsPerHost:   10,
+		IdleConnTimeout:       90 * time.Second,
+		TLSHandshakeTimeout:   10 * time.Second,
+		ExpectContinueTimeout: 1 * time.Second,
+	},
+	CheckRedirect: checkWebhookRedirect,
+}
+
+// SetHTTPClient 设置webhook投递使用的HTTP客户端，可用于配置TLS（如mTLS）、代理等
+// 自定义客户端不包含默认客户端的连接地址和重定向检查，需要自行防范SSRF
+// 传入nil时恢复使用共享的默认客户端
+func (e *Endpoint) SetHTTPClient(client *http.Client) {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	e.httpClient = client
+}
+
+// SetWebhookTimeout 设置单次webhook请求的超时，传入0时使用 DefaultWebhookTimeout
+func (e *Endpoint) SetWebhookTimeout(timeout time.Duration) {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	e.webhookTimeout = timeout
+}
+
+// SetLogger 设置事件投递使用的日志记录器，传入nil时使用 slog.Default()
+func (e *Endpoint) SetLogger(logger *slog.Logger) {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	e.logger = logger
+}
+
+// log 返回事件投递使用的日志记录器
+func (e *Endpoint) log() *slog.Logger {
+	e.mutex.RLock()
+	defer e.mutex.RUnlock()
+
+	if e.logger == nil {
+		return slog.Default()
+	}
+	return e.logger
+}
+
+// webhookClient 返回webhook客户端及超时配置
+func (e *Endpoint) webhookClient() (*http.Client, time.Duration) {
+	e.mutex.RLock()
+	defer e.mutex.RUnlock()
+
+	client := e.httpClient
+	if client == nil {
+		client = defaultWebhookClient
+	}
+	timeout := e.webhookTimeout
+	if timeout <= 0 {
+		timeout = DefaultWebhookTimeout
+	}
+	return client, timeout
+}
+
+// reservedWebhookHeaders 由投递逻辑设置的请求头，应用自定义请求头不能覆盖
+var reservedWebhookHeaders = []string{"Content-Type", "Content-Length", "X-Aira-Event-Id", "X-Aira-Signature"}
+
+// notifyHeaders 获取应用配置的webhook自定义请求头，应用未实现 NotifyHeadersProvider 时返回nil
+func notifyHeaders(app interfaces.ApplicationInfo) map[string]string {
+	if provider, ok := app.(interfaces.NotifyHeadersProvider); ok {
+		return provider.GetNotifyHeaders()
+	}
 	return nil
 }
 
-func (e *Endpoint) sendSQS(sqsURL string, payload EventPayload) error {
+// webhookResponseSnippetSize 保留的webhook响应体长度上限
+const webhookResponseSnippetSize = 1024
+
+// postWebhook 以POST方式发送payload，返回响应状态码及响应体的开头部分
+// headers 为附加的自定义请求头，保留请求头会被忽略
+func (e *Endpoint) postWebhook(ctx context.Context, url string, headers map[string]string, payload EventPayload) (int, []byte, error) {
+	return e.postWebhookBody(ctx, url, headers, payload.EventID, payload)
+}
+
+// postWebhookBody 以POST方式发送JSON请求体，eventID 不为空时设置事件ID请求头
+func (e *Endpoint) postWebhookBody(ctx context.Context, url string, headers map[string]string, eventID string, body interface{}) (int, []byte, error) {
+	jsonData, err := json.Marshal(body)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	client, timeout := e.webhookClient()
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(jsonData))
+	if err != nil {
+		return 0, nil, err
+	}
+	for name, value := range headers {
+		if slices.ContainsFunc(reservedWebhookHeaders, func(reserved string) bool {
+			return strings.EqualFold(reserved, name)
+		}) {
+			continue
+		}
+		if strings.EqualFold(name, "Host") {
+			req.Host = value
+			continue
+		}
+		req.Header.Set(name, value)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if eventID != "" {
+		req.Header.Set("X-Aira-Event-Id", eventID)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, nil, err
+	}
+	defer resp.Body.Close()
+
+	snippet, _ := io.ReadAll(io.LimitReader(resp.Body, webhookResponseSnippetSize))
+	// 读完响应体以便连接回到连接池复用
+	io.Copy(io.Discard, resp.Body)
+
+	return resp.StatusCode, snippet, nil
+}
+
+// sendSQS 发送消息到SQS队列，返回消息ID
+func (e *Endpoint) sendSQS(ctx context.Context, sqsURL string, payload EventPayload) (string, error) {
 	// 将payload编码为JSON
 	jsonData, err := json.Marshal(payload)
 	if err != nil {
-		return fmt.Errorf("failed to marshal payload: %v", err)
+		return "", fmt.Errorf("failed to marshal payload: %v", err)
 	}
 
-	// 创建AWS配置
-	cfg, err := config.LoadDefaultConfig(context.TODO())
+	// 获取SQS客户端，AWS配置加载失败时回退到日志记录（配置只加载一次）
+	sqsClient, err := e.sqsAPI()
 	if err != nil {
-		slog.Warn("Failed to load AWS config, falling back to mock", "error", err)
-		// 如果AWS配置失败，回退到日志记录
-		slog.Info("SQS notification sent", "url", sqsURL, "payload", string(jsonData))
-		return nil
+		e.log().Info("SQS notification sent", "notify_type", "sqs", "url", sqsURL, "event_code", payload.EventCode, "payload", string(jsonData))
+		return "", nil
 	}
 
-	// 创建SQS客户端
-	sqsClient := sqs.NewFromConfig(cfg)
-
 	// 发送消息到SQS队列
-	_, err = sqsClient.SendMessage(context.TODO(), &sqs.SendMessageInput{
-		QueueUrl:    aws.String(sqsURL),
-		MessageBody: aws.String(string(jsonData)),
-		MessageAttributes: map[string]types.MessageAttributeValue{
-			"EventCode": {
-				DataType:    aws.String("String"),
-				StringValue: aws.String(string(payload.EventCode)),
-			},
-			"Source": {
-				DataType:    aws.String("String"),
-				StringValue: aws.String("project-platform"),
-			},
-		},
+	output, err := sqsClient.SendMessage(ctx, &sqs.SendMessageInput{
+		QueueUrl:          aws.String(sqsURL),
+		MessageBody:       aws.String(string(jsonData)),
+		MessageAttributes: sqsMessageAttributes(payload),
 	})
 
 	if err != nil {
-		return fmt.Errorf("failed to send SQS message: %v", err)
+		return "", fmt.Errorf("failed to send SQS message: %v", err)
 	}
 
-	slog.Info("SQS notification successfully sent", "url", sqsURL)
-	return nil
+	e.log().Debug("SQS notification successfully sent", "notify_type", "sqs", "url", sqsURL, "event_code", payload.EventCode, "event_id", payload.EventID)
+	return aws.ToString(output.MessageId), nil
+}
+
+// sendSNS 发布消息到SNS主题，返回消息ID
+func (e *Endpoint) sendSNS(ctx context.Context, topicArn string, payload EventPayload) (string, error) {
+	// 校验Topic ARN格式
+	if !strings.HasPrefix(topicArn, "arn:aws:sns:") {
+		return "", fmt.Errorf("invalid SNS topic ARN: %s", topicArn)
+	}
+
+	// 将payload编码为JSON
+	jsonData, err := json.Marshal(payload)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal payload: %v", err)
+	}
+
+	// 获取SNS客户端
+	snsClient, err := e.snsAPI()
+	if err != nil {
+		return "", fmt.Errorf("failed to load AWS config: %v", err)
+	}
+
+	// 发布消息到SNS主题
+	output, err := snsClient.Publish(ctx, &sns.PublishInput{
+		TopicArn:          aws.String(topicArn),
+		Message:           aws.String(string(jsonData)),
+		MessageAttributes: snsMessageAttributes(payload),
+	})
+
+	if err != nil {
+		return "", fmt.Errorf("failed to publish SNS message: %v", err)
+	}
+
+	e.log().Debug("SNS notification successfully sent", "notify_type", "sns", "url", topicArn, "event_code", payload.EventCode, "event_id", payload.EventID)
+	return aws.ToString(output.MessageId), nil
+}
+
+// maxMessageAttributes SQS/SNS(投递到SQS订阅时) 单条消息最多允许10个消息属性，
+// 其中 EventId、EventCode、Source 固定占用3个，元数据最多映射7个，超出部分只在消息体中出现
+const maxMessageAttributes = 10
+
+// messageAttribute 与具体SDK类型无关的消息属性
+type messageAttribute struct {
+	DataType string
+	Value    string
+}
+
+// eventMessageAttributes 生成事件的消息属性
+// 元数据按键名排序后依次映射：字符串映射为 String，数值映射为 Number，其他类型及与固定属性重名、
+// 名称不合法（含非字母数字及 -_. 以外字符、以 AWS. 或 Amazon. 开头）的键被忽略
+func eventMessageAttributes(payload EventPayload) map[string]messageAttribute {
+	attrs := map[string]messageAttribute{
+		"EventId":   {DataType: "String", Value: payload.EventID},
+		"EventCode": {DataType: "String", Value: string(payload.EventCode)},
+		"Source":    {DataType: "String", Value: "project-platform"},
+	}
+
+	keys := make([]string, 0, len(payload.Metadata))
+	for key := range payload.Metadata {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+
+	for _, key := range keys {
+		if len(attrs) >= maxMessageAttributes {
+			break
+		}
+		if _, exists := attrs[key]; exists || !validMessageAttributeName(key) {
+			continue
+		}
+
+		switch v := payload.Metadata[key].(type) {
+		case string:
+			if v != "" {
+				attrs[key] = messageAttribute{DataType: "String", Value: v}
+			}
+		case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, float32, float64, json.Number:
+			attrs[key] = messageAttribute{DataType: "Number", Value: fmt.Sprint(v)}
+		}
+	}
+	return attrs
+}
+
+// validMessageAttributeName 校验消息属性名称是否符合SQS/SNS的要求
+func validMessageAttributeName(name string) bool {
+	if name == "" || len(name) > 256 || strings.HasPrefix(name, ".") || strings.HasSuffix(name, ".") ||
+		strings.Contains(name, "..") {
+		return false
+	}
+	lower := strings.ToLower(name)
+	if strings.HasPrefix(lower, "aws.") || strings.HasPrefix(lower, "amazon.") {
+		return false
+	}
+	for _, r := range name {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '-' || r == '_' || r == '.') {
+			return false
+		}
+	}
+	return true
+}
+
+// sqsMessageAttributes 转换为SQS消息属性
+func sqsMessageAttributes(payload EventPayload) map[string]types.MessageAttributeValue {
+	attrs := eventMessageAttributes(payload)
+	result := make(map[string]types.MessageAttributeValue, len(attrs))
+	for name, attr := range attrs {
+		result[name] = types.MessageAttributeValue{
+			DataType:    aws.String(attr.DataType),
+			StringValue: aws.String(attr.Value),
+		}
+	}
+	return result
+}
+
+// snsMessageAttributes 转换为SNS消息属性
+func snsMessageAttributes(payload EventPayload) map[string]snstypes.MessageAttributeValue {
+	attrs := eventMessageAttributes(payload)
+	result := make(map[string]snstypes.MessageAttributeValue, len(attrs))
+	for name, attr := range attrs {
+		result[name] = snstypes.MessageAttributeValue{
+			DataType:    aws.String(attr.DataType),
+			StringValue: aws.String(attr.Value),
+		}
+	}
+	return result
 }
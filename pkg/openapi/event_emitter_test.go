@@ -0,0 +1,88 @@
+package openapi
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestEmitEventSyncHonorsAllowMissingEventRepository(t *testing.T) {
+	if eventRepo != nil {
+		t.Skip("event repository is initialized")
+	}
+	defer SetAllowMissingEventRepository(false)
+	e := newTestEndpoint()
+
+	if _, err := e.EmitEventSync("order.created", nil); !errors.Is(err, ErrEventRepoNotInitialized) {
+		t.Fatalf("error = %v, want ErrEventRepoNotInitialized", err)
+	}
+
+	SetAllowMissingEventRepository(true)
+	results, err := e.EmitEventSync("order.created", nil)
+	if err != nil || len(results) != 0 {
+		t.Fatalf("EmitEventSync = %v, %v, want no results and no error", results, err)
+	}
+	if err := e.EmitEvent("order.created", nil); err != nil {
+		t.Fatalf("EmitEvent error = %v, want nil", err)
+	}
+}
+
+// headerApp 配置了自定义webhook请求头的应用
+type headerApp struct {
+	testApp
+	headers map[string]string
+}
+
+func (a *headerApp) GetNotifyHeaders() map[string]string { return a.headers }
+
+func TestPostWebhookSendsCustomHeaders(t *testing.T) {
+	var received http.Header
+	var receivedHost string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = r.Header.Clone()
+		receivedHost = r.Host
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	e := newTestEndpoint()
+	e.SetHTTPClient(server.Client())
+
+	app := &headerApp{
+		testApp: testApp{id: "app-1", notifyType: "webhook", notifyURL: server.URL},
+		headers: map[string]string{
+			"X-Api-Key":       "key-123",
+			"Host":            "hooks.example.com",
+			"content-type":    "text/plain",
+			"X-Aira-Event-Id": "forged",
+		},
+	}
+
+	payload := EventPayload{EventID: "evt-1", EventCode: "order.created"}
+	statusCode, _, err := e.postWebhook(context.Background(), app.GetNotifyURL(), notifyHeaders(app), payload)
+	if err != nil || statusCode != http.StatusOK {
+		t.Fatalf("postWebhook: status=%d err=%v", statusCode, err)
+	}
+
+	if got := received.Get("X-Api-Key"); got != "key-123" {
+		t.Fatalf("X-Api-Key = %q, want key-123", got)
+	}
+	if receivedHost != "hooks.example.com" {
+		t.Fatalf("Host = %q, want hooks.example.com", receivedHost)
+	}
+	// 保留请求头不能被应用覆盖
+	if got := received.Get("Content-Type"); got != "application/json" {
+		t.Fatalf("Content-Type = %q, want application/json", got)
+	}
+	if got := received.Values("X-Aira-Event-Id"); len(got) != 1 || got[0] != "evt-1" {
+		t.Fatalf("X-Aira-Event-Id = %v, want [evt-1]", got)
+	}
+}
+
+func TestNotifyHeadersDefaultsToNone(t *testing.T) {
+	if headers := notifyHeaders(&testApp{id: "app-1"}); headers != nil {
+		t.Fatalf("notifyHeaders = %v, want nil for apps without custom headers", headers)
+	}
+}
@@ -2,12 +2,15 @@ package openapi
 
 import (
 	"errors"
+	"log/slog"
 	"net/http"
 	"strings"
+	"time"
 
 	"github.com/flaboy/aira-web/pkg/openapi/interfaces"
 
 	"github.com/flaboy/pin"
+	"github.com/flaboy/pin/usererrors"
 	"github.com/gin-gonic/gin"
 )
 
@@ -21,12 +24,67 @@ func HandleRequest(c *pin.Context) error {
 		return nil
 	}
 
-	// 检查认证
+	// 检查认证，失败时立即中断，处理器不会被调用
 	if err := endpoint.checkAuth(c); err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return nil
+	}
+
+	err := endpoint.HandleApiRequest(c)
+	recordUsage(c, endpoint, err)
+	if err != nil && c.Writer.Written() {
+		// 处理器已写出响应，不再渲染错误，避免写出第二个响应体
+		slog.Error("openapi handler returned error after writing response",
+			"endpoint", endpointName, "path", c.Param("path"), "error", err)
+		return nil
 	}
+	applyErrorStatus(c, err)
+	return err
+}
+
+// ErrorStatusCodes 错误码到HTTP状态码的映射，HandleRequest 返回这些错误时使用对应的状态码渲染
+// 可通过 RegisterErrorStatus 扩展
+var ErrorStatusCodes = map[string]int{
+	"endpoint_not_found":   http.StatusNotFound,
+	"invalid_request":      http.StatusBadRequest,
+	"invalid_request_type": http.StatusBadRequest,
+	"validation_failed":    http.StatusBadRequest,
+	"unauthorized":         http.StatusUnauthorized,
+	"forbidden":            http.StatusForbidden,
+	"rate_limited":         http.StatusTooManyRequests,
+}
 
-	return endpoint.HandleApiRequest(c)
+// RegisterErrorStatus 注册错误码对应的HTTP状态码，应在初始化阶段调用
+func RegisterErrorStatus(code string, status int) {
+	ErrorStatusCodes[code] = status
+}
+
+// applyErrorStatus 按错误码设置 pin 渲染用户错误时使用的HTTP状态码
+func applyErrorStatus(c *pin.Context, err error) {
+	var userErr *usererrors.Error
+	if !errors.As(err, &userErr) {
+		return
+	}
+	if status, ok := ErrorStatusCodes[userErr.Code()]; ok {
+		c.Set("pin.error_code.user", status)
+	}
+}
+
+// recordUsage 仓储实现 UsageRecorder 时异步记录本次调用，处理器返回错误或响应状态码>=400时视为失败
+func recordUsage(c *pin.Context, endpoint *Endpoint, err error) {
+	recorder, ok := appRepo.(interfaces.UsageRecorder)
+	if !ok {
+		return
+	}
+
+	appID := c.GetString("application_id")
+	success := err == nil && c.Writer.Status() < http.StatusBadRequest
+	at := time.Now()
+	go func() {
+		if err := recorder.RecordUsage(appID, endpoint.Name, success, at); err != nil {
+			slog.Warn("Failed to record API usage", "appId", appID, "error", err)
+		}
+	}()
 }
 
 func (e *Endpoint) checkAuth(c *pin.Context) error {
@@ -41,22 +99,46 @@ func (e *Endpoint) checkAuth(c *pin.Context) error {
 		return errors.New("missing authorization header")
 	}
 
-	// 只支持Basic Authentication
+	var app interfaces.ApplicationInfo
+	var err error
+
+	switch {
+	// Basic Authentication
 	// Authorization: Basic base64(client_id:client_secret)
-	if !strings.HasPrefix(authHeader, "Basic ") {
-		return errors.New("only basic authentication is supported")
-	}
+	case strings.HasPrefix(authHeader, "Basic "):
+		// 使用Gin的内置解析Basic Auth
+		clientID, clientSecret, ok := c.Request.BasicAuth()
+		if !ok {
+			return errors.New("invalid basic auth format")
+		}
 
-	// 使用Gin的内置解析Basic Auth
-	clientID, clientSecret, ok := c.Request.BasicAuth()
-	if !ok {
-		return errors.New("invalid basic auth format")
-	}
+		// 验证应用是否存在且状态为active
+		app, err = appRepo.FindByCredentials(clientID, clientSecret, e.Name, "active")
+		if err != nil {
+			return errors.New("invalid credentials or inactive application")
+		}
+
+	// Bearer Token Authentication
+	// Authorization: Bearer <token>
+	case strings.HasPrefix(authHeader, "Bearer "):
+		tokenRepo, ok := appRepo.(interfaces.TokenApplicationRepository)
+		if !ok {
+			return errors.New("bearer authentication is not supported")
+		}
+
+		token := strings.TrimSpace(strings.TrimPrefix(authHeader, "Bearer "))
+		if token == "" {
+			return errors.New("invalid bearer token format")
+		}
+
+		// 验证应用是否存在且状态为active
+		app, err = tokenRepo.FindByToken(token, e.Name, "active")
+		if err != nil {
+			return errors.New("invalid token or inactive application")
+		}
 
-	// 验证应用是否存在且状态为active
-	app, err := appRepo.FindByCredentials(clientID, clientSecret, e.Name, "active")
-	if err != nil {
-		return errors.New("invalid credentials or inactive application")
+	default:
+		return errors.New("only basic and bearer authentication are supported")
 	}
 
 	// 更新最后使用时间（异步执行，避免阻塞请求）
@@ -0,0 +1,85 @@
+package openapi
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/flaboy/aira-web/pkg/openapi/interfaces"
+
+	"github.com/flaboy/pin"
+	"github.com/flaboy/pin/usererrors"
+	"github.com/gin-gonic/gin"
+)
+
+// credentialRepository 只接受固定凭证的应用仓储
+type credentialRepository struct {
+	clientID, clientSecret string
+}
+
+func (r *credentialRepository) FindByCredentials(clientID, clientSecret string, endpointType interfaces.EndpointType, status string) (interfaces.ApplicationInfo, error) {
+	if clientID != r.clientID || !VerifyClientSecret(clientSecret, r.clientSecret) {
+		return nil, errors.New("not found")
+	}
+	return &testApp{id: "app-1"}, nil
+}
+
+// serveHandleRequest 通过 HandleRequest 处理GET请求，setAuth 用于设置认证请求头
+func serveHandleRequest(endpoint interfaces.EndpointType, path string, setAuth func(*http.Request)) (*httptest.ResponseRecorder, error) {
+	gin.SetMode(gin.TestMode)
+	w := httptest.NewRecorder()
+	ginCtx, _ := gin.CreateTestContext(w)
+	ginCtx.Request = httptest.NewRequest(http.MethodGet, "/openapi/"+string(endpoint)+"/"+path, nil)
+	if setAuth != nil {
+		setAuth(ginCtx.Request)
+	}
+	ginCtx.Params = gin.Params{{Key: "endpoint", Value: string(endpoint)}, {Key: "path", Value: "/" + path}}
+	return w, HandleRequest(&pin.Context{Context: ginCtx})
+}
+
+func TestHandleRequestRejectsUnauthenticated(t *testing.T) {
+	previous := appRepo
+	SetApplicationRepository(&credentialRepository{clientID: "client", clientSecret: "secret"})
+	defer SetApplicationRepository(previous)
+
+	endpoint := interfaces.EndpointType("test-" + t.Name())
+	calls := 0
+	RegisterGetApi(endpoint, "protected", func(c *pin.Context) (*itemResponse, *usererrors.Error) {
+		calls++
+		return &itemResponse{ID: "1"}, nil
+	}, "Protected")
+
+	tests := []struct {
+		name    string
+		setAuth func(*http.Request)
+	}{
+		{"missing header", nil},
+		{"wrong secret", func(r *http.Request) { r.SetBasicAuth("client", "wrong") }},
+		{"unsupported scheme", func(r *http.Request) { r.Header.Set("Authorization", "Digest abc") }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w, err := serveHandleRequest(endpoint, "protected", tt.setAuth)
+			if err != nil {
+				t.Fatalf("HandleRequest returned %v after writing 401", err)
+			}
+			if w.Code != http.StatusUnauthorized {
+				t.Fatalf("status = %d, want 401", w.Code)
+			}
+			if calls != 0 {
+				t.Fatalf("handler ran %d times for an unauthenticated request", calls)
+			}
+			// 只写出一个响应体
+			if strings.Count(w.Body.String(), `"error"`) != 1 || strings.Contains(w.Body.String(), `"data"`) {
+				t.Fatalf("unexpected response body %q", w.Body.String())
+			}
+		})
+	}
+
+	w, err := serveHandleRequest(endpoint, "protected", func(r *http.Request) { r.SetBasicAuth("client", "secret") })
+	if err != nil || w.Code != http.StatusOK || calls != 1 {
+		t.Fatalf("authenticated request: err=%v status=%d calls=%d", err, w.Code, calls)
+	}
+}
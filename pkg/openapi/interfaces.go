@@ -6,6 +6,9 @@ import "github.com/flaboy/aira-web/pkg/openapi/interfaces"
 var (
 	appRepo   interfaces.ApplicationRepository
 	eventRepo interfaces.EventSubscriptionRepository
+	limiter   interfaces.RateLimiter
+
+	deliveryRepo interfaces.DeliveryRecordRepository
 )
 
 // SetApplicationRepository 设置应用仓储
@@ -18,6 +21,16 @@ func SetEventSubscriptionRepository(repo interfaces.EventSubscriptionRepository)
 	eventRepo = repo
 }
 
+// SetRateLimiter 设置API请求限流器，传入nil表示不限流
+func SetRateLimiter(l interfaces.RateLimiter) {
+	limiter = l
+}
+
+// SetDeliveryRecordRepository 设置事件投递记录仓储，未设置时不记录投递结果
+func SetDeliveryRecordRepository(repo interfaces.DeliveryRecordRepository) {
+	deliveryRepo = repo
+}
+
 // GetApplicationRepository 获取应用仓储
 func GetApplicationRepository() interfaces.ApplicationRepository {
 	return appRepo
@@ -27,3 +40,13 @@ func GetApplicationRepository() interfaces.ApplicationRepository {
 func GetEventSubscriptionRepository() interfaces.EventSubscriptionRepository {
 	return eventRepo
 }
+
+// GetRateLimiter 获取API请求限流器
+func GetRateLimiter() interfaces.RateLimiter {
+	return limiter
+}
+
+// GetDeliveryRecordRepository 获取事件投递记录仓储
+func GetDeliveryRecordRepository() interfaces.DeliveryRecordRepository {
+	return deliveryRepo
+}
@@ -1,12 +1,23 @@
 package interfaces
 
+import (
+	"errors"
+	"time"
+)
+
+// ErrDuplicateApplicationName 同一用户下已存在同名应用
+// CreateApplication / UpdateApplication 的实现发现重名时应返回该错误（可包装），以便返回字段级错误
+var ErrDuplicateApplicationName = errors.New("application name already exists")
+
 // DeveloperService 开发者功能服务接口
 type DeveloperService interface {
 	// 应用管理
-	GetApplications(userID uint) ([]ApplicationInfo, error)
+	GetApplications(userID uint, query ApplicationQuery) ([]ApplicationInfo, int64, error)
 	GetApplication(appID string, userID uint) (ApplicationInfo, error)
 	CreateApplication(userID uint, name, description string) (ApplicationInfo, error)
 	UpdateApplication(appID string, userID uint, name, description, status string) (ApplicationInfo, error)
+	// PatchApplication 部分更新应用，只修改 patch 中非nil的字段
+	PatchApplication(appID string, userID uint, patch ApplicationPatch) (ApplicationInfo, error)
 	DeleteApplication(appID string, userID uint) error
 	RegenerateSecret(appID string, userID uint) (ApplicationInfo, error)
 
@@ -19,11 +30,59 @@ type DeveloperService interface {
 	SubscribeEvent(appID string, userID uint, eventCode string) (EventSubscriptionInfo, error)
 	UnsubscribeEvent(appID string, userID uint, eventCode string) error
 
+	// 投递记录
+	GetDeliveryHistory(appID string, userID uint, query DeliveryQuery) ([]DeliveryRecord, int64, error)
+
+	// 调用量统计，window 为统计的时间窗口（截至当前）；没有数据时可返回nil
+	GetUsage(appID string, userID uint, window time.Duration) (*UsageStats, error)
+
 	// 文档和配置
 	GetApiDocs() (interface{}, error)
 	GetEventDocs() ([]EventInfo, error)
 	GetAWSConfig() (interface{}, error)
-	SendTestEvent(appID string, userID uint, eventCode, notifyType, notifyURL string, testData interface{}) error
+	// SendTestEvent 发送测试事件，实现应通过 Endpoint.SendTestDelivery 投递并返回其结果
+	SendTestEvent(appID string, userID uint, eventCode, notifyType, notifyURL string, testData interface{}) (*TestDeliveryResult, error)
+}
+
+// ApplicationPatch 应用的部分更新内容，nil 表示不修改该字段
+type ApplicationPatch struct {
+	Name        *string `json:"name"`
+	Description *string `json:"description"`
+	Status      *string `json:"status"`
+}
+
+// UsageStats 应用在时间窗口内的API调用统计
+type UsageStats struct {
+	Window       string     `json:"window"`        // 时间窗口，如 24h、7d、30d，由处理器填充
+	RequestCount int64      `json:"request_count"` // 请求总数
+	ErrorCount   int64      `json:"error_count"`   // 失败请求数
+	ErrorRate    float64    `json:"error_rate"`    // 失败率（0~1），由处理器根据计数计算
+	LastUsedAt   *time.Time `json:"last_used_at"`  // 最后调用时间，没有调用时为nil
+}
+
+// ApplicationQuery 应用列表的分页与过滤条件
+type ApplicationQuery struct {
+	Page     int    // 页码，从1开始
+	PageSize int    // 每页数量
+	Status   string // 按状态过滤，为空时不过滤
+	Name     string // 按名称模糊过滤，为空时不过滤
+}
+
+// Offset 返回分页查询的偏移量
+func (q ApplicationQuery) Offset() int {
+	return (q.Page - 1) * q.PageSize
+}
+
+// DeliveryQuery 投递记录的分页与过滤条件
+type DeliveryQuery struct {
+	Page      int    // 页码，从1开始
+	PageSize  int    // 每页数量
+	EventCode string // 按事件码过滤，为空时不过滤
+}
+
+// Offset 返回分页查询的偏移量
+func (q DeliveryQuery) Offset() int {
+	return (q.Page - 1) * q.PageSize
 }
 
 // EventInfo 事件信息接口
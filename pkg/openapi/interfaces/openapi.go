@@ -1,5 +1,7 @@
 package interfaces
 
+import "time"
+
 // ApplicationInfo 应用信息接口
 type ApplicationInfo interface {
 	GetID() string
@@ -11,11 +13,43 @@ type ApplicationInfo interface {
 	UpdateLastUsed() error
 }
 
+// NotifyHeadersProvider 提供webhook自定义请求头的应用信息接口（可选）
+// ApplicationInfo 的实现同时实现此接口时，webhook投递会附带这些请求头；保留请求头不可覆盖
+type NotifyHeadersProvider interface {
+	GetNotifyHeaders() map[string]string
+}
+
+// BatchDeliveryApplication 支持批量投递的应用信息接口（可选）
+// SupportsBatchDelivery 返回 true 时，EmitEvents 将同一应用的多个事件合并投递：
+// webhook 以JSON数组作为请求体，SQS 使用 SendMessageBatch
+type BatchDeliveryApplication interface {
+	SupportsBatchDelivery() bool
+}
+
 // ApplicationRepository 应用仓储接口
 type ApplicationRepository interface {
 	FindByCredentials(clientID, clientSecret string, endpointType EndpointType, status string) (ApplicationInfo, error)
 }
 
+// TokenApplicationRepository 支持Bearer Token认证的应用仓储接口（可选）
+// ApplicationRepository 的实现同时实现此接口时，checkAuth 接受 Authorization: Bearer <token>
+type TokenApplicationRepository interface {
+	FindByToken(token string, endpointType EndpointType, status string) (ApplicationInfo, error)
+}
+
+// UsageRecorder 记录API调用量的应用仓储接口（可选）
+// ApplicationRepository 的实现同时实现此接口时，每次认证通过的请求处理完成后异步调用 RecordUsage，
+// 用于支撑 DeveloperService.GetUsage 的聚合统计
+type UsageRecorder interface {
+	RecordUsage(appID string, endpointType EndpointType, success bool, at time.Time) error
+}
+
+// RateLimiter 按应用限流的接口，可基于内存或Redis等实现
+// Allow 返回是否放行本次请求；拒绝时返回建议的重试等待时间
+type RateLimiter interface {
+	Allow(appID string) (bool, time.Duration)
+}
+
 // EventSubscriptionInfo 事件订阅信息接口
 type EventSubscriptionInfo interface {
 	GetApplicationID() uint
@@ -24,10 +58,45 @@ type EventSubscriptionInfo interface {
 }
 
 // EventSubscriptionRepository 事件订阅仓储接口
+// 订阅可以使用通配符事件码，如 "order.*" 或 "*"，仓储按字面值保存即可；
+// 发送事件时会依次以精确事件码及其各级通配前缀调用 FindByEventCode
 type EventSubscriptionRepository interface {
 	FindByEventCode(eventCode string) ([]EventSubscriptionInfo, error)
 }
 
+// DeliveryRecord 单次事件投递记录
+type DeliveryRecord struct {
+	ApplicationID string    `json:"application_id"`
+	EventID       string    `json:"event_id"`
+	EventCode     string    `json:"event_code"`
+	NotifyType    string    `json:"notify_type"`
+	TargetURL     string    `json:"target_url"`
+	StatusCode    int       `json:"status_code,omitempty"` // 仅webhook有效
+	Attempts      int       `json:"attempts"`
+	Success       bool      `json:"success"`
+	Error         string    `json:"error,omitempty"`
+	Timestamp     time.Time `json:"timestamp"`
+}
+
+// TestDeliveryResult 测试投递的结果，返回给开发者用于确认通知配置
+type TestDeliveryResult struct {
+	EventID      string `json:"event_id"`
+	NotifyType   string `json:"notify_type"`
+	TargetURL    string `json:"target_url"`
+	Success      bool   `json:"success"`
+	StatusCode   int    `json:"status_code,omitempty"`   // 仅webhook有效
+	ResponseBody string `json:"response_body,omitempty"` // webhook响应体的开头部分
+	MessageID    string `json:"message_id,omitempty"`    // 仅SQS/SNS有效
+	DurationMs   int64  `json:"duration_ms"`
+	Error        string `json:"error,omitempty"`
+}
+
+// DeliveryRecordRepository 事件投递记录仓储接口（可选）
+// 未设置时事件发送器不持久化投递结果
+type DeliveryRecordRepository interface {
+	SaveDelivery(record DeliveryRecord) error
+}
+
 type EndpointType string
 
 type NotifyType string
@@ -35,4 +104,5 @@ type NotifyType string
 const (
 	NotifyTypeWebhook NotifyType = "webhook"
 	NotifyTypeSQS     NotifyType = "sqs"
+	NotifyTypeSNS     NotifyType = "sns"
 )
@@ -0,0 +1,196 @@
+package openapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// ToMarkdown renders the documentation as a Markdown reference, one section per tag
+// Tags, endpoints and properties are sorted so the output is stable across regenerations
+func (d *ApiDocumentation) ToMarkdown() string {
+	groups := make(map[string][]ApiEndpoint)
+	for _, api := range d.Apis {
+		tag := "default"
+		if len(api.Tags) > 0 {
+			tag = api.Tags[0]
+		}
+		groups[tag] = append(groups[tag], api)
+	}
+
+	tags := make([]string, 0, len(groups))
+	for tag := range groups {
+		tags = append(tags, tag)
+	}
+	sort.Strings(tags)
+
+	title := d.Info.Title
+	if title == "" {
+		title = "API"
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "# %s Reference\n", title)
+	if d.Info.Version != "" {
+		fmt.Fprintf(&b, "\nVersion: %s\n", d.Info.Version)
+	}
+	if d.Info.Description != "" {
+		fmt.Fprintf(&b, "\n%s\n", d.Info.Description)
+	}
+	for _, server := range d.Servers {
+		if server.Description != "" {
+			fmt.Fprintf(&b, "\n- %s: `%s`", server.Description, server.URL)
+		} else {
+			fmt.Fprintf(&b, "\n- `%s`", server.URL)
+		}
+	}
+	if len(d.Servers) > 0 {
+		b.WriteString("\n")
+	}
+
+	for _, tag := range tags {
+		apis := groups[tag]
+		sort.SliceStable(apis, func(i, j int) bool {
+			if apis[i].Path != apis[j].Path {
+				return apis[i].Path < apis[j].Path
+			}
+			return apis[i].Method < apis[j].Method
+		})
+
+		fmt.Fprintf(&b, "\n## %s\n", tag)
+		for _, api := range apis {
+			writeMarkdownEndpoint(&b, api)
+		}
+	}
+
+	return b.String()
+}
+
+// writeMarkdownEndpoint renders a single endpoint section
+func writeMarkdownEndpoint(b *strings.Builder, api ApiEndpoint) {
+	fmt.Fprintf(b, "\n### %s %s\n\n", api.Method, api.Path)
+	if api.Deprecated {
+		b.WriteString("> **Deprecated**\n\n")
+	}
+	if api.Summary != "" {
+		fmt.Fprintf(b, "%s\n\n", api.Summary)
+	}
+	if api.Description != "" && api.Description != api.Summary {
+		fmt.Fprintf(b, "%s\n\n", api.Description)
+	}
+
+	if len(api.Parameters) > 0 {
+		b.WriteString("#### Parameters\n\n")
+		b.WriteString("| Name | In | Type | Required | Description |\n")
+		b.WriteString("| --- | --- | --- | --- | --- |\n")
+		for _, param := range api.Parameters {
+			fmt.Fprintf(b, "| `%s` | %s | %s | %s | %s |\n",
+				param.Name, param.In, param.Type, markdownYesNo(param.Required), markdownCell(param.Description))
+		}
+		b.WriteString("\n")
+	}
+
+	if api.Request != nil {
+		b.WriteString("#### Request\n\n")
+		writeMarkdownSchema(b, api.Request)
+	}
+
+	if len(api.Responses) > 0 {
+		statuses := make([]string, 0, len(api.Responses))
+		for status := range api.Responses {
+			statuses = append(statuses, status)
+		}
+		sort.Strings(statuses)
+		for _, status := range statuses {
+			fmt.Fprintf(b, "#### Response %s\n\n", status)
+			writeMarkdownSchema(b, api.Responses[status])
+		}
+	} else if api.Response != nil {
+		b.WriteString("#### Response\n\n")
+		writeMarkdownSchema(b, api.Response)
+	}
+
+	if len(api.Errors) > 0 {
+		b.WriteString("#### Errors\n\n")
+		b.WriteString("| Code | Message |\n")
+		b.WriteString("| --- | --- |\n")
+		for _, apiErr := range api.Errors {
+			fmt.Fprintf(b, "| `%s` | %s |\n", apiErr.Code, markdownCell(apiErr.Message))
+		}
+		b.WriteString("\n")
+	}
+}
+
+// writeMarkdownSchema renders a schema as a nested property list followed by its example
+func writeMarkdownSchema(b *strings.Builder, schema *ApiSchema) {
+	if len(schema.Properties) > 0 {
+		writeMarkdownProperties(b, schema.Properties, schema.Required, 0)
+		b.WriteString("\n")
+	} else {
+		fmt.Fprintf(b, "Type: `%s`\n\n", schema.Type)
+	}
+
+	if schema.Example != nil {
+		data, err := json.MarshalIndent(schema.Example, "", "  ")
+		if err == nil {
+			fmt.Fprintf(b, "```json\n%s\n```\n\n", data)
+		}
+	}
+}
+
+// writeMarkdownProperties renders properties sorted by name, indenting nested objects
+func writeMarkdownProperties(b *strings.Builder, properties map[string]ApiProperty, required []string, depth int) {
+	names := make([]string, 0, len(properties))
+	for name := range properties {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	requiredSet := make(map[string]bool, len(required))
+	for _, name := range required {
+		requiredSet[name] = true
+	}
+
+	indent := strings.Repeat("  ", depth)
+	for _, name := range names {
+		prop := properties[name]
+
+		typ := prop.Type
+		if prop.Format != "" {
+			typ += ", " + prop.Format
+		}
+		if prop.Items != nil {
+			typ = "array of " + prop.Items.Type
+		}
+
+		line := fmt.Sprintf("%s- `%s` (%s)", indent, name, typ)
+		if requiredSet[name] || prop.Required {
+			line += " **required**"
+		}
+		if prop.Description != "" {
+			line += ": " + prop.Description
+		}
+		b.WriteString(line + "\n")
+
+		switch {
+		case len(prop.Properties) > 0:
+			writeMarkdownProperties(b, prop.Properties, prop.RequiredFields, depth+1)
+		case prop.Items != nil && len(prop.Items.Properties) > 0:
+			writeMarkdownProperties(b, prop.Items.Properties, prop.Items.RequiredFields, depth+1)
+		}
+	}
+}
+
+func markdownYesNo(v bool) string {
+	if v {
+		return "yes"
+	}
+	return "no"
+}
+
+// markdownCell escapes text for use inside a table cell
+func markdownCell(s string) string {
+	s = strings.ReplaceAll(s, "|", "\\|")
+	return strings.ReplaceAll(s, "\n", " ")
+}
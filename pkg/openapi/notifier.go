@@ -0,0 +1,100 @@
+package openapi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// ErrNotifierNotRegistered 通知类型没有注册对应的投递通道
+var ErrNotifierNotRegistered = errors.New("no notifier registered for notify type")
+
+// Notifier 事件投递通道，通过 RegisterNotifier 按通知类型注册
+// 可用于扩展 Kafka、NATS、Slack 等投递方式
+type Notifier interface {
+	Send(url string, payload EventPayload) error
+}
+
+var (
+	notifiersMutex sync.RWMutex
+	notifiers      = make(map[string]Notifier)
+)
+
+// RegisterNotifier 注册通知类型对应的投递通道，已存在时覆盖，传入nil时移除
+// 内置的 webhook、sqs、sns 通道在包初始化时注册
+func RegisterNotifier(notifyType string, notifier Notifier) {
+	notifiersMutex.Lock()
+	defer notifiersMutex.Unlock()
+
+	if notifier == nil {
+		delete(notifiers, notifyType)
+		return
+	}
+	notifiers[notifyType] = notifier
+}
+
+// getNotifier 获取通知类型对应的投递通道
+func getNotifier(notifyType string) (Notifier, error) {
+	notifiersMutex.RLock()
+	defer notifiersMutex.RUnlock()
+
+	notifier, ok := notifiers[notifyType]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrNotifierNotRegistered, notifyType)
+	}
+	return notifier, nil
+}
+
+// endpointNotifier 内置通道实现的投递接口，
+// 使用 Endpoint 上配置的HTTP客户端和应用自定义请求头，并返回投递详情
+type endpointNotifier interface {
+	deliver(e *Endpoint, ctx context.Context, url string, headers map[string]string, payload EventPayload) (deliveryOutcome, error)
+}
+
+type webhookNotifier struct{}
+
+func (n webhookNotifier) Send(url string, payload EventPayload) error {
+	_, err := n.deliver(standaloneEndpoint, context.Background(), url, nil, payload)
+	return err
+}
+
+func (webhookNotifier) deliver(e *Endpoint, ctx context.Context, url string, headers map[string]string, payload EventPayload) (deliveryOutcome, error) {
+	var outcome deliveryOutcome
+	var err error
+	outcome.StatusCode, outcome.ResponseBody, err = e.postWebhook(ctx, url, headers, payload)
+	if err == nil && (outcome.StatusCode < 200 || outcome.StatusCode >= 300) {
+		err = fmt.Errorf("webhook returned non-success status: %d", outcome.StatusCode)
+	}
+	return outcome, err
+}
+
+type sqsNotifier struct{}
+
+func (sqsNotifier) Send(url string, payload EventPayload) error {
+	_, err := standaloneEndpoint.sendSQS(context.Background(), url, payload)
+	return err
+}
+
+func (sqsNotifier) deliver(e *Endpoint, ctx context.Context, url string, headers map[string]string, payload EventPayload) (deliveryOutcome, error) {
+	messageID, err := e.sendSQS(ctx, url, payload)
+	return deliveryOutcome{MessageID: messageID}, err
+}
+
+type snsNotifier struct{}
+
+func (snsNotifier) Send(url string, payload EventPayload) error {
+	_, err := standaloneEndpoint.sendSNS(context.Background(), url, payload)
+	return err
+}
+
+func (snsNotifier) deliver(e *Endpoint, ctx context.Context, url string, headers map[string]string, payload EventPayload) (deliveryOutcome, error) {
+	messageID, err := e.sendSNS(ctx, url, payload)
+	return deliveryOutcome{MessageID: messageID}, err
+}
+
+func init() {
+	RegisterNotifier("webhook", webhookNotifier{})
+	RegisterNotifier("sqs", sqsNotifier{})
+	RegisterNotifier("sns", snsNotifier{})
+}
@@ -0,0 +1,162 @@
+package openapi
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"regexp"
+	"syscall"
+
+	"github.com/flaboy/pin/usererrors"
+)
+
+// NotifyURLPolicy 通知地址的校验策略
+type NotifyURLPolicy struct {
+	RequireHTTPS         bool // webhook 地址必须使用 https
+	BlockPrivateNetworks bool // 拒绝指向回环、私有、链路本地地址的 webhook 地址，防止SSRF
+}
+
+// DefaultNotifyURLPolicy 默认校验策略
+// 默认不拦截内部网络地址，已有投递到内网订阅方（如 http://10.x、集群内域名、sidecar）的部署升级后不受影响；
+// 开发者可自行填写通知地址的多租户场景应通过 SetNotifyURLPolicy 开启 BlockPrivateNetworks，
+// 开启后保存配置、投递时连接的地址以及重定向目标都会被校验
+var DefaultNotifyURLPolicy = NotifyURLPolicy{
+	RequireHTTPS:         true,
+	BlockPrivateNetworks: false,
+}
+
+var notifyURLPolicy = DefaultNotifyURLPolicy
+
+// SetNotifyURLPolicy 设置通知地址的校验策略，应在初始化阶段调用
+func SetNotifyURLPolicy(policy NotifyURLPolicy) {
+	notifyURLPolicy = policy
+}
+
+// lookupIP 解析主机地址，可在测试中替换
+var lookupIP = net.LookupIP
+
+var (
+	sqsQueueURLPattern = regexp.MustCompile(`^https://(sqs\.[a-z0-9-]+\.amazonaws\.com(\.cn)?|[a-z0-9-]+\.queue\.amazonaws\.com)/\d{12}/[A-Za-z0-9_-]{1,80}(\.fifo)?$`)
+	snsTopicARNPattern = regexp.MustCompile(`^arn:aws(-cn|-us-gov)?:sns:[a-z0-9-]+:\d{12}:[A-Za-z0-9_-]{1,256}(\.fifo)?$`)
+)
+
+// ValidateNotifyConfig 按当前策略校验通知类型和地址，每种失败原因返回不同的错误码
+// DeveloperService 的实现在保存通知配置前也可以直接调用
+func ValidateNotifyConfig(notifyType, notifyURL string) *usererrors.Error {
+	switch notifyType {
+	case "webhook":
+		return validateWebhookURL(notifyURL, notifyURLPolicy)
+	case "sqs":
+		if !sqsQueueURLPattern.MatchString(notifyURL) {
+			return usererrors.New("invalid_sqs_queue_url", "SQS notify URL must be a queue URL like https://sqs.<region>.amazonaws.com/<account>/<queue>")
+		}
+		return nil
+	case "sns":
+		if !snsTopicARNPattern.MatchString(notifyURL) {
+			return usererrors.New("invalid_sns_topic_arn", "SNS notify URL must be a topic ARN like arn:aws:sns:<region>:<account>:<topic>")
+		}
+		return nil
+	default:
+		// 自定义通道的地址格式由通道自行约定
+		if _, err := getNotifier(notifyType); err == nil {
+			return nil
+		}
+		return usererrors.New("invalid_notify_type", "Unsupported notify type: "+notifyType)
+	}
+}
+
+// validateWebhookURL 校验webhook地址的格式、协议以及是否指向内部网络
+func validateWebhookURL(notifyURL string, policy NotifyURLPolicy) *usererrors.Error {
+	u, err := url.Parse(notifyURL)
+	if err != nil || u.Host == "" || (u.Scheme != "http" && u.Scheme != "https") {
+		return usererrors.New("invalid_notify_url", "Notify URL must be an absolute http(s) URL")
+	}
+	if policy.RequireHTTPS && u.Scheme != "https" {
+		return usererrors.New("notify_url_https_required", "Webhook notify URL must use https")
+	}
+	if !policy.BlockPrivateNetworks {
+		return nil
+	}
+
+	host := u.Hostname()
+	ips := []net.IP{net.ParseIP(host)}
+	if ips[0] == nil {
+		ips, err = lookupIP(host)
+		if err != nil || len(ips) == 0 {
+			return usererrors.New("notify_url_unresolvable", "Notify URL host cannot be resolved: "+host)
+		}
+	}
+
+	for _, ip := range ips {
+		if isInternalIP(ip) {
+			return usererrors.New("notify_url_private_network", "Notify URL must not point to a private, loopback or link-local address")
+		}
+	}
+	return nil
+}
+
+// nonRoutableNetworks net.IP 没有对应判断方法的内部网段
+var nonRoutableNetworks = []*net.IPNet{
+	mustParseCIDR("0.0.0.0/8"),     // 本网络，部分系统上等同于本机
+	mustParseCIDR("100.64.0.0/10"), // 运营商级NAT共享地址，常见于云厂商内网
+}
+
+func mustParseCIDR(cidr string) *net.IPNet {
+	_, network, err := net.ParseCIDR(cidr)
+	if err != nil {
+		panic(err)
+	}
+	return network
+}
+
+// isInternalIP 判断是否为回环、私有、链路本地、共享地址或未指定地址
+func isInternalIP(ip net.IP) bool {
+	if ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() || ip.IsUnspecified() {
+		return true
+	}
+	for _, network := range nonRoutableNetworks {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// errInternalAddress 投递时连接的地址指向内部网络
+var errInternalAddress = errors.New("webhook address resolves to a private, loopback or link-local address")
+
+// dialControl 在建立连接前检查实际连接的IP，防止保存地址后通过DNS重绑定指向内部网络
+func dialControl(network, address string, _ syscall.RawConn) error {
+	if !notifyURLPolicy.BlockPrivateNetworks {
+		return nil
+	}
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	ip := net.ParseIP(host)
+	if ip == nil || isInternalIP(ip) {
+		return fmt.Errorf("%w: %s", errInternalAddress, address)
+	}
+	return nil
+}
+
+// maxWebhookRedirects webhook投递跟随重定向的次数上限
+const maxWebhookRedirects = 5
+
+// checkWebhookRedirect 开启 BlockPrivateNetworks 时按当前策略重新校验重定向目标，防止通过302跳转到内部地址
+func checkWebhookRedirect(req *http.Request, via []*http.Request) error {
+	if len(via) >= maxWebhookRedirects {
+		return fmt.Errorf("stopped after %d redirects", maxWebhookRedirects)
+	}
+	if !notifyURLPolicy.BlockPrivateNetworks {
+		return nil
+	}
+	if err := validateWebhookURL(req.URL.String(), notifyURLPolicy); err != nil {
+		return fmt.Errorf("webhook redirect rejected: %s", err.Message())
+	}
+	return nil
+}
@@ -0,0 +1,135 @@
+package openapi
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// blockPrivateNetworks 在测试期间开启内部网络拦截
+func blockPrivateNetworks(t *testing.T) {
+	orig := notifyURLPolicy
+	t.Cleanup(func() { SetNotifyURLPolicy(orig) })
+	SetNotifyURLPolicy(NotifyURLPolicy{RequireHTTPS: true, BlockPrivateNetworks: true})
+}
+
+func TestIsInternalIP(t *testing.T) {
+	cases := map[string]bool{
+		"127.0.0.1":       true,
+		"10.1.2.3":        true,
+		"172.16.0.1":      true,
+		"192.168.1.1":     true,
+		"169.254.169.254": true,
+		"100.64.0.1":      true,
+		"100.127.255.255": true,
+		"0.0.0.0":         true,
+		"0.1.2.3":         true,
+		"::1":             true,
+		"fe80::1":         true,
+		"fd00::1":         true,
+		"::ffff:10.0.0.1": true,
+		"100.128.0.1":     false,
+		"8.8.8.8":         false,
+		"2001:4860::8888": false,
+	}
+	for addr, want := range cases {
+		if got := isInternalIP(net.ParseIP(addr)); got != want {
+			t.Errorf("isInternalIP(%s) = %v, want %v", addr, got, want)
+		}
+	}
+}
+
+func TestValidateWebhookURLResolvesHost(t *testing.T) {
+	orig := lookupIP
+	defer func() { lookupIP = orig }()
+	lookupIP = func(host string) ([]net.IP, error) {
+		if host == "internal.example.com" {
+			return []net.IP{net.ParseIP("100.64.1.1")}, nil
+		}
+		return []net.IP{net.ParseIP("93.184.216.34")}, nil
+	}
+
+	policy := NotifyURLPolicy{BlockPrivateNetworks: true}
+	if err := validateWebhookURL("https://public.example.com/hook", policy); err != nil {
+		t.Fatalf("public host rejected: %v", err)
+	}
+	err := validateWebhookURL("https://internal.example.com/hook", policy)
+	if err == nil || err.Code() != "notify_url_private_network" {
+		t.Fatalf("internal host error = %v, want notify_url_private_network", err)
+	}
+
+	// 默认策略不拦截内部网络地址
+	if err := validateWebhookURL("https://internal.example.com/hook", DefaultNotifyURLPolicy); err != nil {
+		t.Fatalf("default policy rejected internal host: %v", err)
+	}
+}
+
+func TestDefaultWebhookClientRefusesInternalAddressAtDial(t *testing.T) {
+	blockPrivateNetworks(t)
+
+	// 模拟保存时校验通过、投递时DNS已指向内部地址的场景：直接连接回环地址的服务
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("request reached internal server")
+	}))
+	defer server.Close()
+
+	req, _ := http.NewRequestWithContext(context.Background(), http.MethodPost, server.URL, nil)
+	resp, err := defaultWebhookClient.Do(req)
+	if err == nil {
+		resp.Body.Close()
+		t.Fatal("expected dial to loopback address to be refused")
+	}
+	if !errors.Is(err, errInternalAddress) {
+		t.Fatalf("error = %v, want errInternalAddress", err)
+	}
+}
+
+func TestDefaultWebhookClientAllowsInternalAddressByDefault(t *testing.T) {
+	orig := notifyURLPolicy
+	defer SetNotifyURLPolicy(orig)
+	SetNotifyURLPolicy(DefaultNotifyURLPolicy)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	resp, err := defaultWebhookClient.Post(server.URL, "application/json", nil)
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNoContent {
+		t.Fatalf("status = %d, want %d", resp.StatusCode, http.StatusNoContent)
+	}
+}
+
+func TestCheckWebhookRedirectRejectsMetadataAddress(t *testing.T) {
+	blockPrivateNetworks(t)
+	via := []*http.Request{httptest.NewRequest(http.MethodPost, "https://public.example.com/hook", nil)}
+
+	req := httptest.NewRequest(http.MethodGet, "http://169.254.169.254/latest/meta-data/", nil)
+	if err := checkWebhookRedirect(req, via); err == nil {
+		t.Fatal("expected redirect to metadata address to be rejected")
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "https://93.184.216.34/hook", nil)
+	if err := checkWebhookRedirect(req, via); err != nil {
+		t.Fatalf("redirect to public address rejected: %v", err)
+	}
+}
+
+func TestCheckWebhookRedirectAllowsInternalAddressByDefault(t *testing.T) {
+	orig := notifyURLPolicy
+	defer SetNotifyURLPolicy(orig)
+	SetNotifyURLPolicy(DefaultNotifyURLPolicy)
+
+	via := []*http.Request{httptest.NewRequest(http.MethodPost, "https://public.example.com/hook", nil)}
+	req := httptest.NewRequest(http.MethodGet, "http://10.0.0.5/hook", nil)
+	if err := checkWebhookRedirect(req, via); err != nil {
+		t.Fatalf("redirect to internal subscriber rejected with the default policy: %v", err)
+	}
+}
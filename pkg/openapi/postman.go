@@ -0,0 +1,203 @@
+package openapi
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+const postmanSchemaURL = "https://schema.getpostman.com/json/collection/v2.1.0/collection.json"
+
+type postmanCollection struct {
+	Info     postmanInfo       `json:"info"`
+	Item     []postmanFolder   `json:"item"`
+	Auth     *postmanAuth      `json:"auth,omitempty"`
+	Variable []postmanKeyValue `json:"variable,omitempty"`
+}
+
+type postmanInfo struct {
+	Name   string `json:"name"`
+	Schema string `json:"schema"`
+}
+
+type postmanFolder struct {
+	Name string        `json:"name"`
+	Item []postmanItem `json:"item"`
+}
+
+type postmanItem struct {
+	Name    string         `json:"name"`
+	Request postmanRequest `json:"request"`
+}
+
+type postmanRequest struct {
+	Method      string            `json:"method"`
+	Header      []postmanKeyValue `json:"header"`
+	URL         postmanURL        `json:"url"`
+	Body        *postmanBody      `json:"body,omitempty"`
+	Description string            `json:"description,omitempty"`
+}
+
+type postmanURL struct {
+	Raw   string            `json:"raw"`
+	Host  []string          `json:"host"`
+	Path  []string          `json:"path"`
+	Query []postmanKeyValue `json:"query,omitempty"`
+}
+
+type postmanBody struct {
+	Mode    string                 `json:"mode"`
+	Raw     string                 `json:"raw"`
+	Options map[string]interface{} `json:"options,omitempty"`
+}
+
+type postmanAuth struct {
+	Type  string            `json:"type"`
+	Basic []postmanKeyValue `json:"basic"`
+}
+
+type postmanKeyValue struct {
+	Key         string `json:"key"`
+	Value       string `json:"value"`
+	Type        string `json:"type,omitempty"`
+	Description string `json:"description,omitempty"`
+	Disabled    bool   `json:"disabled,omitempty"`
+}
+
+// ToPostmanCollection exports the documentation as a Postman v2.1 collection grouped by tag
+// Path parameters become collection variables, request examples pre-fill the JSON body,
+// and the collection uses Basic auth with {{client_id}} / {{client_secret}} placeholders
+func (d *ApiDocumentation) ToPostmanCollection(baseURL string) ([]byte, error) {
+	name := d.Info.Title
+	if name == "" {
+		name = "API"
+	}
+
+	collection := postmanCollection{
+		Info: postmanInfo{Name: name, Schema: postmanSchemaURL},
+		Item: make([]postmanFolder, 0),
+		Auth: &postmanAuth{
+			Type: "basic",
+			Basic: []postmanKeyValue{
+				{Key: "username", Value: "{{client_id}}", Type: "string"},
+				{Key: "password", Value: "{{client_secret}}", Type: "string"},
+			},
+		},
+		Variable: []postmanKeyValue{
+			{Key: "baseUrl", Value: strings.TrimSuffix(baseURL, "/")},
+			{Key: "client_id", Value: ""},
+			{Key: "client_secret", Value: ""},
+		},
+	}
+
+	folderIndex := make(map[string]int)
+	seenVariables := make(map[string]bool)
+
+	for _, api := range d.Apis {
+		tag := "default"
+		if len(api.Tags) > 0 {
+			tag = api.Tags[0]
+		}
+		idx, ok := folderIndex[tag]
+		if !ok {
+			idx = len(collection.Item)
+			folderIndex[tag] = idx
+			collection.Item = append(collection.Item, postmanFolder{Name: tag, Item: make([]postmanItem, 0)})
+		}
+
+		item, err := postmanRequestItem(api)
+		if err != nil {
+			return nil, err
+		}
+		collection.Item[idx].Item = append(collection.Item[idx].Item, item)
+
+		for _, param := range api.Parameters {
+			if param.In == "path" && !seenVariables[param.Name] {
+				seenVariables[param.Name] = true
+				collection.Variable = append(collection.Variable, postmanKeyValue{
+					Key:         param.Name,
+					Value:       "",
+					Description: param.Description,
+				})
+			}
+		}
+	}
+
+	return json.MarshalIndent(collection, "", "  ")
+}
+
+// postmanRequestItem converts a single documented endpoint to a Postman request
+func postmanRequestItem(api ApiEndpoint) (postmanItem, error) {
+	name := api.Summary
+	if name == "" {
+		name = api.Description
+	}
+	if name == "" {
+		name = api.Method + " " + api.Path
+	}
+
+	// Path parameters (":id") are referenced as collection variables ("{{id}}")
+	var path []string
+	for _, segment := range strings.Split(strings.Trim(api.Path, "/"), "/") {
+		if segment == "" {
+			continue
+		}
+		if strings.HasPrefix(segment, ":") {
+			segment = "{{" + strings.TrimPrefix(segment, ":") + "}}"
+		}
+		path = append(path, segment)
+	}
+
+	request := postmanRequest{
+		Method:      api.Method,
+		Header:      make([]postmanKeyValue, 0),
+		Description: api.Description,
+		URL: postmanURL{
+			Raw:  "{{baseUrl}}/" + strings.Join(path, "/"),
+			Host: []string{"{{baseUrl}}"},
+			Path: path,
+		},
+	}
+
+	var query []string
+	for _, param := range api.Parameters {
+		kv := postmanKeyValue{
+			Key:         param.Name,
+			Value:       "",
+			Description: param.Description,
+			Disabled:    !param.Required,
+		}
+		switch param.In {
+		case "query":
+			request.URL.Query = append(request.URL.Query, kv)
+			if param.Required {
+				query = append(query, param.Name+"=")
+			}
+		case "header":
+			request.Header = append(request.Header, kv)
+		}
+	}
+	if len(query) > 0 {
+		request.URL.Raw += "?" + strings.Join(query, "&")
+	}
+
+	if api.Request != nil {
+		request.Header = append(request.Header, postmanKeyValue{Key: "Content-Type", Value: "application/json"})
+		raw := "{}"
+		if api.Request.Example != nil {
+			data, err := json.MarshalIndent(api.Request.Example, "", "  ")
+			if err != nil {
+				return postmanItem{}, err
+			}
+			raw = string(data)
+		}
+		request.Body = &postmanBody{
+			Mode: "raw",
+			Raw:  raw,
+			Options: map[string]interface{}{
+				"raw": map[string]string{"language": "json"},
+			},
+		}
+	}
+
+	return postmanItem{Name: name, Request: request}, nil
+}
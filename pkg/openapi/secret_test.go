@@ -0,0 +1,61 @@
+package openapi
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestVerifyClientSecret(t *testing.T) {
+	bcryptHash, err := HashClientSecret("s3cret")
+	if err != nil {
+		t.Fatalf("HashClientSecret: %v", err)
+	}
+	argon2Hash, err := HashClientSecretArgon2("s3cret")
+	if err != nil {
+		t.Fatalf("HashClientSecretArgon2: %v", err)
+	}
+	if bcryptHash == "s3cret" || !strings.HasPrefix(argon2Hash, "$argon2id$") {
+		t.Fatalf("unexpected hashes %q %q", bcryptHash, argon2Hash)
+	}
+
+	tests := []struct {
+		name   string
+		stored string
+	}{
+		{"bcrypt", bcryptHash},
+		{"argon2id", argon2Hash},
+		{"plaintext", "s3cret"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if !VerifyClientSecret("s3cret", tt.stored) {
+				t.Fatal("matching secret should verify")
+			}
+			for _, wrong := range []string{"", "s3cre", "s3cret!", "S3CRET"} {
+				if VerifyClientSecret(wrong, tt.stored) {
+					t.Fatalf("secret %q should not verify", wrong)
+				}
+			}
+		})
+	}
+}
+
+func TestHashClientSecretUsesRandomSalt(t *testing.T) {
+	first, _ := HashClientSecretArgon2("s3cret")
+	second, _ := HashClientSecretArgon2("s3cret")
+	if first == second {
+		t.Fatal("argon2id hashes of the same secret should differ")
+	}
+}
+
+func TestVerifyClientSecretMalformedArgon2(t *testing.T) {
+	for _, stored := range []string{
+		"$argon2id$v=19$m=65536,t=1,p=4$onlysalt",
+		"$argon2id$v=18$m=65536,t=1,p=4$c2FsdA$aGFzaA",
+		"$argon2id$v=19$m=65536,t=1,p=4$!!!$aGFzaA",
+	} {
+		if VerifyClientSecret("s3cret", stored) {
+			t.Fatalf("malformed hash %q should not verify", stored)
+		}
+	}
+}
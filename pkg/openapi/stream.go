@@ -0,0 +1,125 @@
+package openapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/flaboy/pin"
+)
+
+// StreamFormat 流式响应中逐条数据的编码格式
+type StreamFormat string
+
+const (
+	StreamNDJSON    StreamFormat = "ndjson"     // 每行一个JSON对象（默认）
+	StreamJSONArray StreamFormat = "json_array" // 整体为一个JSON数组
+)
+
+// streamFlushInterval 逐条编码时每写出多少条刷新一次
+const streamFlushInterval = 100
+
+// StreamResponse 流式响应，处理器返回它时 HandleApiRequest 直接写出内容，不在内存中整体编码
+// Reader 与 Items 二选一：Reader 的内容原样输出（实现 io.Closer 时写完后关闭）；
+// Items 中的数据按 Format 逐条编码，生产者应在写完后关闭通道，并在 c.Request.Context() 结束时停止发送
+// 处理器也可以直接返回 io.Reader，等同于只设置 Reader 的 StreamResponse
+type StreamResponse struct {
+	Reader      io.Reader
+	Items       <-chan interface{}
+	Format      StreamFormat
+	ContentType string // 为空时根据 Reader/Format 推断
+	Filename    string // 不为空时以附件形式下载
+}
+
+// renderResponse 渲染处理器的返回值，流式响应直接写出，其余交给 c.Render
+func renderResponse(c *pin.Context, response interface{}) error {
+	switch resp := response.(type) {
+	case *StreamResponse:
+		return writeStream(c, resp)
+	case io.Reader:
+		return writeStream(c, &StreamResponse{Reader: resp})
+	default:
+		return c.Render(response)
+	}
+}
+
+// writeStream 写出流式响应
+func writeStream(c *pin.Context, resp *StreamResponse) error {
+	if closer, ok := resp.Reader.(io.Closer); ok {
+		defer closer.Close()
+	}
+
+	contentType := resp.ContentType
+	if contentType == "" {
+		switch {
+		case resp.Reader != nil:
+			contentType = "application/octet-stream"
+		case resp.Format == StreamJSONArray:
+			contentType = "application/json"
+		default:
+			contentType = "application/x-ndjson"
+		}
+	}
+
+	c.Header("Content-Type", contentType)
+	if resp.Filename != "" {
+		c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", resp.Filename))
+	}
+	c.Status(http.StatusOK)
+
+	if resp.Reader != nil {
+		_, err := io.Copy(c.Writer, resp.Reader)
+		return err
+	}
+	if resp.Items == nil {
+		return nil
+	}
+	return writeStreamItems(c, resp.Items, resp.Format == StreamJSONArray)
+}
+
+// writeStreamItems 逐条编码通道中的数据，客户端断开时停止
+func writeStreamItems(c *pin.Context, items <-chan interface{}, asArray bool) error {
+	ctx := c.Request.Context()
+	encoder := json.NewEncoder(c.Writer)
+
+	if asArray {
+		if _, err := io.WriteString(c.Writer, "["); err != nil {
+			return err
+		}
+	}
+
+	for count := 0; ; count++ {
+		var item interface{}
+		var ok bool
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case item, ok = <-items:
+		}
+		if !ok {
+			break
+		}
+
+		if asArray && count > 0 {
+			if _, err := io.WriteString(c.Writer, ","); err != nil {
+				return err
+			}
+		}
+		if err := encoder.Encode(item); err != nil {
+			return err
+		}
+
+		if count%streamFlushInterval == streamFlushInterval-1 {
+			c.Writer.Flush()
+		}
+	}
+
+	if asArray {
+		if _, err := io.WriteString(c.Writer, "]"); err != nil {
+			return err
+		}
+	}
+	c.Writer.Flush()
+	return nil
+}
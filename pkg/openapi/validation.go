@@ -0,0 +1,85 @@
+package openapi
+
+import (
+	"reflect"
+	"strings"
+
+	"github.com/flaboy/pin/usererrors"
+	"github.com/go-playground/validator/v10"
+)
+
+// ValidationFieldError 单个字段的校验失败信息
+type ValidationFieldError struct {
+	Field string `json:"field"` // JSON字段路径，例如 "address.city"
+	Rule  string `json:"rule"`  // 失败的binding规则，例如 "required"、"min"
+	Param string `json:"param,omitempty"`
+}
+
+// String 格式化为 "field:rule" 或 "field:rule=param"
+func (f ValidationFieldError) String() string {
+	if f.Param != "" {
+		return f.Field + ":" + f.Rule + "=" + f.Param
+	}
+	return f.Field + ":" + f.Rule
+}
+
+// ValidationFieldErrors 将校验错误转换为按JSON字段名描述的列表
+func ValidationFieldErrors(requestType reflect.Type, errs validator.ValidationErrors) []ValidationFieldError {
+	fields := make([]ValidationFieldError, 0, len(errs))
+	for _, fe := range errs {
+		fields = append(fields, ValidationFieldError{
+			Field: jsonFieldPath(requestType, fe.StructNamespace()),
+			Rule:  fe.Tag(),
+			Param: fe.Param(),
+		})
+	}
+	return fields
+}
+
+// newValidationError 生成校验失败的用户错误
+// message 格式为以 "; " 分隔的 "field:rule[=param]" 列表，前端可据此定位输入项
+func newValidationError(requestType reflect.Type, errs validator.ValidationErrors) *usererrors.Error {
+	fields := ValidationFieldErrors(requestType, errs)
+	parts := make([]string, len(fields))
+	for i, field := range fields {
+		parts[i] = field.String()
+	}
+	return usererrors.New("validation_failed", strings.Join(parts, "; "))
+}
+
+// jsonFieldPath 将校验器的结构体命名空间（如 "Req.Address.City"）转换为JSON字段路径（如 "address.city"）
+func jsonFieldPath(t reflect.Type, namespace string) string {
+	parts := strings.Split(namespace, ".")
+	if len(parts) > 1 {
+		parts = parts[1:] // 去掉顶层结构体名
+	}
+
+	path := make([]string, 0, len(parts))
+	for _, part := range parts {
+		// 切片/map元素形如 "Items[0]"
+		name, index, _ := strings.Cut(part, "[")
+		if index != "" {
+			index = "[" + index
+		}
+
+		for t != nil && (t.Kind() == reflect.Ptr || t.Kind() == reflect.Slice || t.Kind() == reflect.Array || t.Kind() == reflect.Map) {
+			t = t.Elem()
+		}
+
+		jsonName := name
+		if t != nil && t.Kind() == reflect.Struct {
+			if field, ok := t.FieldByName(name); ok {
+				if tag := strings.Split(field.Tag.Get("json"), ",")[0]; tag != "" && tag != "-" {
+					jsonName = tag
+				}
+				t = field.Type
+			} else {
+				t = nil
+			}
+		}
+
+		path = append(path, jsonName+index)
+	}
+
+	return strings.Join(path, ".")
+}
@@ -2,78 +2,188 @@ package routes
 
 import (
 	"errors"
-	"fmt"
+	"log/slog"
+	"net/http"
+	"sort"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/flaboy/pin"
+	"github.com/gin-gonic/gin"
 )
 
 // GinRouter 是一个基于gin的简化路由器，提供类似gin的API但适配pin.Context
 type GinRouter struct {
-	basePath string
-	routes   []RouteHandler
+	basePath    string
+	routes      []RouteHandler
+	middlewares []func(*pin.Context) error // 路由器级中间件，作用于所有路由
+	logger      *slog.Logger               // 路由诊断日志，为nil时不输出
+	autoOptions bool                       // 是否自动响应OPTIONS请求
+	cors        *CORSConfig                // 预检请求的CORS配置，为nil时不输出 Access-Control-Allow-* 头
+	slashPolicy TrailingSlashPolicy        // 末尾斜杠处理策略
+}
+
+// TrailingSlashPolicy 末尾斜杠处理策略，根路径 "/" 不受影响
+type TrailingSlashPolicy int
+
+const (
+	// TrailingSlashLenient 忽略末尾斜杠，/apps 与 /apps/ 视为同一路径（默认）
+	TrailingSlashLenient TrailingSlashPolicy = iota
+	// TrailingSlashStrict 末尾斜杠必须与注册的路由一致
+	TrailingSlashStrict
+	// TrailingSlashRedirect 末尾斜杠与注册的路由不一致时重定向到注册的形式
+	TrailingSlashRedirect
+)
+
+// CORSConfig 自动响应OPTIONS预检请求时使用的CORS配置
+type CORSConfig struct {
+	AllowOrigins     []string      // 允许的来源，包含 "*" 时允许任意来源
+	AllowHeaders     []string      // 允许的请求头
+	AllowCredentials bool          // 是否允许携带凭证
+	MaxAge           time.Duration // 预检结果缓存时间，为0时不输出
 }
 
 // RouteHandler 路由处理器
 type RouteHandler struct {
+	Method      string
+	Path        string
+	Handler     func(*pin.Context) error
+	specificity []int // 每段路径的具体程度，用于匹配优先级排序
+}
+
+// 路径段的具体程度：字面量 > 参数 > 通配符
+const (
+	segmentWildcard = iota
+	segmentParam
+	segmentLiteral
+)
+
+// ErrMethodNotAllowed 路径存在但请求方法未注册，可映射为HTTP 405
+var ErrMethodNotAllowed = errors.New("method not allowed")
+
+// MethodNotAllowedError 携带该路径允许的方法列表，可用于设置 Allow 响应头
+type MethodNotAllowedError struct {
 	Method  string
 	Path    string
-	Handler func(*pin.Context) error
+	Allowed []string
+}
+
+func (e *MethodNotAllowedError) Error() string {
+	return "method not allowed: " + e.Method + " " + e.Path
+}
+
+// Is 使 errors.Is(err, ErrMethodNotAllowed) 成立
+func (e *MethodNotAllowedError) Is(target error) bool {
+	return target == ErrMethodNotAllowed
+}
+
+// AllowHeader 返回 Allow 响应头的值
+func (e *MethodNotAllowedError) AllowHeader() string {
+	return strings.Join(e.Allowed, ", ")
 }
 
 // NewGinRouter 创建新的路由器
 func NewGinRouter(basePath string) *GinRouter {
 	return &GinRouter{
-		basePath: strings.TrimSuffix(basePath, "/"),
-		routes:   make([]RouteHandler, 0),
+		basePath:    strings.TrimSuffix(basePath, "/"),
+		routes:      make([]RouteHandler, 0),
+		autoOptions: true,
 	}
 }
 
+// SetAutoOptions 设置是否自动响应OPTIONS请求（默认开启）
+// 开启时，对已注册任意方法但未注册OPTIONS的路径返回 204，并输出 Allow 及CORS响应头
+func (r *GinRouter) SetAutoOptions(enabled bool) {
+	r.autoOptions = enabled
+}
+
+// SetTrailingSlashPolicy 设置末尾斜杠处理策略
+func (r *GinRouter) SetTrailingSlashPolicy(policy TrailingSlashPolicy) {
+	r.slashPolicy = policy
+}
+
+// SetCORS 设置自动响应OPTIONS预检请求时输出的CORS配置，传入nil时只输出 Allow 头
+func (r *GinRouter) SetCORS(cors *CORSConfig) {
+	r.cors = cors
+}
+
+// SetLogger 设置路由诊断日志，传入nil关闭日志（默认关闭）
+func (r *GinRouter) SetLogger(logger *slog.Logger) {
+	r.logger = logger
+}
+
+// Use 注册路由器级中间件，按注册顺序在所有匹配的处理器（包括路由组中间件）之前执行
+// 中间件返回错误时中断后续处理并返回该错误
+func (r *GinRouter) Use(middleware ...func(*pin.Context) error) {
+	r.middlewares = append(r.middlewares, middleware...)
+}
+
 // GET 注册GET路由
 func (r *GinRouter) GET(path string, handler func(*pin.Context) error) {
-	r.routes = append(r.routes, RouteHandler{
-		Method:  "GET",
-		Path:    path,
-		Handler: handler,
-	})
+	r.addRoute("GET", path, handler)
 }
 
 // POST 注册POST路由
 func (r *GinRouter) POST(path string, handler func(*pin.Context) error) {
-	r.routes = append(r.routes, RouteHandler{
-		Method:  "POST",
-		Path:    path,
-		Handler: handler,
-	})
+	r.addRoute("POST", path, handler)
 }
 
 // PUT 注册PUT路由
 func (r *GinRouter) PUT(path string, handler func(*pin.Context) error) {
-	r.routes = append(r.routes, RouteHandler{
-		Method:  "PUT",
-		Path:    path,
-		Handler: handler,
-	})
+	r.addRoute("PUT", path, handler)
 }
 
 // DELETE 注册DELETE路由
 func (r *GinRouter) DELETE(path string, handler func(*pin.Context) error) {
-	r.routes = append(r.routes, RouteHandler{
-		Method:  "DELETE",
-		Path:    path,
-		Handler: handler,
-	})
+	r.addRoute("DELETE", path, handler)
 }
 
 // PATCH 注册PATCH路由
 func (r *GinRouter) PATCH(path string, handler func(*pin.Context) error) {
+	r.addRoute("PATCH", path, handler)
+}
+
+// addRoute 注册路由，并按具体程度排序，使字面量路径优先于参数路径、参数路径优先于通配符
+func (r *GinRouter) addRoute(method, path string, handler func(*pin.Context) error) {
 	r.routes = append(r.routes, RouteHandler{
-		Method:  "PATCH",
-		Path:    path,
-		Handler: handler,
+		Method:      method,
+		Path:        path,
+		Handler:     handler,
+		specificity: routeSpecificity(path),
+	})
+	sort.SliceStable(r.routes, func(i, j int) bool {
+		return moreSpecific(r.routes[i].specificity, r.routes[j].specificity)
 	})
 }
 
+// routeSpecificity 计算路由每段的具体程度
+func routeSpecificity(pattern string) []int {
+	parts := strings.Split(strings.Trim(pattern, "/"), "/")
+	scores := make([]int, len(parts))
+	for i, part := range parts {
+		switch {
+		case strings.HasPrefix(part, "*"):
+			scores[i] = segmentWildcard
+		case strings.HasPrefix(part, ":"):
+			scores[i] = segmentParam
+		default:
+			scores[i] = segmentLiteral
+		}
+	}
+	return scores
+}
+
+// moreSpecific 逐段比较两个路由的具体程度，a 更具体时返回 true
+func moreSpecific(a, b []int) bool {
+	for i := 0; i < len(a) && i < len(b); i++ {
+		if a[i] != b[i] {
+			return a[i] > b[i]
+		}
+	}
+	return false
+}
+
 // HandleRequest 处理请求，类似gin的路由匹配
 func (r *GinRouter) HandleRequest(c *pin.Context, method, requestPath string) error {
 	// 移除basePath前缀
@@ -86,22 +196,128 @@ func (r *GinRouter) HandleRequest(c *pin.Context, method, requestPath string) er
 		requestPath = "/"
 	}
 
+	var allowed []string
+	// Redirect 模式下只有不存在精确匹配（末尾斜杠也一致）的路由时才重定向，记录第一个仅末尾斜杠不同的路由
+	redirect, redirectWithSlash := false, false
 	for _, route := range r.routes {
 		if route.Method == method {
-			fmt.Printf("[GinRouter] Matching route: %s %s %s\n", method, route.Path, requestPath)
+			if r.logger != nil {
+				r.logger.Debug("[GinRouter] Matching route", "method", method, "pattern", route.Path, "path", requestPath)
+			}
 			if match, params := r.matchPath(route.Path, requestPath); match {
+				if r.slashPolicy != TrailingSlashLenient && hasTrailingSlash(route.Path) != hasTrailingSlash(requestPath) {
+					if r.slashPolicy == TrailingSlashRedirect && !redirect {
+						redirect, redirectWithSlash = true, hasTrailingSlash(route.Path)
+					}
+					continue
+				}
 				// 设置路径参数到Context
-				for key, value := range params {
-					c.Set("param_"+key, value)
+				setParams(c, params)
+				// 执行路由器级中间件
+				for _, middleware := range r.middlewares {
+					if err := middleware(c); err != nil {
+						return err
+					}
 				}
 				return route.Handler(c)
 			}
+		} else if match, _ := r.matchPath(route.Path, requestPath); match && (r.slashPolicy != TrailingSlashStrict || hasTrailingSlash(route.Path) == hasTrailingSlash(requestPath)) {
+			// 路径匹配但方法不匹配，记录允许的方法
+			allowed = appendUnique(allowed, route.Method)
+		}
+	}
+
+	if redirect {
+		r.redirectTrailingSlash(c, redirectWithSlash)
+		return nil
+	}
+
+	if method == http.MethodOptions && r.autoOptions && len(allowed) > 0 {
+		r.handleOptions(c, allowed)
+		return nil
+	}
+
+	if len(allowed) > 0 {
+		return &MethodNotAllowedError{
+			Method:  method,
+			Path:    requestPath,
+			Allowed: allowed,
 		}
 	}
 
 	return errors.New("route not found: " + method + " " + requestPath)
 }
 
+// hasTrailingSlash 判断路径是否以斜杠结尾，根路径返回 false
+func hasTrailingSlash(path string) bool {
+	return len(path) > 1 && strings.HasSuffix(path, "/")
+}
+
+// redirectTrailingSlash 重定向到添加或去掉末尾斜杠后的路径
+// GET/HEAD 使用 301，其他方法使用 308 以保留请求方法和请求体
+func (r *GinRouter) redirectTrailingSlash(c *pin.Context, withSlash bool) {
+	target := *c.Request.URL
+	if withSlash {
+		target.Path = strings.TrimSuffix(target.Path, "/") + "/"
+	} else {
+		target.Path = strings.TrimRight(target.Path, "/")
+	}
+	target.RawPath = ""
+
+	code := http.StatusPermanentRedirect
+	if c.Request.Method == http.MethodGet || c.Request.Method == http.MethodHead {
+		code = http.StatusMovedPermanently
+	}
+	c.Redirect(code, target.RequestURI())
+}
+
+// handleOptions 自动响应OPTIONS请求，输出 Allow 及配置的CORS响应头
+func (r *GinRouter) handleOptions(c *pin.Context, allowed []string) {
+	allowHeader := strings.Join(appendUnique(allowed, http.MethodOptions), ", ")
+	c.Header("Allow", allowHeader)
+
+	if r.cors != nil {
+		origin := c.GetHeader("Origin")
+		if allowOrigin := r.cors.allowOrigin(origin); allowOrigin != "" {
+			c.Header("Access-Control-Allow-Origin", allowOrigin)
+			c.Header("Access-Control-Allow-Methods", allowHeader)
+			if allowOrigin != "*" {
+				c.Header("Vary", "Origin")
+			}
+			if len(r.cors.AllowHeaders) > 0 {
+				c.Header("Access-Control-Allow-Headers", strings.Join(r.cors.AllowHeaders, ", "))
+			} else if requested := c.GetHeader("Access-Control-Request-Headers"); requested != "" {
+				c.Header("Access-Control-Allow-Headers", requested)
+			}
+			if r.cors.AllowCredentials {
+				c.Header("Access-Control-Allow-Credentials", "true")
+			}
+			if r.cors.MaxAge > 0 {
+				c.Header("Access-Control-Max-Age", strconv.Itoa(int(r.cors.MaxAge.Seconds())))
+			}
+		}
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// allowOrigin 返回 Access-Control-Allow-Origin 的值，来源不被允许时返回空字符串
+func (cfg *CORSConfig) allowOrigin(origin string) string {
+	for _, allowed := range cfg.AllowOrigins {
+		if allowed == "*" {
+			// 携带凭证时不能使用通配符，回显请求来源
+			if cfg.AllowCredentials && origin != "" {
+				return origin
+			}
+			return "*"
+		}
+		if origin != "" && strings.EqualFold(allowed, origin) {
+			return origin
+		}
+	}
+	return ""
+}
+
 // matchPath 路径匹配，支持参数（:param）和通配符（*）
 func (r *GinRouter) matchPath(pattern, path string) (bool, map[string]string) {
 	params := make(map[string]string)
@@ -152,7 +368,54 @@ func (r *GinRouter) matchPath(pattern, path string) (bool, map[string]string) {
 	return len(patternParts) == len(pathParts), params
 }
 
-// GetParam 从context获取路径参数
+// appendUnique 追加不重复的元素
+func appendUnique(list []string, value string) []string {
+	for _, v := range list {
+		if v == value {
+			return list
+		}
+	}
+	return append(list, value)
+}
+
+// paramsContextKey 保存当前路由全部路径参数的Context键
+const paramsContextKey = "route_params"
+
+// setParams 将路径参数写入Context
+// 参数同时写入 gin 的 c.Params，因此处理器中可以直接使用 c.Param(key)；同名参数会被覆盖
+func setParams(c *pin.Context, params map[string]string) {
+	for key, value := range params {
+		c.Set("param_"+key, value)
+
+		replaced := false
+		for i := range c.Params {
+			if c.Params[i].Key == key {
+				c.Params[i].Value = value
+				replaced = true
+				break
+			}
+		}
+		if !replaced {
+			c.Params = append(c.Params, gin.Param{Key: key, Value: value})
+		}
+	}
+	c.Set(paramsContextKey, params)
+}
+
+// GetParams 从context获取当前路由匹配到的全部路径参数
+func GetParams(c *pin.Context) map[string]string {
+	result := make(map[string]string)
+	if value, exists := c.Get(paramsContextKey); exists {
+		if params, ok := value.(map[string]string); ok {
+			for key, v := range params {
+				result[key] = v
+			}
+		}
+	}
+	return result
+}
+
+// GetParam 从context获取路径参数，与 c.Param(key) 等价，保留以兼容旧代码
 func GetParam(c *pin.Context, key string) string {
 	if value, exists := c.Get("param_" + key); exists {
 		if str, ok := value.(string); ok {
@@ -178,6 +441,20 @@ type GinRouterGroup struct {
 	middlewares []func(*pin.Context) error
 }
 
+// Group 在当前路由组内创建子路由组
+// 子路由组的前缀拼接在父路由组之后，中间件按由外到内的顺序执行（先父组，后子组）
+func (g *GinRouterGroup) Group(prefix string, middleware ...func(*pin.Context) error) *GinRouterGroup {
+	middlewares := make([]func(*pin.Context) error, 0, len(g.middlewares)+len(middleware))
+	middlewares = append(middlewares, g.middlewares...)
+	middlewares = append(middlewares, middleware...)
+
+	return &GinRouterGroup{
+		parent:      g.parent,
+		prefix:      g.prefix + prefix,
+		middlewares: middlewares,
+	}
+}
+
 // GET 组内GET路由
 func (g *GinRouterGroup) GET(path string, handler func(*pin.Context) error) {
 	g.parent.GET(g.prefix+path, g.wrapWithMiddleware(handler))
@@ -0,0 +1,334 @@
+package routes
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/flaboy/pin"
+	"github.com/gin-gonic/gin"
+)
+
+// serve 通过路由器处理请求，返回响应记录和处理结果
+func serve(r *GinRouter, method, target string) (*httptest.ResponseRecorder, error) {
+	gin.SetMode(gin.TestMode)
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(method, target, nil)
+	err := r.HandleRequest(&pin.Context{Context: c}, method, c.Request.URL.Path)
+	c.Writer.WriteHeaderNow()
+	return w, err
+}
+
+// named 返回将名称写入响应体的处理器
+func named(name string) func(*pin.Context) error {
+	return func(c *pin.Context) error {
+		c.String(http.StatusOK, name)
+		return nil
+	}
+}
+
+func TestTrailingSlashRedirectPrefersExactMatch(t *testing.T) {
+	r := NewGinRouter("")
+	r.SetTrailingSlashPolicy(TrailingSlashRedirect)
+	r.GET("/apps", named("no-slash"))
+	r.GET("/apps/", named("slash"))
+
+	for target, want := range map[string]string{"/apps": "no-slash", "/apps/": "slash"} {
+		w, err := serve(r, http.MethodGet, target)
+		if err != nil {
+			t.Fatalf("%s: %v", target, err)
+		}
+		if w.Code != http.StatusOK || w.Body.String() != want {
+			t.Errorf("%s = %d %q, want 200 %q", target, w.Code, w.Body.String(), want)
+		}
+	}
+}
+
+func TestTrailingSlashRedirectBothVariants(t *testing.T) {
+	cases := []struct {
+		route, target, location string
+	}{
+		{"/apps", "/apps/", "/apps"},
+		{"/apps/", "/apps", "/apps/"},
+		{"/apps/:id", "/apps/42/?tab=keys", "/apps/42?tab=keys"},
+	}
+	for _, tc := range cases {
+		r := NewGinRouter("")
+		r.SetTrailingSlashPolicy(TrailingSlashRedirect)
+		r.GET(tc.route, named("handler"))
+
+		w, err := serve(r, http.MethodGet, tc.target)
+		if err != nil {
+			t.Fatalf("%s: %v", tc.target, err)
+		}
+		if w.Code != http.StatusMovedPermanently || w.Header().Get("Location") != tc.location {
+			t.Errorf("%s = %d Location %q, want 301 %q", tc.target, w.Code, w.Header().Get("Location"), tc.location)
+		}
+	}
+}
+
+func TestTrailingSlashRedirectPreservesMethodForPost(t *testing.T) {
+	r := NewGinRouter("")
+	r.SetTrailingSlashPolicy(TrailingSlashRedirect)
+	r.POST("/apps", named("create"))
+
+	w, err := serve(r, http.MethodPost, "/apps/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if w.Code != http.StatusPermanentRedirect {
+		t.Fatalf("status = %d, want 308", w.Code)
+	}
+}
+
+func TestTrailingSlashPoliciesRootPath(t *testing.T) {
+	for _, policy := range []TrailingSlashPolicy{TrailingSlashLenient, TrailingSlashStrict, TrailingSlashRedirect} {
+		r := NewGinRouter("")
+		r.SetTrailingSlashPolicy(policy)
+		r.GET("/", named("root"))
+
+		w, err := serve(r, http.MethodGet, "/")
+		if err != nil {
+			t.Fatalf("policy %d: %v", policy, err)
+		}
+		if w.Code != http.StatusOK || w.Body.String() != "root" {
+			t.Errorf("policy %d: / = %d %q, want 200 root", policy, w.Code, w.Body.String())
+		}
+	}
+}
+
+func TestTrailingSlashStrictRejectsMismatch(t *testing.T) {
+	r := NewGinRouter("")
+	r.SetTrailingSlashPolicy(TrailingSlashStrict)
+	r.GET("/apps", named("apps"))
+
+	if _, err := serve(r, http.MethodGet, "/apps/"); err == nil {
+		t.Fatal("expected /apps/ not to match /apps in strict mode")
+	}
+}
+
+func TestRouterLogsNothingByDefault(t *testing.T) {
+	var buf bytes.Buffer
+	orig := slog.Default()
+	slog.SetDefault(slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug})))
+	defer slog.SetDefault(orig)
+
+	stdout := os.Stdout
+	read, write, _ := os.Pipe()
+	os.Stdout = write
+
+	r := NewGinRouter("")
+	r.GET("/apps/:id", named("app"))
+	serve(r, http.MethodGet, "/apps/1")
+	serve(r, http.MethodGet, "/missing")
+
+	write.Close()
+	os.Stdout = stdout
+	printed, _ := io.ReadAll(read)
+
+	if buf.Len() != 0 || len(printed) != 0 {
+		t.Fatalf("router produced output without a logger: slog=%q stdout=%q", buf.String(), printed)
+	}
+}
+
+func TestRouterLoggerReceivesDiagnostics(t *testing.T) {
+	var buf bytes.Buffer
+	r := NewGinRouter("")
+	r.SetLogger(slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug})))
+	r.GET("/apps", named("apps"))
+
+	serve(r, http.MethodGet, "/apps")
+	if !strings.Contains(buf.String(), "Matching route") {
+		t.Fatalf("logger output = %q, want matching diagnostics", buf.String())
+	}
+}
+
+func TestStaticRouteTakesPriorityOverParam(t *testing.T) {
+	// 注册顺序不影响优先级：参数路由先注册时字面量路由仍然优先
+	r := NewGinRouter("")
+	r.GET("/apps/:id", func(c *pin.Context) error {
+		c.String(http.StatusOK, "app:"+c.Param("id"))
+		return nil
+	})
+	r.GET("/apps/docs", named("docs"))
+	r.GET("/apps/*rest", named("wildcard"))
+
+	cases := map[string]string{
+		"/apps/docs":   "docs",
+		"/apps/42":     "app:42",
+		"/apps/42/key": "wildcard",
+	}
+	for target, want := range cases {
+		w, err := serve(r, http.MethodGet, target)
+		if err != nil {
+			t.Fatalf("%s: %v", target, err)
+		}
+		if w.Body.String() != want {
+			t.Errorf("%s = %q, want %q", target, w.Body.String(), want)
+		}
+	}
+}
+
+// recordingMiddleware 返回把名称追加到 calls 的中间件
+func recordingMiddleware(calls *[]string, name string) func(*pin.Context) error {
+	return func(c *pin.Context) error {
+		*calls = append(*calls, name)
+		return nil
+	}
+}
+
+func TestRouterMiddlewareRunsForGroupedAndUngroupedRoutes(t *testing.T) {
+	var calls []string
+	r := NewGinRouter("")
+	r.Use(recordingMiddleware(&calls, "router"))
+	r.GET("/health", func(c *pin.Context) error {
+		calls = append(calls, "health")
+		return nil
+	})
+	api := r.Group("/api", recordingMiddleware(&calls, "group"))
+	api.GET("/apps", func(c *pin.Context) error {
+		calls = append(calls, "apps")
+		return nil
+	})
+
+	for target, want := range map[string]string{
+		"/health":   "router,health",
+		"/api/apps": "router,group,apps",
+	} {
+		calls = nil
+		if _, err := serve(r, http.MethodGet, target); err != nil {
+			t.Fatalf("%s: %v", target, err)
+		}
+		if got := strings.Join(calls, ","); got != want {
+			t.Errorf("%s calls = %s, want %s", target, got, want)
+		}
+	}
+}
+
+func TestRouterMiddlewareErrorStopsHandler(t *testing.T) {
+	errDenied := errors.New("denied")
+	r := NewGinRouter("")
+	r.Use(func(c *pin.Context) error { return errDenied })
+	r.GET("/apps", func(c *pin.Context) error {
+		t.Error("handler must not run")
+		return nil
+	})
+
+	if _, err := serve(r, http.MethodGet, "/apps"); !errors.Is(err, errDenied) {
+		t.Fatalf("error = %v, want middleware error", err)
+	}
+}
+
+// servePreflight 通过路由器处理带 Origin 的 OPTIONS 预检请求
+func servePreflight(r *GinRouter, target, origin string) (*httptest.ResponseRecorder, error) {
+	gin.SetMode(gin.TestMode)
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodOptions, target, nil)
+	c.Request.Header.Set("Origin", origin)
+	c.Request.Header.Set("Access-Control-Request-Method", http.MethodPost)
+	c.Request.Header.Set("Access-Control-Request-Headers", "Content-Type")
+	err := r.HandleRequest(&pin.Context{Context: c}, http.MethodOptions, c.Request.URL.Path)
+	c.Writer.WriteHeaderNow()
+	return w, err
+}
+
+func TestOptionsPreflightForGetPostPath(t *testing.T) {
+	r := NewGinRouter("")
+	r.SetCORS(&CORSConfig{AllowOrigins: []string{"https://app.example.com"}, MaxAge: 10 * time.Minute})
+	r.GET("/apps", named("list"))
+	r.POST("/apps", named("create"))
+
+	w, err := servePreflight(r, "/apps", "https://app.example.com")
+	if err != nil {
+		t.Fatalf("preflight: %v", err)
+	}
+	if w.Code != http.StatusNoContent {
+		t.Fatalf("status = %d, want 204", w.Code)
+	}
+	want := map[string]string{
+		"Allow":                        "GET, POST, OPTIONS",
+		"Access-Control-Allow-Origin":  "https://app.example.com",
+		"Access-Control-Allow-Methods": "GET, POST, OPTIONS",
+		"Access-Control-Allow-Headers": "Content-Type",
+		"Access-Control-Max-Age":       "600",
+		"Vary":                         "Origin",
+	}
+	for header, value := range want {
+		if got := w.Header().Get(header); got != value {
+			t.Errorf("%s = %q, want %q", header, got, value)
+		}
+	}
+}
+
+func TestOptionsPreflightFromDisallowedOrigin(t *testing.T) {
+	r := NewGinRouter("")
+	r.SetCORS(&CORSConfig{AllowOrigins: []string{"https://app.example.com"}})
+	r.GET("/apps", named("list"))
+
+	w, err := servePreflight(r, "/apps", "https://evil.example.com")
+	if err != nil {
+		t.Fatalf("preflight: %v", err)
+	}
+	if w.Header().Get("Access-Control-Allow-Origin") != "" {
+		t.Fatalf("disallowed origin received CORS headers: %v", w.Header())
+	}
+	if w.Header().Get("Allow") != "GET, OPTIONS" {
+		t.Fatalf("Allow = %q, want GET, OPTIONS", w.Header().Get("Allow"))
+	}
+}
+
+func TestOptionsDisabledReturnsMethodNotAllowed(t *testing.T) {
+	r := NewGinRouter("")
+	r.SetAutoOptions(false)
+	r.GET("/apps", named("list"))
+	r.POST("/apps", named("create"))
+
+	_, err := serve(r, http.MethodOptions, "/apps")
+	var notAllowed *MethodNotAllowedError
+	if !errors.As(err, &notAllowed) || notAllowed.AllowHeader() != "GET, POST" {
+		t.Fatalf("error = %v, want method not allowed with GET, POST", err)
+	}
+}
+
+func TestNestedGroupsPrefixAndMiddlewareOrder(t *testing.T) {
+	var calls []string
+	r := NewGinRouter("/base")
+	r.Use(recordingMiddleware(&calls, "router"))
+	api := r.Group("/api", recordingMiddleware(&calls, "api"))
+	v1 := api.Group("/v1", recordingMiddleware(&calls, "v1"), recordingMiddleware(&calls, "v1-second"))
+	v1.GET("/apps/:id", func(c *pin.Context) error {
+		calls = append(calls, "handler:"+c.Param("id"))
+		return nil
+	})
+	// 子组的中间件不影响父组的路由
+	api.GET("/status", func(c *pin.Context) error {
+		calls = append(calls, "status")
+		return nil
+	})
+
+	for target, want := range map[string]string{
+		"/base/api/v1/apps/7": "router,api,v1,v1-second,handler:7",
+		"/base/api/status":    "router,api,status",
+	} {
+		calls = nil
+		if _, err := serve(r, http.MethodGet, target); err != nil {
+			t.Fatalf("%s: %v", target, err)
+		}
+		if got := strings.Join(calls, ","); got != want {
+			t.Errorf("%s calls = %s, want %s", target, got, want)
+		}
+	}
+
+	if _, err := serve(r, http.MethodGet, "/base/v1/apps/7"); err == nil {
+		t.Fatal("child group route must not be registered without the parent prefix")
+	}
+}